@@ -8,6 +8,8 @@ import (
 	"path/filepath"
 	"syscall"
 	"time"
+
+	"github.com/anthropics/altera/internal/message"
 )
 
 // Role represents the function an agent serves.
@@ -44,6 +46,7 @@ type Agent struct {
 	LastStallNotified time.Time `json:"last_stall_notified,omitempty"`
 	EscalationLevel   string    `json:"escalation_level,omitempty"`
 	LastEscalation    time.Time `json:"last_escalation,omitempty"`
+	BudgetWarned      float64   `json:"budget_warned,omitempty"` // limit the agent was last warned about
 }
 
 var (
@@ -163,17 +166,6 @@ const (
 	EscalationCritical = "critical"
 )
 
-// Heartbeat escalation thresholds.
-var (
-	HeartbeatWarnTimeout     = 3 * time.Minute
-	HeartbeatCriticalTimeout = 6 * time.Minute
-	HeartbeatDeadTimeout     = 10 * time.Minute
-)
-
-// HeartbeatTimeout is kept for backward compatibility with callers that
-// reference the original threshold. It equals HeartbeatWarnTimeout.
-var HeartbeatTimeout = HeartbeatWarnTimeout
-
 // CheckPID returns true if the agent's OS process is still running
 // (verified via signal 0).
 func CheckPID(a *Agent) bool {
@@ -193,16 +185,6 @@ func HeartbeatStaleness(a *Agent) time.Duration {
 	return time.Since(a.Heartbeat)
 }
 
-// CheckLiveness returns true if the agent's heartbeat is fresh and its
-// OS process still exists. It is preserved for backward compatibility;
-// new code should use CheckPID and HeartbeatStaleness directly.
-func CheckLiveness(a *Agent) bool {
-	if HeartbeatStaleness(a) > HeartbeatTimeout {
-		return false
-	}
-	return CheckPID(a)
-}
-
 // listAll reads every agent file in the store directory.
 func (s *Store) listAll() ([]*Agent, error) {
 	entries, err := os.ReadDir(s.dir)
@@ -254,6 +236,45 @@ func (s *Store) ListByStatus(status Status) ([]*Agent, error) {
 	return out, nil
 }
 
+// RoleOf returns the role of the agent with the given ID, or "" if it
+// cannot be read.
+func (s *Store) RoleOf(id string) string {
+	a, err := s.Get(id)
+	if err != nil {
+		return ""
+	}
+	return string(a.Role)
+}
+
+// Recipient returns the agent with the given ID as a message recipient.
+// Together with Recipients it satisfies message.Directory.
+func (s *Store) Recipient(id string) (message.Recipient, bool) {
+	a, err := s.Get(id)
+	if err != nil {
+		return message.Recipient{}, false
+	}
+	return a.recipient(), true
+}
+
+// Recipients returns every agent not marked dead as a message recipient.
+func (s *Store) Recipients() ([]message.Recipient, error) {
+	all, err := s.listAll()
+	if err != nil {
+		return nil, err
+	}
+	var out []message.Recipient
+	for _, a := range all {
+		if a.Status != StatusDead {
+			out = append(out, a.recipient())
+		}
+	}
+	return out, nil
+}
+
+func (a *Agent) recipient() message.Recipient {
+	return message.Recipient{ID: a.ID, Role: string(a.Role), StartedAt: a.StartedAt, Dead: a.Status == StatusDead}
+}
+
 // CountByRole returns the count of active agents with the given role.
 func (s *Store) CountByRole(role Role) (int, error) {
 	all, err := s.listAll()
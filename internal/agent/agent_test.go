@@ -5,6 +5,8 @@ import (
 	"path/filepath"
 	"testing"
 	"time"
+
+	"github.com/anthropics/altera/internal/message"
 )
 
 func newTestStore(t *testing.T) *Store {
@@ -207,51 +209,6 @@ func TestHeartbeatStaleness_Stale(t *testing.T) {
 	}
 }
 
-func TestHeartbeatTimeoutConstants(t *testing.T) {
-	if HeartbeatWarnTimeout >= HeartbeatCriticalTimeout {
-		t.Errorf("warn (%v) should be < critical (%v)", HeartbeatWarnTimeout, HeartbeatCriticalTimeout)
-	}
-	if HeartbeatCriticalTimeout >= HeartbeatDeadTimeout {
-		t.Errorf("critical (%v) should be < dead (%v)", HeartbeatCriticalTimeout, HeartbeatDeadTimeout)
-	}
-}
-
-func TestCheckLiveness_Alive(t *testing.T) {
-	a := sampleAgent("a1")
-	a.PID = os.Getpid()
-	a.Heartbeat = time.Now()
-	if !CheckLiveness(a) {
-		t.Error("expected alive agent to be live")
-	}
-}
-
-func TestCheckLiveness_StaleHeartbeat(t *testing.T) {
-	a := sampleAgent("a1")
-	a.PID = os.Getpid()
-	a.Heartbeat = time.Now().Add(-1 * time.Hour)
-	if CheckLiveness(a) {
-		t.Error("expected stale heartbeat to be not live")
-	}
-}
-
-func TestCheckLiveness_DeadProcess(t *testing.T) {
-	a := sampleAgent("a1")
-	a.Heartbeat = time.Now()
-	a.PID = 999999999
-	if CheckLiveness(a) {
-		t.Error("expected dead process to be not live")
-	}
-}
-
-func TestCheckLiveness_NoPID(t *testing.T) {
-	a := sampleAgent("a1")
-	a.Heartbeat = time.Now()
-	a.PID = 0
-	if CheckLiveness(a) {
-		t.Error("expected zero PID to be not live")
-	}
-}
-
 func TestEscalationFields(t *testing.T) {
 	s := newTestStore(t)
 	a := sampleAgent("a1")
@@ -346,6 +303,33 @@ func TestListByStatus(t *testing.T) {
 	}
 }
 
+func TestRecipients(t *testing.T) {
+	s := newTestStore(t)
+	var _ message.Directory = s
+
+	for i, status := range []Status{StatusActive, StatusIdle, StatusDead} {
+		a := sampleAgent(string(rune('a' + i)))
+		a.Status = status
+		if err := s.Create(a); err != nil {
+			t.Fatalf("Create: %v", err)
+		}
+	}
+
+	all, err := s.Recipients()
+	if err != nil {
+		t.Fatalf("Recipients: %v", err)
+	}
+	if len(all) != 2 {
+		t.Errorf("Recipients = %v, want the 2 agents not dead", all)
+	}
+	if r, ok := s.Recipient("c"); !ok || !r.Dead || r.Role != string(RoleWorker) {
+		t.Errorf("Recipient(c) = %+v, %v; want dead worker", r, ok)
+	}
+	if _, ok := s.Recipient("missing"); ok {
+		t.Error("Recipient(missing) found")
+	}
+}
+
 func TestCountByRole(t *testing.T) {
 	s := newTestStore(t)
 
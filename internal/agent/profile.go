@@ -0,0 +1,141 @@
+package agent
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"text/template"
+
+	"github.com/anthropics/altera/internal/config"
+)
+
+// ProfileTagPrefix marks a task tag that picks an agent profile by name:
+// a task tagged "profile:cheap" runs its worker with the "cheap" profile.
+const ProfileTagPrefix = "profile:"
+
+// DefaultCommand starts Claude Code without permission prompts.
+const DefaultCommand = "claude --dangerously-skip-permissions"
+
+// defaultPrompts are the initial prompts for roles whose profile sets none.
+var defaultPrompts = map[Role]string{
+	RoleWorker:   "Read task.json, then run alt help worker startup for full instructions. When finished, run: alt task-done {{.TaskID}} {{.AgentID}}",
+	RoleResolver: "Read conflict-context.json, then resolve all merge conflicts. When done, commit and exit. Agent ID: {{.AgentID}}, Task: {{.TaskID}}",
+}
+
+// LaunchData is what command and prompt templates can refer to.
+type LaunchData struct {
+	AgentID string
+	TaskID  string
+	Role    Role
+	Dir     string // the agent's working directory
+	Model   string
+}
+
+// ProfileName returns the profile picked by a task's tags, or "" if none.
+func ProfileName(tags []string) string {
+	for _, tag := range tags {
+		if name, ok := strings.CutPrefix(tag, ProfileTagPrefix); ok {
+			return name
+		}
+	}
+	return ""
+}
+
+// Profile returns the profile for an agent of role: built-in defaults,
+// overlaid with cfg.Agents[role], overlaid with cfg.Agents[name] when name
+// is set. Env maps merge; other fields are replaced when set.
+func Profile(cfg config.Config, role Role, name string) (config.AgentProfile, error) {
+	p := config.AgentProfile{Command: DefaultCommand, Prompt: defaultPrompts[role]}
+	p = overlay(p, cfg.Agents[string(role)])
+	if name != "" && name != string(role) {
+		named, ok := cfg.Agents[name]
+		if !ok {
+			return p, fmt.Errorf("unknown agent profile %q", name)
+		}
+		p = overlay(p, named)
+	}
+	return p, nil
+}
+
+func overlay(base, o config.AgentProfile) config.AgentProfile {
+	if o.Command != "" {
+		base.Command = o.Command
+	}
+	if o.Model != "" {
+		base.Model = o.Model
+	}
+	if o.Args != nil {
+		base.Args = o.Args
+	}
+	if o.Prompt != "" {
+		base.Prompt = o.Prompt
+	}
+	if len(o.Env) > 0 {
+		env := maps.Clone(base.Env)
+		if env == nil {
+			env = make(map[string]string, len(o.Env))
+		}
+		maps.Copy(env, o.Env)
+		base.Env = env
+	}
+	return base
+}
+
+// LaunchCommand renders p into the shell line typed into an agent's tmux
+// pane. It changes to data.Dir, exports ALT_AGENT_ID and p.Env, and execs
+// the command so the pane PID is the agent's PID.
+func LaunchCommand(p config.AgentProfile, data LaunchData) (string, error) {
+	data.Model = p.Model
+	command, err := render("command", p.Command, data)
+	if err != nil {
+		return "", err
+	}
+	if strings.TrimSpace(command) == "" {
+		return "", fmt.Errorf("agent profile for %s has an empty command", data.Role)
+	}
+	prompt, err := render("prompt", p.Prompt, data)
+	if err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "cd %s && unset CLAUDECODE && export ALT_AGENT_ID=%s", shellQuote(data.Dir), shellQuote(data.AgentID))
+	for _, k := range slices.Sorted(maps.Keys(p.Env)) {
+		fmt.Fprintf(&b, " %s=%s", k, shellQuote(p.Env[k]))
+	}
+	b.WriteString(" && exec ")
+	b.WriteString(command)
+	if p.Model != "" {
+		b.WriteString(" --model " + shellQuote(p.Model))
+	}
+	for _, arg := range p.Args {
+		b.WriteString(" " + shellQuote(arg))
+	}
+	if prompt != "" {
+		b.WriteString(" " + shellQuote(prompt))
+	}
+	return b.String(), nil
+}
+
+func render(name, text string, data LaunchData) (string, error) {
+	tmpl, err := template.New(name).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return "", fmt.Errorf("parse %s template: %w", name, err)
+	}
+	var b strings.Builder
+	if err := tmpl.Execute(&b, data); err != nil {
+		return "", fmt.Errorf("render %s template: %w", name, err)
+	}
+	return b.String(), nil
+}
+
+// shellQuote quotes s for a POSIX shell.
+func shellQuote(s string) string {
+	if s != "" && strings.IndexFunc(s, func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || strings.ContainsRune("-_./:=@,+", r))
+	}) < 0 {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
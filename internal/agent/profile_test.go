@@ -0,0 +1,68 @@
+package agent
+
+import (
+	"os/exec"
+	"strings"
+	"testing"
+
+	"github.com/anthropics/altera/internal/config"
+)
+
+func TestProfile(t *testing.T) {
+	cfg := config.NewConfig()
+	cfg.Agents = map[string]config.AgentProfile{
+		"worker": {Model: "sonnet", Env: map[string]string{"A": "1"}},
+		"cheap":  {Model: "haiku", Env: map[string]string{"B": "2"}},
+	}
+
+	p, err := Profile(cfg, RoleWorker, "")
+	if err != nil || p.Command != DefaultCommand || p.Model != "sonnet" || !strings.Contains(p.Prompt, "task-done") {
+		t.Errorf("worker profile = %+v, %v", p, err)
+	}
+	p, err = Profile(cfg, RoleWorker, ProfileName([]string{"ui", "profile:cheap"}))
+	if err != nil || p.Model != "haiku" || p.Env["A"] != "1" || p.Env["B"] != "2" {
+		t.Errorf("cheap profile = %+v, %v", p, err)
+	}
+	if cfg.Agents["worker"].Env["B"] != "" {
+		t.Error("overlay modified the role profile's env")
+	}
+	if _, err := Profile(cfg, RoleWorker, "missing"); err == nil {
+		t.Error("expected error for unknown profile")
+	}
+	if p, _ := Profile(cfg, RoleLiaison, ""); p.Prompt != "" || p.Command != DefaultCommand {
+		t.Errorf("liaison profile = %+v", p)
+	}
+}
+
+func TestLaunchCommand(t *testing.T) {
+	dir := t.TempDir()
+	p := config.AgentProfile{
+		Command: `sh -c 'printf "%s|" "$ALT_AGENT_ID" "$GREETING" "$@"' {{.Role}}`,
+		Model:   "m-1",
+		Args:    []string{"--flag", "two words"},
+		Env:     map[string]string{"GREETING": "it's here"},
+		Prompt:  "do {{.TaskID}} as {{.AgentID}}",
+	}
+	line, err := LaunchCommand(p, LaunchData{AgentID: "worker-1", TaskID: "t-1", Role: RoleWorker, Dir: dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(line, "exec sh -c") {
+		t.Errorf("launch line does not exec the agent: %s", line)
+	}
+	out, err := exec.Command("sh", "-c", line).CombinedOutput()
+	if err != nil {
+		t.Fatalf("run %q: %v: %s", line, err, out)
+	}
+	want := "worker-1|it's here|--model|m-1|--flag|two words|do t-1 as worker-1|"
+	if got := string(out); got != want {
+		t.Errorf("output = %q, want %q", got, want)
+	}
+
+	if _, err := LaunchCommand(config.AgentProfile{Command: "{{.Nope}}"}, LaunchData{}); err == nil {
+		t.Error("expected error for unknown template field")
+	}
+	if _, err := LaunchCommand(config.AgentProfile{}, LaunchData{}); err == nil {
+		t.Error("expected error for empty command")
+	}
+}
@@ -9,7 +9,11 @@ import (
 	"testing"
 	"time"
 
+	"github.com/anthropics/altera/internal/agent"
+	"github.com/anthropics/altera/internal/config"
 	"github.com/anthropics/altera/internal/events"
+	"github.com/anthropics/altera/internal/git"
+	"github.com/anthropics/altera/internal/message"
 	"github.com/anthropics/altera/internal/task"
 )
 
@@ -62,7 +66,7 @@ func TestSubcommandRegistration(t *testing.T) {
 		"status", "task", "log", "work",
 		"daemon", "heartbeat", "checkpoint", "liaison",
 		"worker", "session", "prime", "setup", "help",
-		"task-done",
+		"task-done", "gc",
 	}
 	cmds := rootCmd.Commands()
 	names := make(map[string]bool)
@@ -79,7 +83,7 @@ func TestSubcommandRegistration(t *testing.T) {
 func TestTaskSubcommands(t *testing.T) {
 	for _, c := range rootCmd.Commands() {
 		if c.Name() == "task" {
-			expected := []string{"list", "show", "create"}
+			expected := []string{"list", "show", "create", "budget"}
 			subs := c.Commands()
 			names := make(map[string]bool)
 			for _, s := range subs {
@@ -194,6 +198,65 @@ func TestTaskCreateAndShow(t *testing.T) {
 	}
 }
 
+func TestTaskBudget(t *testing.T) {
+	root := setupProject(t)
+
+	store, err := task.NewStore(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Create(&task.Task{ID: "t-budget", Title: "Budgeted"}); err != nil {
+		t.Fatal(err)
+	}
+
+	out, err := executeCmd(t, "task", "budget", "t-budget", "12.5")
+	if err != nil {
+		t.Fatalf("task budget: %v", err)
+	}
+	if !strings.Contains(out, "$12.50") {
+		t.Errorf("output = %q", out)
+	}
+	if got, _ := store.Get("t-budget"); got.Budget != 12.5 {
+		t.Errorf("Budget = %v, want 12.5", got.Budget)
+	}
+
+	if _, err := executeCmd(t, "task", "budget", "t-budget", "-1"); err == nil {
+		t.Error("expected error for negative amount")
+	}
+	if _, err := executeCmd(t, "task", "budget", "t-missing", "5"); err == nil {
+		t.Error("expected error for unknown task")
+	}
+}
+
+func TestTaskCreateProfileTag(t *testing.T) {
+	root := setupProject(t)
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Cleanup(func() {
+		taskCreateTitle = ""
+		taskCreateTags = nil
+	})
+
+	if _, err := executeCmd(t, "task", "create", "--title", "Cheap", "--tag", "profile:cheap"); err == nil {
+		t.Fatal("expected error for unknown profile")
+	}
+	taskCreateTags = nil
+
+	if _, err := executeCmd(t, "config", "set", "agents", `{"cheap":{"model":"haiku"}}`); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := executeCmd(t, "task", "create", "--title", "Cheap", "--tag", "ui", "--tag", "profile:cheap"); err != nil {
+		t.Fatalf("task create: %v", err)
+	}
+	store, err := task.NewStore(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tasks, err := store.List(task.Filter{Tag: "profile:cheap"})
+	if err != nil || len(tasks) != 1 || len(tasks[0].Tags) != 2 {
+		t.Errorf("tagged tasks = %+v, %v", tasks, err)
+	}
+}
+
 func TestTaskListEmpty(t *testing.T) {
 	setupProject(t)
 	_, err := executeCmd(t, "task", "list")
@@ -242,6 +305,156 @@ func TestLogLastFlag(t *testing.T) {
 	t.Fatal("log command not found")
 }
 
+// resetLogFlags restores alt log flag globals after a test, since cobra
+// keeps them between executeCmd calls.
+func resetLogFlags(t *testing.T) {
+	t.Cleanup(func() {
+		logLast, logTail = 0, false
+		logTask, logType, logAgent, logSince, logUntil = "", "", "", "", ""
+		logData = nil
+		logFormat = logFormatTable
+	})
+}
+
+func writeLogFixture(t *testing.T, root string) {
+	t.Helper()
+	now := time.Now()
+	writer := events.NewWriter(filepath.Join(root, ".alt", "events.jsonl"))
+	if err := writer.Append(
+		events.Event{Timestamp: now.Add(-3 * time.Hour), Type: events.TaskCreated, TaskID: "t-old"},
+		events.Event{Timestamp: now.Add(-10 * time.Minute), Type: events.TaskCreated, TaskID: "t-abc123"},
+		events.Event{Timestamp: now.Add(-9 * time.Minute), Type: events.AgentSpawned, AgentID: "w-1", TaskID: "t-abc123"},
+		events.Event{Timestamp: now.Add(-5 * time.Minute), Type: events.MergeConflict, AgentID: "w-1", TaskID: "t-abc123",
+			Data: map[string]any{"attempt": float64(1)}},
+		events.Event{Timestamp: now.Add(-4 * time.Minute), Type: events.TaskCreated, TaskID: "t-def456"},
+	); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLogFilters(t *testing.T) {
+	root := setupProject(t)
+	resetLogFlags(t)
+	writeLogFixture(t, root)
+
+	tests := []struct {
+		args    []string
+		want    []string
+		notWant []string
+	}{
+		{[]string{"--task", "t-abc123"}, []string{"merge_conflict", "agent_spawned"}, []string{"t-def456", "t-old"}},
+		{[]string{"--type", "task_created", "--since", "1h"}, []string{"t-abc123", "t-def456"}, []string{"t-old", "merge_conflict"}},
+		{[]string{"--agent", "w-1", "--data", "attempt=1"}, []string{"merge_conflict"}, []string{"agent_spawned"}},
+		{[]string{"--until", "2h"}, []string{"t-old"}, []string{"t-abc123"}},
+		{[]string{"--task", "t-nope"}, []string{"No matching events."}, nil},
+	}
+	for _, tt := range tests {
+		logTask, logType, logAgent, logSince, logUntil, logData = "", "", "", "", "", nil
+		out, err := executeCmd(t, append([]string{"log"}, tt.args...)...)
+		if err != nil {
+			t.Fatalf("log %v: %v", tt.args, err)
+		}
+		for _, w := range tt.want {
+			if !strings.Contains(out, w) {
+				t.Errorf("log %v: output missing %q:\n%s", tt.args, w, out)
+			}
+		}
+		for _, w := range tt.notWant {
+			if strings.Contains(out, w) {
+				t.Errorf("log %v: output unexpectedly contains %q:\n%s", tt.args, w, out)
+			}
+		}
+	}
+}
+
+func TestLogFormatJSONL(t *testing.T) {
+	root := setupProject(t)
+	resetLogFlags(t)
+	writeLogFixture(t, root)
+
+	out, err := executeCmd(t, "log", "--format", "jsonl", "--task", "t-abc123")
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("got %d lines, want 3:\n%s", len(lines), out)
+	}
+	var ev events.Event
+	if err := json.Unmarshal([]byte(lines[2]), &ev); err != nil {
+		t.Fatalf("line is not an event: %v", err)
+	}
+	if ev.Type != events.MergeConflict {
+		t.Errorf("last event type = %s, want merge_conflict", ev.Type)
+	}
+}
+
+func TestLogFormatTimeline(t *testing.T) {
+	root := setupProject(t)
+	resetLogFlags(t)
+	writeLogFixture(t, root)
+
+	out, err := executeCmd(t, "log", "--format", "timeline", "--since", "1h")
+	if err != nil {
+		t.Fatal(err)
+	}
+	abc := strings.Index(out, "t-abc123")
+	def := strings.Index(out, "t-def456")
+	if abc < 0 || def < 0 || abc > def {
+		t.Fatalf("timeline should group t-abc123 before t-def456:\n%s", out)
+	}
+	if !strings.Contains(out, "(3 events, 5m0s)") {
+		t.Errorf("timeline missing t-abc123 summary:\n%s", out)
+	}
+	if !strings.Contains(out, "+5m0s") || !strings.Contains(out, "attempt=1") {
+		t.Errorf("timeline missing offsets or data:\n%s", out)
+	}
+}
+
+func TestLogRejectsBadFlags(t *testing.T) {
+	setupProject(t)
+	resetLogFlags(t)
+
+	for _, args := range [][]string{
+		{"log", "--format", "xml"},
+		{"log", "--since", "yesterday"},
+		{"log", "--data", "novalue"},
+	} {
+		if _, err := executeCmd(t, args...); err == nil {
+			t.Errorf("%v: expected error", args)
+		}
+		logFormat, logSince, logData = logFormatTable, "", nil
+	}
+}
+
+func TestParseTimeArg(t *testing.T) {
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		in   string
+		want time.Time
+	}{
+		{"2h", now.Add(-2 * time.Hour)},
+		{"90m", now.Add(-90 * time.Minute)},
+		{"3d", now.AddDate(0, 0, -3)},
+		{"2026-03-01T08:00:00Z", time.Date(2026, 3, 1, 8, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		got, err := parseTimeArg(tt.in, now)
+		if err != nil {
+			t.Errorf("parseTimeArg(%q): %v", tt.in, err)
+			continue
+		}
+		if !got.Equal(tt.want) {
+			t.Errorf("parseTimeArg(%q) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+	for _, bad := range []string{"", "soon", "-2h"} {
+		if _, err := parseTimeArg(bad, now); err == nil {
+			t.Errorf("parseTimeArg(%q): expected error", bad)
+		}
+	}
+}
+
 func TestHeartbeatRequiresArg(t *testing.T) {
 	setupProject(t)
 	_, err := executeCmd(t, "heartbeat")
@@ -431,6 +644,107 @@ func TestHelpAgentUnknownTopic(t *testing.T) {
 	}
 }
 
+func TestHelpAgentProjectPrompts(t *testing.T) {
+	root := setupProject(t)
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	dir := filepath.Join(root, ".alt", "prompts", "worker")
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "style.md"), []byte("# House style\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := executeCmd(t, "config", "set", "prompts", `{"worker":{"commit":"Sign off every commit."}}`); err != nil {
+		t.Fatal(err)
+	}
+
+	out, err := executeCmd(t, "help", "worker")
+	if err != nil || !strings.Contains(out, "style") {
+		t.Errorf("help worker = %q, %v", out, err)
+	}
+	out, err = executeCmd(t, "help", "worker", "style")
+	if err != nil || !strings.Contains(out, "House style") {
+		t.Errorf("help worker style = %q, %v", out, err)
+	}
+	out, err = executeCmd(t, "help", "worker", "commit")
+	if err != nil || !strings.Contains(out, "Sign off every commit.") {
+		t.Errorf("help worker commit = %q, %v", out, err)
+	}
+
+	// Project topics are templates over the task, agent and config.
+	if err := os.WriteFile(filepath.Join(dir, "tests.md"), []byte("Run `{{.Config.TestCommand}}`.\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := executeCmd(t, "config", "set", "test_command", "make check"); err != nil {
+		t.Fatal(err)
+	}
+	out, err = executeCmd(t, "help", "worker", "tests")
+	if err != nil || strings.TrimSpace(out) != "Run `make check`." {
+		t.Errorf("help worker tests = %q, %v", out, err)
+	}
+}
+
+func TestPromptDataResolver(t *testing.T) {
+	root := setupProject(t)
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	altDir := filepath.Join(root, ".alt")
+	wt := filepath.Join(root, "worktrees", "resolver-01")
+	if err := os.MkdirAll(wt, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	conflicted := "package api\n<<<<<<< HEAD\nfunc a() {}\n=======\nfunc b() {}\nfunc c() {}\n>>>>>>> worker/w-1\n"
+	if err := os.WriteFile(filepath.Join(wt, "api.go"), []byte(conflicted), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	ctx := `{"task_id":"t-1","branch":"worker/w-1","base_branch":"main","task_description":"Add login",` +
+		`"resolve_attempt":2,"conflicts":[{"Path":"api.go","Markers":[{"OursStart":2}]}]}`
+	if err := os.WriteFile(filepath.Join(wt, "conflict-context.json"), []byte(ctx), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	agents, err := agent.NewStore(filepath.Join(altDir, "agents"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, a := range []*agent.Agent{
+		{ID: "resolver-01", Role: agent.RoleResolver, Worktree: wt, CurrentTask: "t-1"},
+		{ID: "custom-7", Role: agent.RoleWorker},
+	} {
+		if err := agents.Create(a); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	data := promptData(altDir, "resolver", "resolver-01")
+	c := data.Conflict
+	if c == nil || c.Attempt != 2 || c.Intent != "Add login" || len(c.Files) != 1 || len(c.Files[0].Hunks) != 1 {
+		t.Fatalf("Conflict = %+v", c)
+	}
+	if h := c.Files[0].Hunks[0]; h.Line != 2 || h.Ours != "func a() {}\n" || h.Theirs != "func b() {}\nfunc c() {}\n" {
+		t.Errorf("hunk = %+v", h)
+	}
+
+	// A resolver that removed the ======= line but not the others.
+	halfEdited := "package api\n<<<<<<< HEAD\nfunc a() {}\nfunc b() {}\n>>>>>>> worker/w-1\n"
+	if err := os.WriteFile(filepath.Join(wt, "api.go"), []byte(halfEdited), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	c = promptData(altDir, "resolver", "resolver-01").Conflict
+	if c == nil || len(c.Files) != 1 || len(c.Files[0].Hunks) != 1 {
+		t.Fatalf("Conflict for half-edited file = %+v", c)
+	}
+	if h := c.Files[0].Hunks[0]; h.Ours != "" || h.Theirs != "" {
+		t.Errorf("half-edited hunk = %+v, want empty sides", h)
+	}
+
+	// Roles come from agent records before ID prefixes.
+	for id, want := range map[string]string{"resolver-01": "resolver", "custom-7": "worker", "w-abc123": "worker", "someone": "liaison"} {
+		t.Setenv("ALT_AGENT_ID", id)
+		if role, _ := detectRole(root, altDir); role != want {
+			t.Errorf("detectRole(%s) = %q, want %q", id, role, want)
+		}
+	}
+}
+
 func TestTaskDoneRequiresArgs(t *testing.T) {
 	setupProject(t)
 	_, err := executeCmd(t, "task-done")
@@ -512,3 +826,333 @@ func TestLogTailFlag(t *testing.T) {
 	}
 	t.Fatal("log command not found")
 }
+
+func TestMessageSendRejectsMalformedPayload(t *testing.T) {
+	root := setupProject(t)
+	t.Cleanup(func() { msgSendType, msgSendPayload = "user_message", "" })
+
+	_, err := executeCmd(t, "message", "send", "w-1", "--type", "merge_result", "--payload", `{"success":"yes"}`)
+	if err == nil {
+		t.Fatal("expected error for malformed merge_result payload")
+	}
+
+	entries, _ := os.ReadDir(filepath.Join(root, ".alt", "messages"))
+	for _, e := range entries {
+		if !e.IsDir() {
+			t.Errorf("message stored despite invalid payload: %s", e.Name())
+		}
+	}
+}
+
+func TestMessageDLQReplayRoleMessage(t *testing.T) {
+	root := setupProject(t)
+	altDir := filepath.Join(root, ".alt")
+	agents, err := agent.NewStore(filepath.Join(altDir, "agents"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, id := range []string{"w-1", "w-2"} {
+		if err := agents.Create(&agent.Agent{
+			ID: id, Role: agent.RoleWorker, Status: agent.StatusActive,
+			Heartbeat: time.Now(), StartedAt: time.Now().Add(-time.Hour),
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	store, err := openMessageStore(altDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := store.Create(message.TypeUserMessage, "liaison", "role:worker", "", map[string]any{"body": "rebase now"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.DeadLetter(m.ID, "expired"); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := executeCmd(t, "message", "dlq", "replay", m.ID); err != nil {
+		t.Fatalf("dlq replay: %v", err)
+	}
+	for i, id := range []string{"w-1", "w-2"} {
+		pending, err := store.ListPending(id)
+		if err != nil || len(pending) != 1 || pending[0].ID != m.ID {
+			t.Fatalf("ListPending(%s) = %v, %v; want the replayed message", id, pending, err)
+		}
+		if err := store.Ack(m.ID, id); err != nil {
+			t.Fatalf("Ack(%s): %v", id, err)
+		}
+		archived, _ := filepath.Glob(filepath.Join(altDir, "messages", "archive", "*"+m.ID+"*"))
+		if want := i == 1; (len(archived) == 1) != want {
+			t.Errorf("after %s acked: archived = %v, want archived %v", id, archived, want)
+		}
+	}
+}
+
+func TestBuildPayload(t *testing.T) {
+	p, err := buildPayload("help", "need a hand", `{"worker_id":"w-1"}`)
+	if err != nil {
+		t.Fatalf("buildPayload: %v", err)
+	}
+	if p["message"] != "need a hand" || p["worker_id"] != "w-1" {
+		t.Errorf("payload = %v", p)
+	}
+	if _, err := buildPayload("merge_result", "text", ""); err == nil {
+		t.Error("expected error for text on merge_result")
+	}
+	if _, err := buildPayload("user_message", "", ""); err == nil {
+		t.Error("expected error for empty user_message")
+	}
+}
+
+func TestNotifyListAndTest(t *testing.T) {
+	root := setupProject(t)
+	altDir := filepath.Join(root, ".alt")
+	out := filepath.Join(root, "delivered")
+
+	cfg := config.NewConfig()
+	cfg.Notify = []config.Sink{{
+		Name: "file", Kind: config.SinkCommand, Command: "cat > " + out,
+		Types: []string{"agent_died"},
+	}}
+	if err := config.Save(altDir, cfg); err != nil {
+		t.Fatal(err)
+	}
+
+	list, err := executeCmd(t, "notify", "list")
+	if err != nil {
+		t.Fatalf("notify list: %v", err)
+	}
+	if !strings.Contains(list, "file") || !strings.Contains(list, "agent_died") {
+		t.Errorf("notify list output:\n%s", list)
+	}
+
+	if _, err := executeCmd(t, "notify", "test", "file"); err != nil {
+		t.Fatalf("notify test: %v", err)
+	}
+	data, _ := os.ReadFile(out)
+	if !strings.Contains(string(data), `"type":"agent_died"`) {
+		t.Errorf("sink received %q", data)
+	}
+
+	if _, err := executeCmd(t, "notify", "test", "nope"); err == nil {
+		t.Error("expected error for unknown sink")
+	}
+}
+
+func TestExportTraces(t *testing.T) {
+	root := setupProject(t)
+	t.Cleanup(func() { exportTask, exportSince, exportOutput, exportEndpoint, exportOpen = "", "", "", "", true })
+	writeLogFixture(t, root)
+
+	out, err := executeCmd(t, "export", "traces", "--task", "t-abc123")
+	if err != nil {
+		t.Fatalf("export traces: %v", err)
+	}
+	var req struct {
+		ResourceSpans []struct {
+			ScopeSpans []struct {
+				Spans []struct {
+					Name string `json:"name"`
+				} `json:"spans"`
+			} `json:"scopeSpans"`
+		} `json:"resourceSpans"`
+	}
+	if err := json.Unmarshal([]byte(out), &req); err != nil {
+		t.Fatalf("output is not OTLP JSON: %v\n%s", err, out)
+	}
+	spans := req.ResourceSpans[0].ScopeSpans[0].Spans
+	if len(spans) != 3 || spans[0].Name != "task t-abc123" || spans[1].Name != "worker w-1" || spans[2].Name != "merge" {
+		t.Errorf("spans = %+v", spans)
+	}
+
+	// Only open tasks in the fixture: nothing to export when they are excluded.
+	file := filepath.Join(root, "traces.json")
+	if _, err := executeCmd(t, "export", "traces", "--include-open=false", "-o", file); err != nil {
+		t.Fatal(err)
+	}
+	data, _ := os.ReadFile(file)
+	if !strings.Contains(string(data), `"spans": []`) {
+		t.Errorf("expected no spans:\n%s", data)
+	}
+}
+
+func TestReport(t *testing.T) {
+	root := setupProject(t)
+	t.Cleanup(func() { reportFormat, reportOutput, reportSince = "markdown", "", "" })
+	writeLogFixture(t, root)
+
+	out, err := executeCmd(t, "report")
+	if err != nil {
+		t.Fatalf("report: %v", err)
+	}
+	for _, want := range []string{"# Altera run report", "| Tasks | 3 (0 merged, 0 failed, 3 open) |", "| Merge attempts | 0 (1 conflicts, 0 failed) |"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("markdown missing %q:\n%s", want, out)
+		}
+	}
+
+	out, err = executeCmd(t, "report", "--format", "json", "--since", "1h")
+	if err != nil {
+		t.Fatalf("report --format json: %v", err)
+	}
+	var r struct {
+		Tasks struct{ Total int }
+	}
+	if err := json.Unmarshal([]byte(out), &r); err != nil {
+		t.Fatalf("not JSON: %v\n%s", err, out)
+	}
+	if r.Tasks.Total != 2 {
+		t.Errorf("--since 1h should cover 2 tasks, got %d", r.Tasks.Total)
+	}
+
+	file := filepath.Join(root, "report.html")
+	if _, err := executeCmd(t, "report", "--format", "html", "-o", file); err != nil {
+		t.Fatal(err)
+	}
+	data, _ := os.ReadFile(file)
+	if !strings.Contains(string(data), `class="bar worker"`) {
+		t.Errorf("html report missing gantt bar for w-1:\n%s", data)
+	}
+
+	if _, err := executeCmd(t, "report", "--format", "pdf"); err == nil {
+		t.Error("expected error for unknown format")
+	}
+}
+
+func TestReportUsage(t *testing.T) {
+	root := setupProject(t)
+	t.Cleanup(func() {
+		usageModel, usageTask = "", ""
+		usageInput, usageOutput, usageCacheWrite, usageCacheRead = 0, 0, 0, 0
+		usageCost = -1
+	})
+
+	out, err := executeCmd(t, "report-usage", "w-1", "--model", "claude-sonnet-4-5", "--input", "1000000", "--output", "100000", "--task", "t-1")
+	if err != nil {
+		t.Fatalf("report-usage: %v", err)
+	}
+	if !strings.Contains(out, "$4.5000") {
+		t.Errorf("output = %q", out)
+	}
+
+	usageInput, usageOutput = 0, 0
+	if _, err := executeCmd(t, "report-usage", "w-1", "--model", "local-llm", "--cost", "0.25"); err != nil {
+		t.Fatalf("report-usage --cost: %v", err)
+	}
+	usageCost = -1
+	if _, err := executeCmd(t, "report-usage", "w-1", "--model", "local-llm"); err == nil {
+		t.Error("expected error for model without a price")
+	}
+
+	evts, err := events.NewReader(filepath.Join(root, ".alt", "events.jsonl")).Read(events.Filter{Type: events.TokenUsage})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(evts) != 2 || evts[0].TaskID != "t-1" || evts[0].Data["token_cost"] != 4.5 || evts[0].Data["source"] != "agent" {
+		t.Errorf("events = %+v", evts)
+	}
+}
+
+func TestConfigLayers(t *testing.T) {
+	setupProject(t)
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Cleanup(func() {
+		configShowOrigin = false
+		configOverrides = nil
+		_ = config.SetFlagOverrides(nil)
+	})
+
+	if _, err := executeCmd(t, "config", "set", "max_workers", "6"); err != nil {
+		t.Fatalf("config set: %v", err)
+	}
+	out, err := executeCmd(t, "config", "get", "constraints.max_workers")
+	if err != nil || strings.TrimSpace(out) != "6" {
+		t.Fatalf("config get = %q, %v", out, err)
+	}
+
+	t.Setenv("ALT_METRICS_LISTEN", "127.0.0.1:9464")
+	out, err = executeCmd(t, "config", "list", "--show-origin", "--set", "test_command=make check")
+	if err != nil {
+		t.Fatalf("config list: %v", err)
+	}
+	for _, want := range []string{
+		"constraints.max_workers = 6",
+		"env:ALT_METRICS_LISTEN",
+		"flag:--set test_command",
+		"default ",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("list output missing %q:\n%s", want, out)
+		}
+	}
+
+	if _, err := executeCmd(t, "config", "set", "no_such_key", "1"); err == nil {
+		t.Error("expected error for unknown key")
+	}
+}
+
+func TestConfigValidate(t *testing.T) {
+	root := setupProject(t)
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	altDir := filepath.Join(root, ".alt")
+	if err := git.Init(root); err != nil {
+		t.Fatal(err)
+	}
+	head, err := git.HeadBranch(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// An old flat layout is reported and migrated.
+	old := `{"default_branch": "` + head + `", "max_workers": 2}`
+	if err := os.WriteFile(filepath.Join(altDir, "config.json"), []byte(old), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	out, err := executeCmd(t, "config", "validate")
+	if err != nil || !strings.Contains(out, "schema version 0") || !strings.Contains(out, "Config OK") {
+		t.Fatalf("validate = %q, %v", out, err)
+	}
+	if out, err := executeCmd(t, "config", "migrate"); err != nil || !strings.Contains(out, "from schema version 0") {
+		t.Fatalf("migrate = %q, %v", out, err)
+	}
+	if v, _ := config.FileVersion(altDir); v != config.SchemaVersion {
+		t.Errorf("FileVersion after migrate = %d", v)
+	}
+
+	if _, err := executeCmd(t, "config", "set", "default_branch", "no-such-branch"); err != nil {
+		t.Fatal(err)
+	}
+	out, err = executeCmd(t, "config", "validate")
+	if err == nil || !strings.Contains(out, `no branch "no-such-branch"`) {
+		t.Errorf("validate with bad branch = %q, %v", out, err)
+	}
+}
+
+func TestInitWritesValidConfig(t *testing.T) {
+	root := t.TempDir()
+	orig, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(root); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = os.Chdir(orig) })
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	if _, err := executeCmd(t, "init"); err != nil {
+		t.Fatalf("init: %v", err)
+	}
+	cfg, err := config.Load(filepath.Join(root, ".alt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	head, _ := git.HeadBranch(root)
+	if cfg.DefaultBranch != head || cfg.SchemaVersion != config.SchemaVersion {
+		t.Errorf("init config = %+v, want default_branch %q", cfg, head)
+	}
+	if err := config.ValidateProject(cfg, root); err != nil {
+		t.Errorf("init wrote invalid config: %v", err)
+	}
+}
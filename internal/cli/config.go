@@ -2,7 +2,9 @@ package cli
 
 import (
 	"fmt"
-	"strconv"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
 
 	"github.com/anthropics/altera/internal/config"
 	"github.com/spf13/cobra"
@@ -13,68 +15,34 @@ func init() {
 	configCmd.AddCommand(configGetCmd)
 	configCmd.AddCommand(configSetCmd)
 	configCmd.AddCommand(configListCmd)
-}
+	configCmd.AddCommand(configValidateCmd)
+	configCmd.AddCommand(configMigrateCmd)
 
-var validKeys = []string{
-	"repo_path", "default_branch", "test_command",
-	"budget_ceiling", "max_workers", "max_queue_depth",
+	configListCmd.Flags().BoolVar(&configShowOrigin, "show-origin", false, "show which layer each value came from")
 }
 
-func getField(cfg config.Config, key string) (string, error) {
-	switch key {
-	case "repo_path":
-		return cfg.RepoPath, nil
-	case "default_branch":
-		return cfg.DefaultBranch, nil
-	case "test_command":
-		return cfg.TestCommand, nil
-	case "budget_ceiling":
-		return strconv.FormatFloat(cfg.Constraints.BudgetCeiling, 'f', -1, 64), nil
-	case "max_workers":
-		return strconv.Itoa(cfg.Constraints.MaxWorkers), nil
-	case "max_queue_depth":
-		return strconv.Itoa(cfg.Constraints.MaxQueueDepth), nil
-	default:
-		return "", fmt.Errorf("unknown config key %q (valid keys: %v)", key, validKeys)
-	}
-}
-
-func setField(cfg *config.Config, key, value string) error {
-	switch key {
-	case "repo_path":
-		cfg.RepoPath = value
-	case "default_branch":
-		cfg.DefaultBranch = value
-	case "test_command":
-		cfg.TestCommand = value
-	case "budget_ceiling":
-		v, err := strconv.ParseFloat(value, 64)
-		if err != nil {
-			return fmt.Errorf("invalid float for budget_ceiling: %w", err)
-		}
-		cfg.Constraints.BudgetCeiling = v
-	case "max_workers":
-		v, err := strconv.Atoi(value)
-		if err != nil {
-			return fmt.Errorf("invalid integer for max_workers: %w", err)
-		}
-		cfg.Constraints.MaxWorkers = v
-	case "max_queue_depth":
-		v, err := strconv.Atoi(value)
-		if err != nil {
-			return fmt.Errorf("invalid integer for max_queue_depth: %w", err)
-		}
-		cfg.Constraints.MaxQueueDepth = v
-	default:
-		return fmt.Errorf("unknown config key %q (valid keys: %v)", key, validKeys)
-	}
-	return nil
-}
+var configShowOrigin bool
 
 var configCmd = &cobra.Command{
 	Use:   "config",
 	Short: "View and modify configuration",
-	Long:  `Read and write fields in .alt/config.json.`,
+	Long: `Read and write configuration keys.
+
+The effective config is built in layers, each overriding the keys it sets:
+
+  1. built-in defaults
+  2. ~/.config/altera/config.json (or $XDG_CONFIG_HOME/altera/config.json)
+  3. .alt/config.json
+  4. ALT_* environment variables (constraints.max_workers is
+     ALT_CONSTRAINTS_MAX_WORKERS)
+  5. --set key=value flags
+
+Keys are dotted JSON paths such as constraints.max_workers or metrics.listen.
+Maps and lists such as pricing and notify take JSON values; durations take
+Go duration strings like 30s.
+
+config.json carries a schema_version. Files from older versions of alt are
+migrated when read; 'alt config migrate' rewrites them in the new layout.`,
 }
 
 var configGetCmd = &cobra.Command{
@@ -90,35 +58,32 @@ var configGetCmd = &cobra.Command{
 		if err != nil {
 			return err
 		}
-		val, err := getField(cfg, args[0])
+		val, err := config.Get(cfg, args[0])
 		if err != nil {
 			return err
 		}
-		fmt.Println(val)
+		_, _ = fmt.Fprintln(cmd.OutOrStdout(), val)
 		return nil
 	},
 }
 
 var configSetCmd = &cobra.Command{
 	Use:   "set <key> <value>",
-	Short: "Update a config value",
+	Short: "Update a config value in .alt/config.json",
 	Args:  cobra.ExactArgs(2),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		altDir, err := resolveAltDir()
 		if err != nil {
 			return err
 		}
-		cfg, err := config.Load(altDir)
+		key, err := config.CanonicalKey(args[0])
 		if err != nil {
 			return err
 		}
-		if err := setField(&cfg, args[0], args[1]); err != nil {
-			return err
-		}
-		if err := config.Save(altDir, cfg); err != nil {
+		if err := config.SetProject(altDir, key, args[1]); err != nil {
 			return err
 		}
-		fmt.Printf("%s = %s\n", args[0], args[1])
+		_, _ = fmt.Fprintf(cmd.OutOrStdout(), "%s = %s\n", key, args[1])
 		return nil
 	},
 }
@@ -132,14 +97,74 @@ var configListCmd = &cobra.Command{
 		if err != nil {
 			return err
 		}
+		cfg, origins, err := config.Resolve(altDir)
+		if err != nil {
+			return err
+		}
+		w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 4, 2, ' ', 0)
+		for _, key := range config.Keys() {
+			val, _ := config.Get(cfg, key)
+			if configShowOrigin {
+				_, _ = fmt.Fprintf(w, "%s\t%s = %s\n", origins[key], key, val)
+			} else {
+				_, _ = fmt.Fprintf(w, "%s = %s\n", key, val)
+			}
+		}
+		return w.Flush()
+	},
+}
+
+var configValidateCmd = &cobra.Command{
+	Use:   "validate",
+	Short: "Check the effective config",
+	Long: `Check the effective config for errors: out-of-range values, a repo_path
+that is not a git repository, a default_branch that does not exist and a
+test_command that is not valid shell.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		altDir, err := resolveAltDir()
+		if err != nil {
+			return err
+		}
+		out := cmd.OutOrStdout()
 		cfg, err := config.Load(altDir)
 		if err != nil {
 			return err
 		}
-		for _, key := range validKeys {
-			val, _ := getField(cfg, key)
-			fmt.Printf("%s = %s\n", key, val)
+		if v, err := config.FileVersion(altDir); err == nil && v < config.SchemaVersion {
+			_, _ = fmt.Fprintf(out, "note: config.json is schema version %d; run 'alt config migrate' to upgrade it to %d\n",
+				v, config.SchemaVersion)
+		}
+		if err := config.ValidateProject(cfg, filepath.Dir(altDir)); err != nil {
+			problems := strings.Split(err.Error(), "\n")
+			for _, p := range problems {
+				_, _ = fmt.Fprintf(out, "  - %s\n", p)
+			}
+			return fmt.Errorf("config has %d problem(s)", len(problems))
+		}
+		_, _ = fmt.Fprintln(out, "Config OK.")
+		return nil
+	},
+}
+
+var configMigrateCmd = &cobra.Command{
+	Use:   "migrate",
+	Short: "Rewrite .alt/config.json in the current schema",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		altDir, err := resolveAltDir()
+		if err != nil {
+			return err
+		}
+		from, err := config.Migrate(altDir)
+		if err != nil {
+			return err
+		}
+		if from == config.SchemaVersion {
+			_, _ = fmt.Fprintf(cmd.OutOrStdout(), "config.json is already schema version %d.\n", from)
+			return nil
 		}
+		_, _ = fmt.Fprintf(cmd.OutOrStdout(), "Migrated config.json from schema version %d to %d.\n", from, config.SchemaVersion)
 		return nil
 	},
 }
@@ -26,6 +26,7 @@ func init() {
 	daemonCmd.AddCommand(daemonStopCmd)
 	daemonCmd.AddCommand(daemonStatusCmd)
 	daemonCmd.AddCommand(daemonTickCmd)
+	daemonCmd.AddCommand(daemonReloadCmd)
 	daemonCmd.AddCommand(daemonLogsCmd)
 	daemonStatusCmd.Flags().BoolVar(&daemonStatusVerbose, "verbose", false, "show detailed daemon state")
 	daemonLogsCmd.Flags().BoolVarP(&daemonLogsFollow, "follow", "f", false, "follow log output")
@@ -137,6 +138,27 @@ var daemonTickCmd = &cobra.Command{
 	},
 }
 
+var daemonReloadCmd = &cobra.Command{
+	Use:   "reload",
+	Short: "Reload the daemon config",
+	Long: `Sends SIGHUP to the daemon so it re-reads its config.
+
+The daemon also reloads on its own when a config file changes. Constraints,
+daemon settings, pricing and the test command apply at once; other changes
+are logged and take effect on the next restart.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		altDir, err := resolveAltDir()
+		if err != nil {
+			return err
+		}
+		if err := daemon.SendReload(altDir); err != nil {
+			return err
+		}
+		fmt.Println("Reload signal sent.")
+		return nil
+	},
+}
+
 var daemonLogsCmd = &cobra.Command{
 	Use:   "logs",
 	Short: "Show daemon log output",
@@ -0,0 +1,124 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/anthropics/altera/internal/config"
+	"github.com/anthropics/altera/internal/events"
+	"github.com/anthropics/altera/internal/tracing"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	rootCmd.AddCommand(exportCmd)
+	exportCmd.AddCommand(exportTracesCmd)
+	exportTracesCmd.Flags().StringVar(&exportTask, "task", "", "export only this task's trace")
+	exportTracesCmd.Flags().StringVar(&exportSince, "since", "", "export tasks with events after this time (duration like 2h or 3d, or RFC3339)")
+	exportTracesCmd.Flags().StringVarP(&exportOutput, "output", "o", "", "write OTLP/JSON to this file instead of stdout")
+	exportTracesCmd.Flags().StringVar(&exportEndpoint, "endpoint", "", "POST to this OTLP/HTTP collector (e.g. http://localhost:4318) instead of writing JSON")
+	exportTracesCmd.Flags().BoolVar(&exportOpen, "include-open", true, "include tasks that have not merged or failed yet")
+}
+
+var (
+	exportTask     string
+	exportSince    string
+	exportOutput   string
+	exportEndpoint string
+	exportOpen     bool
+)
+
+var exportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Export orchestration data to other tools",
+}
+
+var exportTracesCmd = &cobra.Command{
+	Use:   "traces",
+	Short: "Export task lifecycles as OpenTelemetry traces",
+	Long: `Convert the event log into OpenTelemetry traces. Each task is a trace; its
+worker and resolver agents and merge attempts are child spans, and other task
+events are span events.
+
+By default the traces are written to stdout as an OTLP/JSON
+ExportTraceServiceRequest. With --endpoint they are POSTed to an OTLP/HTTP
+collector such as Jaeger or Tempo (port 4318). The daemon can export traces
+continuously when "tracing.endpoint" is set in .alt/config.json.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		altDir, err := resolveAltDir()
+		if err != nil {
+			return fmt.Errorf("not an altera project: %w", err)
+		}
+		cfg, err := config.Load(altDir)
+		if err != nil {
+			return err
+		}
+
+		filter := events.Filter{TaskID: exportTask}
+		if exportSince != "" {
+			if filter.After, err = parseTimeArg(exportSince, time.Now()); err != nil {
+				return fmt.Errorf("invalid --since: %w", err)
+			}
+		}
+		reader := events.NewReader(filepath.Join(altDir, "events.jsonl"))
+		evts, err := reader.Read(filter)
+		if err != nil {
+			return fmt.Errorf("reading events: %w", err)
+		}
+
+		// A --since window can start mid-task; rebuild each task from its
+		// full history so spans are not cut off.
+		var traces []tracing.Trace
+		for _, tr := range tracing.Build(evts) {
+			if exportSince != "" {
+				full, err := reader.Read(events.Filter{TaskID: tr.TaskID})
+				if err != nil {
+					return fmt.Errorf("reading events: %w", err)
+				}
+				tr = tracing.Build(full)[0]
+			}
+			if !tr.Complete && !exportOpen {
+				continue
+			}
+			traces = append(traces, tr)
+		}
+
+		service := cfg.Tracing.ServiceName
+		if exportEndpoint != "" {
+			exporter, err := tracing.NewExporter(exportEndpoint, service)
+			if err != nil {
+				return err
+			}
+			if err := exporter.Export(context.Background(), traces); err != nil {
+				return err
+			}
+			_, _ = fmt.Fprintf(cmd.OutOrStdout(), "Exported %d traces to %s\n", len(traces), exportEndpoint)
+			return nil
+		}
+
+		data, err := tracing.EncodeOTLP(traces, service)
+		if err != nil {
+			return err
+		}
+		if exportOutput != "" {
+			var buf bytes.Buffer
+			if err := json.Indent(&buf, data, "", "  "); err != nil {
+				return err
+			}
+			buf.WriteByte('\n')
+			if err := os.WriteFile(exportOutput, buf.Bytes(), 0o644); err != nil {
+				return fmt.Errorf("writing %s: %w", exportOutput, err)
+			}
+			_, _ = fmt.Fprintf(cmd.OutOrStdout(), "Wrote %d traces to %s\n", len(traces), exportOutput)
+			return nil
+		}
+		_, _ = cmd.OutOrStdout().Write(append(data, '\n'))
+		return nil
+	},
+}
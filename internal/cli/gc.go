@@ -0,0 +1,100 @@
+package cli
+
+import (
+	"fmt"
+	"path/filepath"
+	"text/tabwriter"
+	"time"
+
+	"github.com/anthropics/altera/internal/config"
+	"github.com/anthropics/altera/internal/daemon"
+	"github.com/anthropics/altera/internal/gc"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	rootCmd.AddCommand(gcCmd)
+	gcCmd.Flags().BoolVar(&gcDryRun, "dry-run", false, "list what would be removed without removing it")
+	gcCmd.Flags().BoolVar(&gcForce, "force", false, "also remove worktrees with uncommitted changes")
+	gcCmd.Flags().IntVar(&gcMessageDays, "message-days", 30, "remove archived messages, receipts and dead letters older than this many days (0 keeps them)")
+}
+
+var (
+	gcDryRun      bool
+	gcForce       bool
+	gcMessageDays int
+)
+
+var gcCmd = &cobra.Command{
+	Use:   "gc",
+	Short: "Remove worktrees, branches and files left behind by crashed agents",
+	Long: `Find and remove state that crashed agents and daemons leave behind:
+
+  - tmux sessions of agents that are no longer running
+  - worktrees under .alt/worktrees/ and worktrees/ with no running agent
+  - git worktree metadata whose directory is gone
+  - worker/*, alt/t-* and alt/resolve-* branches that are not in use and
+    whose work has merged into the default branch or whose task failed
+  - .tmp-* files under .alt/ from interrupted writes
+  - archived messages, receipts and dead letters older than --message-days
+
+Unmerged branches of tasks that have not failed are always kept. Worktrees
+with uncommitted changes, and the branches they have checked out, are kept
+and listed unless --force is given. Stop the daemon first, or use --dry-run
+to see what would go.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if gcMessageDays < 0 {
+			return fmt.Errorf("--message-days must be >= 0, got %d", gcMessageDays)
+		}
+		altDir, err := resolveAltDir()
+		if err != nil {
+			return fmt.Errorf("not an altera project: %w", err)
+		}
+		if !gcDryRun && daemon.ReadStatus(altDir).Running {
+			return fmt.Errorf("daemon is running; stop it first (alt daemon stop) or use --dry-run")
+		}
+		cfg, err := config.Load(altDir)
+		if err != nil {
+			return fmt.Errorf("loading config: %w", err)
+		}
+
+		c, err := gc.New(filepath.Dir(altDir), cfg)
+		if err != nil {
+			return err
+		}
+		items, runErr := c.Run(gc.Options{
+			DryRun:     gcDryRun,
+			Force:      gcForce,
+			MessageAge: time.Duration(gcMessageDays) * 24 * time.Hour,
+		})
+
+		out := cmd.OutOrStdout()
+		kept := 0
+		if len(items) > 0 {
+			w := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+			_, _ = fmt.Fprintln(w, "ACTION\tKIND\tNAME\tREASON")
+			for _, it := range items {
+				action := "remove"
+				if it.Kept {
+					action = "keep"
+					kept++
+				}
+				_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", action, it.Kind, it.Name, it.Reason)
+			}
+			_ = w.Flush()
+		}
+		switch removed := len(items) - kept; {
+		case removed == 0:
+			_, _ = fmt.Fprintln(out, "Nothing to collect.")
+		case gcDryRun:
+			_, _ = fmt.Fprintf(out, "Dry run: %d item(s) would be removed.\n", removed)
+		default:
+			_, _ = fmt.Fprintf(out, "Removed %d item(s).\n", removed)
+		}
+		if kept > 0 {
+			_, _ = fmt.Fprintf(out, "Kept %d worktree(s) with uncommitted changes; commit or discard them, or rerun with --force.\n", kept)
+		}
+		return runErr
+	},
+}
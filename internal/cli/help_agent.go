@@ -2,8 +2,10 @@ package cli
 
 import (
 	"fmt"
+	"path/filepath"
 	"strings"
 
+	"github.com/anthropics/altera/internal/config"
 	"github.com/anthropics/altera/internal/prompts/help"
 	"github.com/spf13/cobra"
 )
@@ -15,19 +17,25 @@ func init() {
 var helpAgentCmd = &cobra.Command{
 	Use:   "help <agent-type> <topic> [subtopic...]",
 	Short: "Look up agent help topics",
-	Long: `Look up embedded help content for Altera agent types.
+	Long: `Look up help content for Altera agent types.
 
-Agent types: liaison, worker
+Agent types: liaison, worker, resolver
+
+Built-in topics can be overridden, and new ones added, with files in
+.alt/prompts/<agent-type>/<topic>.md. Snippets in the prompts config key
+(agent type -> topic -> markdown) are appended to their topic.
 
 Examples:
   alt help liaison startup
   alt help worker startup
-  alt help worker task-done`,
+  alt help worker task-done
+  alt help resolver strategy`,
 	DisableFlagsInUseLine: true,
 	RunE: func(cmd *cobra.Command, args []string) error {
+		h := projectHelp()
 		if len(args) == 0 {
 			_, _ = fmt.Fprintln(cmd.OutOrStdout(), "Available agent types:")
-			for _, t := range help.AgentTypes() {
+			for _, t := range h.AgentTypes() {
 				_, _ = fmt.Fprintf(cmd.OutOrStdout(), "  %s\n", t)
 			}
 			_, _ = fmt.Fprintln(cmd.OutOrStdout(), "\nUsage: alt help <agent-type> <topic> [subtopic...]")
@@ -37,7 +45,7 @@ Examples:
 		agentType := args[0]
 
 		if len(args) == 1 {
-			topics, err := help.Topics(agentType)
+			topics, err := h.Topics(agentType)
 			if err != nil {
 				return err
 			}
@@ -48,7 +56,7 @@ Examples:
 			return nil
 		}
 
-		content, err := help.Lookup(agentType, args[1:]...)
+		content, err := h.Render(helpData(), agentType, args[1:]...)
 		if err != nil {
 			return err
 		}
@@ -61,3 +69,26 @@ Examples:
 		return nil
 	},
 }
+
+// projectHelp returns the help for the current project, or only the
+// embedded help outside a project. A config that fails to load still gets
+// the project's prompt files, just not its snippets.
+func projectHelp() *help.Project {
+	altDir, err := resolveAltDir()
+	if err != nil {
+		return help.NewProject("", nil)
+	}
+	cfg, _ := config.Load(altDir)
+	return help.NewProject(altDir, cfg.Prompts)
+}
+
+// helpData returns the template variables for `alt help` as seen by the
+// agent running it, or none outside a project.
+func helpData() help.Data {
+	altDir, err := resolveAltDir()
+	if err != nil {
+		return help.Data{}
+	}
+	role, agentID := detectRole(filepath.Dir(altDir), altDir)
+	return promptData(altDir, role, agentID)
+}
@@ -17,7 +17,10 @@ func init() {
 var initCmd = &cobra.Command{
 	Use:   "init",
 	Short: "Initialize an altera project",
-	Long:  `Creates .alt/ with full directory structure and initializes git if not already a repo.`,
+	Long: `Creates .alt/ with full directory structure and initializes git if not already a repo.
+
+The default config is validated before it is written; an existing config.json
+is migrated to the current schema.`,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		cwd, err := os.Getwd()
 		if err != nil {
@@ -33,19 +36,40 @@ var initCmd = &cobra.Command{
 			fmt.Println("Initialized git repository.")
 		}
 
+		// Build the default config before creating anything, so an invalid
+		// one is never written. default_branch follows the repo's HEAD.
+		cfgPath := filepath.Join(cwd, config.DirName, "config.json")
+		_, statErr := os.Stat(cfgPath)
+		writeConfig := os.IsNotExist(statErr)
+		cfg := config.NewConfig()
+		if writeConfig {
+			if head, err := git.HeadBranch(cwd); err == nil {
+				cfg.DefaultBranch = head
+			}
+			if err := config.ValidateProject(cfg, cwd); err != nil {
+				return fmt.Errorf("refusing to write invalid config:\n%w", err)
+			}
+		}
+
 		// Create .alt/ with all subdirectories.
 		altDir, err := config.EnsureDir(cwd)
 		if err != nil {
 			return fmt.Errorf("creating .alt/ directory: %w", err)
 		}
 
-		// Write default config.json if it doesn't exist.
-		cfgPath := filepath.Join(altDir, "config.json")
-		if _, err := os.Stat(cfgPath); os.IsNotExist(err) {
-			cfg := config.NewConfig()
+		if writeConfig {
 			if err := config.Save(altDir, cfg); err != nil {
 				return fmt.Errorf("writing default config: %w", err)
 			}
+		} else {
+			// Bring an existing config up to the current schema.
+			from, err := config.Migrate(altDir)
+			if err != nil {
+				return fmt.Errorf("migrating config: %w", err)
+			}
+			if from != config.SchemaVersion {
+				fmt.Printf("Migrated config.json from schema version %d to %d.\n", from, config.SchemaVersion)
+			}
 		}
 
 		fmt.Printf("Initialized altera project in %s\n", cwd)
@@ -48,6 +48,7 @@ func newLiaisonManager() (*liaison.Manager, error) {
 	if err != nil {
 		return nil, fmt.Errorf("opening message store: %w", err)
 	}
+	msgs.SetDirectory(agents)
 	evReader := events.NewReader(filepath.Join(altDir, "events.jsonl"))
 
 	return liaison.NewManager(root, agents, tasks, msgs, evReader), nil
@@ -136,8 +137,7 @@ func checkMessagesTabular(agentID string) error {
 		return fmt.Errorf("not an altera project: %w", err)
 	}
 
-	msgDir := filepath.Join(altDir, "messages")
-	store, err := message.NewStore(msgDir)
+	store, err := openMessageStore(altDir)
 	if err != nil {
 		return fmt.Errorf("opening message store: %w", err)
 	}
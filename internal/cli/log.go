@@ -1,11 +1,17 @@
 package cli
 
 import (
+	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"os"
 	"os/signal"
 	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
 	"text/tabwriter"
 	"time"
 
@@ -16,116 +22,294 @@ import (
 func init() {
 	rootCmd.AddCommand(logCmd)
 	logCmd.Flags().IntVar(&logLast, "last", 0, "show only the last N events")
-	logCmd.Flags().BoolVar(&logTail, "tail", false, "follow the event log (poll every 2s)")
+	logCmd.Flags().BoolVar(&logTail, "tail", false, "follow the event log as new events are appended")
+	logCmd.Flags().StringVar(&logTask, "task", "", "show only events for this task")
+	logCmd.Flags().StringVar(&logType, "type", "", "show only events of this type")
+	logCmd.Flags().StringVar(&logAgent, "agent", "", "show only events for this agent")
+	logCmd.Flags().StringVar(&logSince, "since", "", "show events after this time (duration like 2h or 3d, or RFC3339)")
+	logCmd.Flags().StringVar(&logUntil, "until", "", "show events before this time (duration like 2h or 3d, or RFC3339)")
+	logCmd.Flags().StringArrayVar(&logData, "data", nil, "show only events whose data has key=value (repeatable)")
+	logCmd.Flags().StringVar(&logFormat, "format", "table", "output format: table, jsonl, or timeline")
 }
 
 var (
-	logLast int
-	logTail bool
+	logLast   int
+	logTail   bool
+	logTask   string
+	logType   string
+	logAgent  string
+	logSince  string
+	logUntil  string
+	logData   []string
+	logFormat string
+)
+
+// Output formats for alt log.
+const (
+	logFormatTable    = "table"
+	logFormatJSONL    = "jsonl"
+	logFormatTimeline = "timeline"
 )
 
 var logCmd = &cobra.Command{
 	Use:   "log",
 	Short: "Show event log",
-	Long:  `Display events from the event log. Use --last N to show only the most recent N events. Use --tail to follow new events.`,
+	Long: `Display events from the event log.
+
+Filter with --type, --agent, --task, --since/--until (a duration such as 2h
+or 3d before now, or an RFC3339 time) and --data key=value. Use --last N to
+show only the most recent N matching events and --tail to follow new events.
+
+--format selects table (default), jsonl (one event per line), or timeline
+(events grouped per task with time since the task's first event).`,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		altDir, err := resolveAltDir()
 		if err != nil {
 			return fmt.Errorf("not an altera project: %w", err)
 		}
 
+		filter, err := buildLogFilter(time.Now())
+		if err != nil {
+			return err
+		}
+		switch logFormat {
+		case logFormatTable, logFormatJSONL, logFormatTimeline:
+		default:
+			return fmt.Errorf("invalid --format %q (want table, jsonl, or timeline)", logFormat)
+		}
+
 		evtPath := filepath.Join(altDir, "events.jsonl")
+		out := cmd.OutOrStdout()
 
 		if logTail {
-			return tailLog(evtPath)
+			if logFormat == logFormatTimeline {
+				return fmt.Errorf("--format timeline cannot be used with --tail")
+			}
+			return tailLog(out, evtPath, filter)
 		}
 
-		reader := events.NewReader(evtPath)
-
-		var evts []events.Event
-		if logLast > 0 {
-			evts, err = reader.Tail(logLast)
-		} else {
-			evts, err = reader.ReadAll()
-		}
+		evts, err := readLog(events.NewReader(evtPath), filter, logLast)
 		if err != nil {
 			if errors.Is(err, os.ErrNotExist) {
-				fmt.Println("No events recorded yet.")
+				_, _ = fmt.Fprintln(out, "No events recorded yet.")
 				return nil
 			}
 			return fmt.Errorf("reading events: %w", err)
 		}
 
-		if len(evts) == 0 {
-			fmt.Println("No events recorded yet.")
+		if len(evts) == 0 && logFormat != logFormatJSONL {
+			if isZeroFilter(filter) {
+				_, _ = fmt.Fprintln(out, "No events recorded yet.")
+			} else {
+				_, _ = fmt.Fprintln(out, "No matching events.")
+			}
 			return nil
 		}
 
-		w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
-		_, _ = fmt.Fprintln(w, "TIME\tTYPE\tAGENT\tTASK")
-		for _, ev := range evts {
-			_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%s\n",
-				ev.Timestamp.Format(time.RFC3339), ev.Type, ev.AgentID, ev.TaskID)
+		switch logFormat {
+		case logFormatJSONL:
+			return writeEventsJSONL(out, evts)
+		case logFormatTimeline:
+			writeEventsTimeline(out, evts)
+		default:
+			writeEventsTable(out, evts, true)
 		}
-		_ = w.Flush()
-
 		return nil
 	},
 }
 
-// tailLog shows the last 10 events then polls for new events every 2 seconds.
-func tailLog(evtPath string) error {
-	reader := events.NewReader(evtPath)
+// buildLogFilter turns the alt log flags into an events.Filter.
+func buildLogFilter(now time.Time) (events.Filter, error) {
+	f := events.Filter{
+		Type:    events.Type(logType),
+		AgentID: logAgent,
+		TaskID:  logTask,
+	}
+	var err error
+	if logSince != "" {
+		if f.After, err = parseTimeArg(logSince, now); err != nil {
+			return f, fmt.Errorf("invalid --since: %w", err)
+		}
+	}
+	if logUntil != "" {
+		if f.Before, err = parseTimeArg(logUntil, now); err != nil {
+			return f, fmt.Errorf("invalid --until: %w", err)
+		}
+	}
+	for _, kv := range logData {
+		k, v, ok := strings.Cut(kv, "=")
+		if !ok || k == "" {
+			return f, fmt.Errorf("invalid --data %q (want key=value)", kv)
+		}
+		if f.Data == nil {
+			f.Data = map[string]string{}
+		}
+		f.Data[k] = v
+	}
+	return f, nil
+}
 
-	// Show last 10 events as initial context.
-	evts, err := reader.Tail(10)
-	if err != nil && !errors.Is(err, os.ErrNotExist) {
-		return fmt.Errorf("reading events: %w", err)
+// parseTimeArg parses a point in time given either as a duration before
+// now ("90m", "2h", "3d") or as an RFC3339 timestamp or date.
+func parseTimeArg(s string, now time.Time) (time.Time, error) {
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		if n, err := strconv.Atoi(days); err == nil && n >= 0 {
+			return now.AddDate(0, 0, -n), nil
+		}
+	}
+	if d, err := time.ParseDuration(s); err == nil {
+		if d < 0 {
+			return time.Time{}, fmt.Errorf("duration %q must not be negative", s)
+		}
+		return now.Add(-d), nil
+	}
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02", s, time.Local); err == nil {
+		return t, nil
 	}
+	return time.Time{}, fmt.Errorf("%q is not a duration (2h, 3d) or RFC3339 time", s)
+}
+
+func isZeroFilter(f events.Filter) bool {
+	return f.Type == "" && f.AgentID == "" && f.TaskID == "" &&
+		f.After.IsZero() && f.Before.IsZero() && len(f.Data) == 0
+}
 
-	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
-	_, _ = fmt.Fprintln(w, "TIME\tTYPE\tAGENT\tTASK")
+// readLog returns the events matching filter, keeping only the last n if
+// n > 0. An unfiltered --last reads backward from the end of the log.
+func readLog(reader *events.Reader, filter events.Filter, n int) ([]events.Event, error) {
+	if n > 0 && isZeroFilter(filter) {
+		return reader.Tail(n)
+	}
+	evts, err := reader.Read(filter)
+	if err != nil {
+		return nil, err
+	}
+	if n > 0 && len(evts) > n {
+		evts = evts[len(evts)-n:]
+	}
+	return evts, nil
+}
+
+func writeEventsTable(out io.Writer, evts []events.Event, header bool) {
+	w := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	if header {
+		_, _ = fmt.Fprintln(w, "TIME\tTYPE\tAGENT\tTASK")
+	}
 	for _, ev := range evts {
 		_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%s\n",
 			ev.Timestamp.Format(time.RFC3339), ev.Type, ev.AgentID, ev.TaskID)
 	}
 	_ = w.Flush()
+}
+
+func writeEventsJSONL(out io.Writer, evts []events.Event) error {
+	enc := json.NewEncoder(out)
+	for _, ev := range evts {
+		if err := enc.Encode(ev); err != nil {
+			return fmt.Errorf("encoding event: %w", err)
+		}
+	}
+	return nil
+}
 
-	// Track the count of events we've already seen.
-	seen := 0
-	allEvts, _ := reader.ReadAll()
-	seen = len(allEvts)
+// writeEventsTimeline prints events grouped by task, tasks ordered by
+// their first event. Each line shows the offset from the task's first
+// event and the event data.
+func writeEventsTimeline(out io.Writer, evts []events.Event) {
+	var order []string
+	groups := map[string][]events.Event{}
+	for _, ev := range evts {
+		if _, ok := groups[ev.TaskID]; !ok {
+			order = append(order, ev.TaskID)
+		}
+		groups[ev.TaskID] = append(groups[ev.TaskID], ev)
+	}
 
-	// Set up Ctrl-C handler.
-	sigCh := make(chan os.Signal, 1)
-	signal.Notify(sigCh, os.Interrupt)
-	defer signal.Stop(sigCh)
+	for i, taskID := range order {
+		group := groups[taskID]
+		start := group[0].Timestamp
+		end := group[len(group)-1].Timestamp
+		if i > 0 {
+			_, _ = fmt.Fprintln(out)
+		}
+		name := taskID
+		if name == "" {
+			name = "(no task)"
+		}
+		_, _ = fmt.Fprintf(out, "%s  %s  (%d events, %s)\n",
+			name, start.Format(time.RFC3339), len(group), end.Sub(start).Round(time.Second))
 
-	ticker := time.NewTicker(2 * time.Second)
-	defer ticker.Stop()
+		w := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+		for _, ev := range group {
+			_, _ = fmt.Fprintf(w, "  +%s\t%s\t%s\t%s\n",
+				ev.Timestamp.Sub(start).Round(time.Second), ev.Type, ev.AgentID, formatEventData(ev.Data))
+		}
+		_ = w.Flush()
+	}
+}
 
-	for {
-		select {
-		case <-sigCh:
-			fmt.Println("\nStopped.")
-			return nil
-		case <-ticker.C:
-			allEvts, err := reader.ReadAll()
-			if err != nil {
-				continue
-			}
-			if len(allEvts) > seen {
-				newEvts := allEvts[seen:]
-				w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
-				for _, ev := range newEvts {
-					_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%s\n",
-						ev.Timestamp.Format(time.RFC3339), ev.Type, ev.AgentID, ev.TaskID)
-				}
-				_ = w.Flush()
-				seen = len(allEvts)
+// formatEventData renders event data as sorted key=value pairs.
+func formatEventData(data map[string]any) string {
+	keys := make([]string, 0, len(data))
+	for k := range data {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	parts := make([]string, 0, len(keys))
+	for _, k := range keys {
+		parts = append(parts, fmt.Sprintf("%s=%v", k, data[k]))
+	}
+	return strings.Join(parts, " ")
+}
+
+// tailLog shows the last 10 matching events then streams new ones as they
+// are appended until interrupted.
+func tailLog(out io.Writer, evtPath string, filter events.Filter) error {
+	reader := events.NewReader(evtPath)
+
+	// Take the cursor first so nothing appended after the initial context
+	// is missed.
+	from, err := reader.End()
+	if err != nil {
+		return fmt.Errorf("reading events: %w", err)
+	}
+
+	// Show last 10 events as initial context.
+	evts, err := readLog(reader, filter, 10)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("reading events: %w", err)
+	}
+	if logFormat == logFormatJSONL {
+		if err := writeEventsJSONL(out, evts); err != nil {
+			return err
+		}
+	} else {
+		writeEventsTable(out, evts, true)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	ch, err := reader.Watch(ctx, filter, events.FromCursor(from))
+	if err != nil {
+		return fmt.Errorf("watching events: %w", err)
+	}
+	for ev := range ch {
+		if logFormat == logFormatJSONL {
+			if err := writeEventsJSONL(out, []events.Event{ev}); err != nil {
+				return err
 			}
+			continue
 		}
+		writeEventsTable(out, []events.Event{ev}, false)
+	}
+	if logFormat != logFormatJSONL {
+		_, _ = fmt.Fprintln(out, "\nStopped.")
 	}
+	return nil
 }
 
 // logTaskCreated appends a task_created event to the event log.
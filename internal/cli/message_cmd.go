@@ -1,10 +1,13 @@
 package cli
 
 import (
+	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
+	"text/tabwriter"
+	"time"
 
 	"github.com/anthropics/altera/internal/agent"
 	"github.com/anthropics/altera/internal/message"
@@ -16,6 +19,121 @@ func init() {
 	rootCmd.AddCommand(messageCmd)
 	messageCmd.AddCommand(messageSendCmd)
 	messageCmd.AddCommand(messageReadCmd)
+	messageCmd.AddCommand(messageSubscribeCmd)
+	messageCmd.AddCommand(messageUnsubscribeCmd)
+	messageCmd.AddCommand(messageDLQCmd)
+	messageDLQCmd.AddCommand(messageDLQReplayCmd)
+
+	messageSendCmd.Flags().StringVar(&msgSendType, "type", string(message.TypeUserMessage), "message type (user_message, help, task_done, merge_result, checkpoint)")
+	messageSendCmd.Flags().StringVar(&msgSendPayload, "payload", "", "payload as a JSON object, validated against the message type")
+	messageSendCmd.Flags().StringVar(&msgSendTask, "task", "", "task ID the message refers to")
+	messageSendCmd.Flags().StringVar(&msgSendPriority, "priority", "normal", "delivery priority: low, normal or high")
+	messageSendCmd.Flags().DurationVar(&msgSendTTL, "ttl", 0, "expire the message if unread after this long (e.g. 30m)")
+	messageDLQReplayCmd.Flags().StringVar(&msgReplayTo, "to", "", "readdress the message before replaying it")
+}
+
+var (
+	msgSendType     string
+	msgSendPayload  string
+	msgSendTask     string
+	msgSendPriority string
+	msgSendTTL      time.Duration
+	msgReplayTo     string
+)
+
+// openMessageStore opens the project's message store with role resolution
+// wired to the agent store, so role: addresses reach their recipients.
+func openMessageStore(altDir string) (*message.Store, error) {
+	store, err := message.NewStore(filepath.Join(altDir, "messages"))
+	if err != nil {
+		return nil, err
+	}
+	agents, err := agent.NewStore(filepath.Join(altDir, "agents"))
+	if err != nil {
+		return nil, err
+	}
+	store.SetDirectory(agents)
+	return store, nil
+}
+
+// payloadTextField names the payload field that free text on the command
+// line fills for each message type.
+var payloadTextField = map[message.Type]string{
+	message.TypeUserMessage: "body",
+	message.TypeHelp:        "message",
+	message.TypeTaskDone:    "result",
+	message.TypeCheckpoint:  "checkpoint",
+}
+
+// buildPayload assembles a message payload from a JSON object and free
+// text, then validates it against the schema for msgType.
+func buildPayload(msgType message.Type, text, rawJSON string) (map[string]any, error) {
+	payload := map[string]any{}
+	if rawJSON != "" {
+		if err := json.Unmarshal([]byte(rawJSON), &payload); err != nil {
+			return nil, fmt.Errorf("parsing --payload: %w", err)
+		}
+	}
+	if text != "" {
+		field, ok := payloadTextField[msgType]
+		if !ok {
+			return nil, fmt.Errorf("%s messages take no text; use --payload", msgType)
+		}
+		payload[field] = text
+	}
+	if len(payload) == 0 {
+		payload = nil
+	}
+	if _, err := message.ParsePayload(msgType, payload); err != nil {
+		return nil, err
+	}
+	return payload, nil
+}
+
+// resolveRecipients returns the active agents addressed by to. A concrete
+// agent ID resolves to that agent alone, whether or not it is active.
+func resolveRecipients(msgs *message.Store, agents *agent.Store, to string) ([]*agent.Agent, error) {
+	if !message.IsGroupAddress(to) {
+		a, err := agents.Get(to)
+		if err != nil {
+			return nil, err
+		}
+		return []*agent.Agent{a}, nil
+	}
+	active, err := agents.ListByStatus(agent.StatusActive)
+	if err != nil {
+		return nil, err
+	}
+	var out []*agent.Agent
+	for _, a := range active {
+		pending, err := msgs.ListPending(a.ID)
+		if err != nil {
+			return nil, err
+		}
+		for _, m := range pending {
+			if m.To == to {
+				out = append(out, a)
+				break
+			}
+		}
+	}
+	return out, nil
+}
+
+// notifyAgent nudges an agent's tmux session about a new message and
+// returns a short note describing why notification was skipped, if it was.
+func notifyAgent(a *agent.Agent) string {
+	if a.TmuxSession == "" {
+		return "no tmux session to notify"
+	}
+	notification := "You have a new message. Read it with: alt message read"
+	if err := tmux.SendText(a.TmuxSession, notification); err != nil {
+		return fmt.Sprintf("tmux notification failed: %v", err)
+	}
+	if err := tmux.SendEnter(a.TmuxSession); err != nil {
+		return fmt.Sprintf("tmux Enter failed: %v", err)
+	}
+	return ""
 }
 
 var messageCmd = &cobra.Command{
@@ -25,62 +143,77 @@ var messageCmd = &cobra.Command{
 }
 
 var messageSendCmd = &cobra.Command{
-	Use:   "send <agent-id> <text...>",
-	Short: "Send a message to a running agent",
-	Args:  cobra.MinimumNArgs(2),
+	Use:   "send <to> [text...]",
+	Short: "Send a message to an agent, a role, a topic or everyone",
+	Long: `Send a message. The recipient is an agent ID, role:<role>
+(e.g. role:worker), topic:<name> for agents subscribed to a topic, or *
+to broadcast to every agent. Each recipient reads and acknowledges its
+copy independently.
+
+By default a user_message is sent with the text as its body. Use --type to
+send another message type; --payload takes a JSON object that is checked
+against the type's schema, and any text fills the type's main text field
+(help: message, task_done: result, checkpoint: checkpoint). Malformed
+payloads are rejected before anything is stored.`,
+	Args: cobra.MinimumNArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		altDir, err := resolveAltDir()
 		if err != nil {
 			return fmt.Errorf("not an altera project: %w", err)
 		}
 
-		agentID := args[0]
-		body := strings.Join(args[1:], " ")
+		to := args[0]
+		text := strings.Join(args[1:], " ")
+		msgType := message.Type(msgSendType)
+		payload, err := buildPayload(msgType, text, msgSendPayload)
+		if err != nil {
+			return err
+		}
 
 		// Store the message.
-		msgStore, err := message.NewStore(filepath.Join(altDir, "messages"))
+		msgStore, err := openMessageStore(altDir)
 		if err != nil {
 			return fmt.Errorf("opening message store: %w", err)
 		}
-		payload := map[string]any{"body": body}
-		if _, err := msgStore.Create(message.TypeUserMessage, "user", agentID, "", payload); err != nil {
+		priority, err := message.ParsePriority(msgSendPriority)
+		if err != nil {
+			return err
+		}
+		if _, err := msgStore.Create(msgType, "user", to, msgSendTask, payload,
+			message.WithPriority(priority), message.WithTTL(msgSendTTL)); err != nil {
 			return fmt.Errorf("creating message: %w", err)
 		}
 
-		// Look up agent to get tmux session.
+		// Look up recipients to get their tmux sessions.
 		agentStore, err := agent.NewStore(filepath.Join(altDir, "agents"))
 		if err != nil {
 			return fmt.Errorf("opening agent store: %w", err)
 		}
-		a, err := agentStore.Get(agentID)
+		recipients, err := resolveRecipients(msgStore, agentStore, to)
 		if err != nil {
 			// Message stored but can't notify — still useful.
-			fmt.Printf("Message stored for %s (could not look up agent for notification: %v)\n", agentID, err)
+			fmt.Printf("Message stored for %s (could not look up agent for notification: %v)\n", to, err)
 			return nil
 		}
-		if a.TmuxSession == "" {
-			fmt.Printf("Message stored for %s (no tmux session to notify)\n", agentID)
+		if message.IsGroupAddress(to) && len(recipients) == 0 {
+			fmt.Printf("Message stored for %s (no active agents match)\n", to)
 			return nil
 		}
 
-		notification := "You have a new message. Read it with: alt message read"
-		if err := tmux.SendText(a.TmuxSession, notification); err != nil {
-			fmt.Printf("Message stored for %s (tmux notification failed: %v)\n", agentID, err)
-			return nil
-		}
-		if err := tmux.SendEnter(a.TmuxSession); err != nil {
-			fmt.Printf("Message stored for %s (tmux Enter failed: %v)\n", agentID, err)
-			return nil
+		for _, a := range recipients {
+			if note := notifyAgent(a); note != "" {
+				fmt.Printf("Message stored for %s (%s)\n", a.ID, note)
+				continue
+			}
+			fmt.Printf("Message sent to %s\n", a.ID)
 		}
-
-		fmt.Printf("Message sent to %s\n", agentID)
 		return nil
 	},
 }
 
 var messageReadCmd = &cobra.Command{
 	Use:   "read [agent-id]",
-	Short: "Read and archive pending messages for an agent",
+	Short: "Read and acknowledge pending messages for an agent",
 	Long:  `Reads pending user messages for the given agent. If agent-id is omitted, uses the ALT_AGENT_ID environment variable.`,
 	Args:  cobra.MaximumNArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
@@ -99,7 +232,7 @@ var messageReadCmd = &cobra.Command{
 			return fmt.Errorf("agent-id required (pass as argument or set ALT_AGENT_ID)")
 		}
 
-		store, err := message.NewStore(filepath.Join(altDir, "messages"))
+		store, err := openMessageStore(altDir)
 		if err != nil {
 			return fmt.Errorf("opening message store: %w", err)
 		}
@@ -123,12 +256,126 @@ var messageReadCmd = &cobra.Command{
 		}
 
 		for _, m := range userMsgs {
-			body, _ := m.Payload["body"].(string)
-			fmt.Printf("[%s] %s\n", m.CreatedAt.Format("15:04:05"), body)
-			if err := store.Archive(m.ID); err != nil {
-				return fmt.Errorf("archiving message %s: %w", m.ID, err)
+			var p message.UserMessagePayload
+			_ = m.DecodePayload(&p)
+			fmt.Printf("[%s] %s\n", m.CreatedAt.Format("15:04:05"), p.Body)
+			if err := store.Ack(m.ID, agentID); err != nil {
+				return fmt.Errorf("acknowledging message %s: %w", m.ID, err)
 			}
 		}
 		return nil
 	},
 }
+
+var messageSubscribeCmd = &cobra.Command{
+	Use:   "subscribe <topic> [agent-id]",
+	Short: "Subscribe an agent to a message topic",
+	Long:  `Subscribes the agent to messages sent to topic:<topic>. If agent-id is omitted, uses the ALT_AGENT_ID environment variable.`,
+	Args:  cobra.RangeArgs(1, 2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return updateSubscription(args, true)
+	},
+}
+
+var messageUnsubscribeCmd = &cobra.Command{
+	Use:   "unsubscribe <topic> [agent-id]",
+	Short: "Unsubscribe an agent from a message topic",
+	Long:  `Removes the agent's subscription to topic:<topic>. If agent-id is omitted, uses the ALT_AGENT_ID environment variable.`,
+	Args:  cobra.RangeArgs(1, 2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return updateSubscription(args, false)
+	},
+}
+
+// updateSubscription implements subscribe and unsubscribe.
+func updateSubscription(args []string, subscribe bool) error {
+	altDir, err := resolveAltDir()
+	if err != nil {
+		return fmt.Errorf("not an altera project: %w", err)
+	}
+
+	topic := args[0]
+	agentID := os.Getenv("ALT_AGENT_ID")
+	if len(args) > 1 {
+		agentID = args[1]
+	}
+	if agentID == "" {
+		return fmt.Errorf("agent-id required (pass as argument or set ALT_AGENT_ID)")
+	}
+
+	store, err := openMessageStore(altDir)
+	if err != nil {
+		return fmt.Errorf("opening message store: %w", err)
+	}
+	if subscribe {
+		if err := store.Subscribe(agentID, topic); err != nil {
+			return fmt.Errorf("subscribing: %w", err)
+		}
+		fmt.Printf("%s subscribed to %s\n", agentID, message.ToTopic(topic))
+		return nil
+	}
+	if err := store.Unsubscribe(agentID, topic); err != nil {
+		return fmt.Errorf("unsubscribing: %w", err)
+	}
+	fmt.Printf("%s unsubscribed from %s\n", agentID, message.ToTopic(topic))
+	return nil
+}
+
+var messageDLQCmd = &cobra.Command{
+	Use:   "dlq",
+	Short: "List dead-lettered messages",
+	Long: `Lists messages that could not be delivered: expired messages, messages
+addressed to dead agents, and daemon messages whose handler failed too many
+times. Use 'alt message dlq replay <id>' to requeue one.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		altDir, err := resolveAltDir()
+		if err != nil {
+			return fmt.Errorf("not an altera project: %w", err)
+		}
+		store, err := openMessageStore(altDir)
+		if err != nil {
+			return fmt.Errorf("opening message store: %w", err)
+		}
+		msgs, err := store.ListDeadLetters()
+		if err != nil {
+			return fmt.Errorf("listing dead letters: %w", err)
+		}
+		if len(msgs) == 0 {
+			fmt.Println("No dead-lettered messages.")
+			return nil
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		_, _ = fmt.Fprintln(w, "ID\tTYPE\tFROM\tTO\tTASK\tATTEMPTS\tDEAD AT\tREASON")
+		for _, m := range msgs {
+			_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%d\t%s\t%s\n",
+				m.ID, m.Type, m.From, m.To, m.TaskID, m.Attempts,
+				m.DeadAt.Local().Format("2006-01-02 15:04:05"), m.DeadReason)
+		}
+		_ = w.Flush()
+		return nil
+	},
+}
+
+var messageDLQReplayCmd = &cobra.Command{
+	Use:   "replay <message-id>",
+	Short: "Move a dead-lettered message back to the pending queue",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		altDir, err := resolveAltDir()
+		if err != nil {
+			return fmt.Errorf("not an altera project: %w", err)
+		}
+		store, err := openMessageStore(altDir)
+		if err != nil {
+			return fmt.Errorf("opening message store: %w", err)
+		}
+		m, err := store.Replay(args[0], msgReplayTo)
+		if err != nil {
+			return fmt.Errorf("replaying %s: %w", args[0], err)
+		}
+		fmt.Printf("Replayed %s (%s) to %s\n", m.ID, m.Type, m.To)
+		return nil
+	},
+}
@@ -0,0 +1,112 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/anthropics/altera/internal/config"
+	"github.com/anthropics/altera/internal/events"
+	"github.com/anthropics/altera/internal/notify"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	rootCmd.AddCommand(notifyCmd)
+	notifyCmd.AddCommand(notifyListCmd)
+	notifyCmd.AddCommand(notifyTestCmd)
+	notifyTestCmd.Flags().StringVar(&notifyTestType, "type", string(events.AgentDied), "event type of the test event")
+}
+
+var notifyTestType string
+
+var notifyCmd = &cobra.Command{
+	Use:   "notify",
+	Short: "Inspect notification sinks",
+	Long: `Notification sinks are configured in the "notify" list of .alt/config.json
+and run inside the daemon. Each sink is a webhook (JSON or Slack body), a
+shell command (event JSON on stdin), or notify-send, filtered by event type,
+agent, task and data.`,
+}
+
+var notifyListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List configured sinks and their delivery cursors",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		m, cfg, err := openNotifyManager()
+		if err != nil {
+			return err
+		}
+		if len(cfg.Notify) == 0 {
+			_, _ = fmt.Fprintln(cmd.OutOrStdout(), "No notification sinks configured.")
+			return nil
+		}
+
+		w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 4, 2, ' ', 0)
+		_, _ = fmt.Fprintln(w, "NAME\tKIND\tTARGET\tTYPES\tCURSOR")
+		for _, s := range cfg.Notify {
+			target := s.URL
+			if s.Kind == config.SinkCommand {
+				target = s.Command
+			}
+			types := strings.Join(s.Types, ",")
+			if types == "" {
+				types = "*"
+			}
+			cursor := "-"
+			if c, ok, _ := events.LoadCursor(m.CursorPath(s.Name)); ok {
+				cursor = fmt.Sprintf("%d:%d", c.Seq, c.Offset)
+			}
+			_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", s.Name, s.Kind, target, types, cursor)
+		}
+		return w.Flush()
+	},
+}
+
+var notifyTestCmd = &cobra.Command{
+	Use:   "test <sink>",
+	Short: "Send a test event to one sink, ignoring its filters",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		m, _, err := openNotifyManager()
+		if err != nil {
+			return err
+		}
+		for _, s := range m.Sinks() {
+			if s.Name() != args[0] {
+				continue
+			}
+			ev := events.Event{
+				Timestamp: time.Now(),
+				Type:      events.Type(notifyTestType),
+				Data:      map[string]any{"test": true},
+			}
+			if err := s.Deliver(context.Background(), ev); err != nil {
+				return err
+			}
+			_, _ = fmt.Fprintf(cmd.OutOrStdout(), "Delivered test %s event to %s\n", ev.Type, s.Name())
+			return nil
+		}
+		return fmt.Errorf("no sink named %q", args[0])
+	},
+}
+
+// openNotifyManager builds a notify.Manager from the project config so the
+// CLI sees the same sinks and cursor paths as the daemon.
+func openNotifyManager() (*notify.Manager, config.Config, error) {
+	altDir, err := resolveAltDir()
+	if err != nil {
+		return nil, config.Config{}, fmt.Errorf("not an altera project: %w", err)
+	}
+	cfg, err := config.Load(altDir)
+	if err != nil {
+		return nil, cfg, err
+	}
+	reader := events.NewReader(filepath.Join(altDir, "events.jsonl"))
+	m, err := notify.NewManager(altDir, reader, cfg.Notify, nil)
+	return m, cfg, err
+}
@@ -1,6 +1,7 @@
 package cli
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"os/exec"
@@ -13,12 +14,13 @@ import (
 	"github.com/anthropics/altera/internal/message"
 	"github.com/anthropics/altera/internal/prompts/help"
 	"github.com/anthropics/altera/internal/task"
+	"github.com/anthropics/altera/internal/tmux"
 	"github.com/spf13/cobra"
 )
 
 func init() {
 	rootCmd.AddCommand(primeCmd)
-	primeCmd.Flags().StringVar(&primeRole, "role", "", "force role (liaison or worker)")
+	primeCmd.Flags().StringVar(&primeRole, "role", "", "force role (liaison, worker or resolver)")
 	primeCmd.Flags().StringVar(&primeAgentID, "agent-id", "", "explicit agent ID")
 }
 
@@ -44,7 +46,9 @@ tmux session name, working directory, default to liaison.`,
 
 		switch role {
 		case "worker":
-			return primeWorker(root, altDir, agentID)
+			return primeWorker(altDir, agentID)
+		case "resolver":
+			return primeResolver(altDir, agentID)
 		default:
 			return primeLiaison(root, altDir)
 		}
@@ -55,8 +59,11 @@ tmux session name, working directory, default to liaison.`,
 // 1. --role/--agent-id flags
 // 2. ALT_AGENT_ID env var
 // 3. tmux session name
-// 4. working directory (inside worktrees/{id} = worker)
+// 4. working directory (inside worktrees/{id} = worker or resolver)
 // 5. default to liaison
+//
+// An agent's role comes from its record when it has one, so daemon
+// workers ("w-…") and resolvers are recognised as well as "worker-NN".
 func detectRole(root, altDir string) (string, string) {
 	// Explicit flags take priority.
 	if primeRole != "" {
@@ -65,53 +72,76 @@ func detectRole(root, altDir string) (string, string) {
 
 	// Check ALT_AGENT_ID env var.
 	if envID := os.Getenv("ALT_AGENT_ID"); envID != "" {
-		if strings.HasPrefix(envID, "worker-") {
-			return "worker", envID
-		}
-		return "liaison", envID
+		return roleOf(altDir, envID, "liaison"), envID
 	}
 
 	// Explicit --agent-id flag.
 	if primeAgentID != "" {
-		if strings.HasPrefix(primeAgentID, "worker-") {
-			return "worker", primeAgentID
-		}
-		return "liaison", primeAgentID
+		return roleOf(altDir, primeAgentID, "liaison"), primeAgentID
 	}
 
-	// Try tmux session name.
+	// Try tmux session name: alt-liaison, alt-worker-{id}, alt-resolver-{id}.
 	tmuxCmd := exec.Command("tmux", "display-message", "-p", "#{session_name}")
 	if out, err := tmuxCmd.Output(); err == nil {
 		sessionName := strings.TrimSpace(string(out))
-		if strings.HasPrefix(sessionName, "alt-worker-") {
-			id := strings.TrimPrefix(sessionName, "alt-")
-			return "worker", id
+		if sessionName == tmux.SessionPrefix+"liaison" {
+			return "liaison", liaison.AgentID
 		}
-		if sessionName == "alt-liaison" {
-			return "liaison", "liaison-01"
+		for _, role := range []string{"worker", "resolver"} {
+			if id, ok := strings.CutPrefix(sessionName, tmux.SessionPrefix+role+"-"); ok && id != "" {
+				return role, id
+			}
 		}
 	}
 
-	// Check working directory - if inside worktrees/{id}, it's a worker.
+	// Check working directory - if inside worktrees/{id}, it's a worker or
+	// a resolver.
 	if cwd, err := os.Getwd(); err == nil {
 		worktreeDir := filepath.Join(root, "worktrees")
 		if rel, err := filepath.Rel(worktreeDir, cwd); err == nil && !strings.HasPrefix(rel, "..") {
 			parts := strings.SplitN(rel, string(filepath.Separator), 2)
-			if len(parts) > 0 && parts[0] != "" {
-				return "worker", parts[0]
+			if len(parts) > 0 && parts[0] != "" && parts[0] != "." {
+				fallback := "worker"
+				if _, err := os.Stat(filepath.Join(worktreeDir, parts[0], "conflict-context.json")); err == nil {
+					fallback = "resolver"
+				}
+				return roleOf(altDir, parts[0], fallback), parts[0]
 			}
 		}
 	}
 
-	return "liaison", "liaison-01"
+	return "liaison", liaison.AgentID
+}
+
+// roleOf returns the role of agent id: from its record if it has one,
+// otherwise guessed from the ID's prefix, otherwise fallback.
+func roleOf(altDir, id, fallback string) string {
+	if agents, err := agent.NewStore(filepath.Join(altDir, "agents")); err == nil {
+		if role := agents.RoleOf(id); role != "" {
+			return role
+		}
+	}
+	switch {
+	case strings.HasPrefix(id, "resolver-"):
+		return "resolver"
+	case strings.HasPrefix(id, "worker-"), strings.HasPrefix(id, "w-"):
+		return "worker"
+	}
+	return fallback
 }
 
-// primeLiaison outputs a slim role header + runtime state.
+// primeLiaison outputs the rendered liaison startup help + runtime state.
 func primeLiaison(root, altDir string) error {
+	h := projectHelp()
+	data := promptData(altDir, "liaison", liaison.AgentID)
+
 	// Inline the full liaison startup help so the agent has instructions immediately.
-	startupHelp, err := help.Lookup("liaison", "startup")
+	startupHelp, err := h.Render(data, "liaison", "startup")
 	if err != nil {
-		// Fallback to the brief header if help content is missing.
+		if !errors.Is(err, help.ErrNotFound) {
+			fmt.Fprintf(os.Stderr, "warning: %v\n", err)
+		}
+		// Fallback to the brief header if help content is missing or broken.
 		fmt.Println("# Liaison Agent")
 		fmt.Println()
 		fmt.Println("You are the liaison agent in the Altera multi-agent orchestration system.")
@@ -134,6 +164,7 @@ func primeLiaison(root, altDir string) error {
 	if err != nil {
 		return fmt.Errorf("opening message store: %w", err)
 	}
+	msgStore.SetDirectory(agentStore)
 	evReader := events.NewReader(filepath.Join(altDir, "events.jsonl"))
 
 	m := liaison.NewManager(root, agentStore, taskStore, msgStore, evReader)
@@ -143,62 +174,56 @@ func primeLiaison(root, altDir string) error {
 	}
 	fmt.Print(summary)
 
+	if project := renderPrime(h, data); project != "" {
+		fmt.Println()
+		fmt.Print(project)
+	}
 	return nil
 }
 
-// primeWorker outputs the worker system prompt + task.json contents + checkpoint state.
-func primeWorker(root, altDir, agentID string) error {
+// primeWorker outputs the worker prime template: role header, task,
+// task.json contents and checkpoint state.
+func primeWorker(altDir, agentID string) error {
 	agentStore, err := agent.NewStore(filepath.Join(altDir, "agents"))
 	if err != nil {
 		return fmt.Errorf("opening agent store: %w", err)
 	}
-
-	a, err := agentStore.Get(agentID)
-	if err != nil {
+	if _, err := agentStore.Get(agentID); err != nil {
 		// If agent not found, output a generic worker prompt.
 		fmt.Printf("# Worker Agent: %s\n\nAgent record not found. Operating in standalone mode.\n", agentID)
 		return nil
 	}
 
-	// Load task details if available.
-	var t *task.Task
-	if a.CurrentTask != "" {
-		taskStore, err := task.NewStore(root)
-		if err == nil {
-			t, _ = taskStore.Get(a.CurrentTask)
-		}
-	}
+	fmt.Print(renderPrime(projectHelp(), promptData(altDir, "worker", agentID)))
+	return nil
+}
 
-	// Output slim role header.
-	fmt.Printf("# Worker Agent: %s\n\n", agentID)
-	if t != nil {
-		fmt.Printf("- **Task**: %s `%s`\n", t.Title, t.ID)
-	} else {
-		fmt.Println("No task currently assigned.")
-	}
-	fmt.Println()
-	fmt.Println("Use `alt help worker startup` for instructions. Use `alt <command> --help` for syntax.")
-	fmt.Println()
-
-	// Output task.json contents if available in the worktree.
-	if a.Worktree != "" {
-		taskJSONPath := filepath.Join(a.Worktree, "task.json")
-		if data, err := os.ReadFile(taskJSONPath); err == nil {
-			fmt.Println("## task.json")
-			fmt.Println("```json")
-			fmt.Print(string(data))
-			fmt.Println("```")
-			fmt.Println()
-		}
+// primeResolver outputs the resolver prime template: role header, task
+// intent, attempt number and the conflicting hunks from conflict-context.json.
+func primeResolver(altDir, agentID string) error {
+	agentStore, err := agent.NewStore(filepath.Join(altDir, "agents"))
+	if err != nil {
+		return fmt.Errorf("opening agent store: %w", err)
 	}
-
-	// Output checkpoint state if available.
-	if t != nil && t.Checkpoint != "" {
-		fmt.Println("## Checkpoint (resuming)")
-		fmt.Println()
-		fmt.Println(t.Checkpoint)
-		fmt.Println()
+	if _, err := agentStore.Get(agentID); err != nil {
+		fmt.Printf("# Resolver Agent: %s\n\nAgent record not found. Operating in standalone mode.\n", agentID)
+		return nil
 	}
 
+	fmt.Print(renderPrime(projectHelp(), promptData(altDir, "resolver", agentID)))
 	return nil
 }
+
+// renderPrime renders the role's prime template followed by the project's
+// "prime" topic. A broken project template is reported on stderr rather
+// than failing the hook, so the built-in part still reaches the agent.
+func renderPrime(h *help.Project, data help.Data) string {
+	out, err := h.Prime(data)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "warning: %v\n", err)
+	}
+	if out != "" && !strings.HasSuffix(out, "\n") {
+		out += "\n"
+	}
+	return out
+}
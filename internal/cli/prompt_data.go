@@ -0,0 +1,156 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/anthropics/altera/internal/agent"
+	"github.com/anthropics/altera/internal/config"
+	"github.com/anthropics/altera/internal/events"
+	"github.com/anthropics/altera/internal/merge"
+	"github.com/anthropics/altera/internal/prompts/help"
+	"github.com/anthropics/altera/internal/resolver"
+	"github.com/anthropics/altera/internal/task"
+)
+
+// promptRecentEvents is how many recent events prompt templates see.
+const promptRecentEvents = 10
+
+// promptHunkLines caps each side of a conflict hunk shown to a resolver;
+// the rest is in the file.
+const promptHunkLines = 40
+
+// promptData gathers the variables help and prime templates see for the
+// agent agentID. Records that cannot be read leave their fields empty.
+func promptData(altDir, role, agentID string) help.Data {
+	data := help.Data{Agent: help.Agent{ID: agentID, Role: role}}
+	root := filepath.Dir(altDir)
+
+	cfg, cfgErr := config.Load(altDir)
+	if cfgErr == nil {
+		data.Config = help.Config{
+			RepoPath:      cfg.RepoPath,
+			DefaultBranch: cfg.DefaultBranch,
+			TestCommand:   cfg.TestCommand,
+		}
+	}
+
+	var taskID string
+	if agents, err := agent.NewStore(filepath.Join(altDir, "agents")); err == nil {
+		if a, err := agents.Get(agentID); err == nil {
+			data.Agent.Worktree = a.Worktree
+			taskID = a.CurrentTask
+			if a.Worktree != "" {
+				if b, err := os.ReadFile(filepath.Join(a.Worktree, "task.json")); err == nil {
+					data.TaskJSON = string(b)
+				}
+				if a.Role == agent.RoleResolver {
+					data.Conflict, _ = conflictData(a.Worktree, cfg.Daemon.MaxResolverAttempts)
+				}
+			}
+		}
+	}
+
+	reader := events.NewReader(filepath.Join(altDir, "events.jsonl"))
+	if taskID != "" {
+		if tasks, err := task.NewStore(root); err == nil {
+			if t, err := tasks.Get(taskID); err == nil {
+				spawned, _ := reader.Read(events.Filter{Type: events.AgentSpawned, TaskID: t.ID})
+				data.Task = &help.Task{
+					ID:         t.ID,
+					Title:      t.Title,
+					Intent:     t.Description,
+					Status:     string(t.Status),
+					Branch:     t.Branch,
+					Checkpoint: t.Checkpoint,
+					Deps:       t.Deps,
+					Tags:       t.Tags,
+					Priority:   t.Priority,
+					Attempts:   len(spawned),
+					Budget:     t.Budget,
+				}
+			}
+		}
+	}
+
+	// Workers see their task's history; everyone else the whole log.
+	var recent []events.Event
+	if taskID != "" {
+		recent, _ = reader.Read(events.Filter{TaskID: taskID})
+		recent = recent[max(0, len(recent)-promptRecentEvents):]
+	} else {
+		recent, _ = reader.Tail(promptRecentEvents)
+	}
+	for _, ev := range recent {
+		data.Events = append(data.Events, help.Event{
+			Time:    ev.Timestamp,
+			Type:    string(ev.Type),
+			AgentID: ev.AgentID,
+			TaskID:  ev.TaskID,
+		})
+	}
+	return data
+}
+
+// conflictData reads a resolver's conflict-context.json and the hunks still
+// marked in each conflicting file of its worktree.
+func conflictData(worktree string, maxAttempts int) (*help.Conflict, error) {
+	b, err := os.ReadFile(filepath.Join(worktree, "conflict-context.json"))
+	if err != nil {
+		return nil, err
+	}
+	var ctx resolver.ConflictContext
+	if err := json.Unmarshal(b, &ctx); err != nil {
+		return nil, fmt.Errorf("parse conflict-context.json: %w", err)
+	}
+
+	c := &help.Conflict{
+		TaskID:      ctx.TaskID,
+		Branch:      ctx.Branch,
+		BaseBranch:  ctx.BaseBranch,
+		Intent:      ctx.TaskDescription,
+		Attempt:     max(ctx.ResolveAttempt, 1),
+		MaxAttempts: maxAttempts,
+	}
+	for _, info := range ctx.Conflicts {
+		file := help.ConflictFile{Path: info.Path}
+		path := filepath.Join(worktree, info.Path)
+		content, err := os.ReadFile(path)
+		if err != nil {
+			c.Files = append(c.Files, file)
+			continue
+		}
+		lines := strings.Split(string(content), "\n")
+		for _, m := range merge.ExtractConflicts(path).Markers {
+			file.Hunks = append(file.Hunks, help.Hunk{
+				Line:   m.OursStart,
+				Ours:   hunkSide(lines, m.OursStart, m.OursEnd),
+				Theirs: hunkSide(lines, m.TheirsStart, m.TheirsEnd),
+			})
+		}
+		c.Files = append(c.Files, file)
+	}
+	return c, nil
+}
+
+// hunkSide returns the lines strictly between the 1-based marker lines
+// start and end, one per line, capped at promptHunkLines. A side whose
+// marker is missing, as in a half-edited hunk without =======, is empty.
+func hunkSide(lines []string, start, end int) string {
+	if start <= 0 || end-1 < start {
+		return ""
+	}
+	side := lines[min(start, len(lines)):min(end-1, len(lines))]
+	var b strings.Builder
+	for i, line := range side {
+		if i == promptHunkLines {
+			fmt.Fprintf(&b, "... (%d more lines)\n", len(side)-i)
+			break
+		}
+		b.WriteString(line + "\n")
+	}
+	return b.String()
+}
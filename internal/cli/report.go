@@ -0,0 +1,123 @@
+package cli
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/anthropics/altera/internal/agent"
+	"github.com/anthropics/altera/internal/events"
+	"github.com/anthropics/altera/internal/report"
+	"github.com/anthropics/altera/internal/task"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	rootCmd.AddCommand(reportCmd)
+	reportCmd.Flags().StringVar(&reportFormat, "format", report.FormatMarkdown, "output format: markdown, json or html")
+	reportCmd.Flags().StringVarP(&reportOutput, "output", "o", "", "write the report to this file instead of stdout")
+	reportCmd.Flags().StringVar(&reportSince, "since", "", "only cover tasks with activity after this time (duration like 2h or 3d, or RFC3339)")
+}
+
+var (
+	reportFormat string
+	reportOutput string
+	reportSince  string
+)
+
+var reportCmd = &cobra.Command{
+	Use:   "report",
+	Short: "Summarise a run: cycle times, merge conflicts, agent deaths and cost",
+	Long: `Compute a run report from the event log and the task and agent stores:
+
+  - task cycle time (created → merged), time waiting for a worker, and time
+    in the merge queue
+  - merge conflict rate and resolver success rate
+  - agent deaths by reason
+  - token cost in total and per task
+
+The report is Markdown by default. --format json emits the raw numbers, and
+--format html writes a self-contained page with a Gantt chart of agent
+activity.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		switch reportFormat {
+		case report.FormatMarkdown, "md", report.FormatJSON, report.FormatHTML:
+		default:
+			return fmt.Errorf("invalid --format %q (want markdown, json or html)", reportFormat)
+		}
+		altDir, err := resolveAltDir()
+		if err != nil {
+			return fmt.Errorf("not an altera project: %w", err)
+		}
+		now := time.Now()
+
+		var filter events.Filter
+		if reportSince != "" {
+			if filter.After, err = parseTimeArg(reportSince, now); err != nil {
+				return fmt.Errorf("invalid --since: %w", err)
+			}
+		}
+		evts, err := events.NewReader(filepath.Join(altDir, "events.jsonl")).Read(filter)
+		if err != nil {
+			return fmt.Errorf("reading events: %w", err)
+		}
+
+		ts, err := task.NewStore(filepath.Dir(altDir))
+		if err != nil {
+			return err
+		}
+		tasks, err := ts.List(task.Filter{})
+		if err != nil {
+			return err
+		}
+		if !filter.After.IsZero() {
+			tasks = tasksActiveSince(tasks, evts, filter.After)
+		}
+
+		as, err := agent.NewStore(filepath.Join(altDir, "agents"))
+		if err != nil {
+			return err
+		}
+		agents, err := as.ListByStatus(agent.StatusActive)
+		if err != nil {
+			return err
+		}
+		dead, err := as.ListByStatus(agent.StatusDead)
+		if err != nil {
+			return err
+		}
+		agents = append(agents, dead...)
+
+		var buf bytes.Buffer
+		if err := report.Render(&buf, report.Build(evts, tasks, agents, now), reportFormat); err != nil {
+			return err
+		}
+		if reportOutput != "" {
+			if err := os.WriteFile(reportOutput, buf.Bytes(), 0o644); err != nil {
+				return fmt.Errorf("writing %s: %w", reportOutput, err)
+			}
+			_, _ = fmt.Fprintf(cmd.OutOrStdout(), "Wrote %s report to %s\n", reportFormat, reportOutput)
+			return nil
+		}
+		_, err = cmd.OutOrStdout().Write(buf.Bytes())
+		return err
+	},
+}
+
+// tasksActiveSince keeps tasks created after since or mentioned in evts.
+func tasksActiveSince(tasks []*task.Task, evts []events.Event, since time.Time) []*task.Task {
+	seen := map[string]bool{}
+	for _, ev := range evts {
+		seen[ev.TaskID] = true
+	}
+	var out []*task.Task
+	for _, t := range tasks {
+		if seen[t.ID] || t.CreatedAt.After(since) {
+			out = append(out, t)
+		}
+	}
+	return out
+}
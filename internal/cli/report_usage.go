@@ -0,0 +1,100 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+
+	"github.com/anthropics/altera/internal/agent"
+	"github.com/anthropics/altera/internal/config"
+	"github.com/anthropics/altera/internal/events"
+	"github.com/anthropics/altera/internal/usage"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	rootCmd.AddCommand(reportUsageCmd)
+	f := reportUsageCmd.Flags()
+	f.StringVar(&usageModel, "model", "", "model name, used to look up prices (required)")
+	f.Int64Var(&usageInput, "input", 0, "input tokens")
+	f.Int64Var(&usageOutput, "output", 0, "output tokens")
+	f.Int64Var(&usageCacheWrite, "cache-write", 0, "cache creation input tokens")
+	f.Int64Var(&usageCacheRead, "cache-read", 0, "cache read input tokens")
+	f.Float64Var(&usageCost, "cost", -1, "cost in USD; computed from the price table when omitted")
+	f.StringVar(&usageTask, "task", "", "task the usage belongs to (default: the agent's current task)")
+}
+
+var (
+	usageModel      string
+	usageInput      int64
+	usageOutput     int64
+	usageCacheWrite int64
+	usageCacheRead  int64
+	usageCost       float64
+	usageTask       string
+)
+
+var reportUsageCmd = &cobra.Command{
+	Use:   "report-usage <agent-id>",
+	Short: "Record token usage for an agent",
+	Long: `Records a token_usage event with the cost of the given token counts, which
+counts toward constraints.budget_ceiling. The cost is computed from the price
+table (built-in prices, overridden by "pricing" in .alt/config.json) unless
+--cost is given.
+
+The daemon already reads usage from Claude Code transcripts. Agents that run
+this command are treated as reporting their own usage, and their transcripts
+are no longer counted.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if usageModel == "" {
+			return errors.New("--model is required")
+		}
+		if usageInput < 0 || usageOutput < 0 || usageCacheWrite < 0 || usageCacheRead < 0 {
+			return errors.New("token counts must be >= 0")
+		}
+		altDir, err := resolveAltDir()
+		if err != nil {
+			return fmt.Errorf("not an altera project: %w", err)
+		}
+		cfg, err := config.Load(altDir)
+		if err != nil {
+			return err
+		}
+
+		agentID := args[0]
+		taskID := usageTask
+		if taskID == "" {
+			store, err := agent.NewStore(filepath.Join(altDir, "agents"))
+			if err != nil {
+				return fmt.Errorf("opening agent store: %w", err)
+			}
+			if a, err := store.Get(agentID); err == nil {
+				taskID = a.CurrentTask
+			}
+		}
+
+		u := usage.Usage{
+			Model:               usageModel,
+			InputTokens:         usageInput,
+			OutputTokens:        usageOutput,
+			CacheCreationTokens: usageCacheWrite,
+			CacheReadTokens:     usageCacheRead,
+		}
+		cost := usageCost
+		if cost < 0 {
+			var ok bool
+			if cost, ok = usage.NewPrices(cfg.Pricing).Cost(u); !ok {
+				return fmt.Errorf("no price for model %q: pass --cost or add it to \"pricing\" in config.json", usageModel)
+			}
+		}
+
+		w := events.NewWriter(filepath.Join(altDir, "events.jsonl"))
+		if err := w.Append(usage.NewEvent(agentID, taskID, u, cost, usage.SourceAgent)); err != nil {
+			return fmt.Errorf("recording usage: %w", err)
+		}
+		_, _ = fmt.Fprintf(cmd.OutOrStdout(), "Recorded %d tokens ($%.4f) for agent %s\n",
+			u.InputTokens+u.OutputTokens+u.CacheCreationTokens+u.CacheReadTokens, cost, agentID)
+		return nil
+	},
+}
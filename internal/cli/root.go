@@ -14,6 +14,17 @@ var rootCmd = &cobra.Command{
 	Short:         "Altera - multi-agent orchestration system",
 	Long:          `Altera is a multi-agent orchestration system with filesystem-based state (.alt/ directory).`,
 	SilenceUsage:  true,
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		return config.SetFlagOverrides(configOverrides)
+	},
+}
+
+// configOverrides holds --set key=value pairs, the highest-precedence
+// config layer.
+var configOverrides []string
+
+func init() {
+	rootCmd.PersistentFlags().StringArrayVar(&configOverrides, "set", nil, "override a config key for this command (key=value, repeatable)")
 }
 
 func Execute() error {
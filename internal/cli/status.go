@@ -5,11 +5,14 @@ import (
 	"os"
 	"os/signal"
 	"path/filepath"
+	"strings"
 	"syscall"
 	"text/tabwriter"
 	"time"
 
 	"github.com/anthropics/altera/internal/agent"
+	"github.com/anthropics/altera/internal/config"
+	"github.com/anthropics/altera/internal/constraints"
 	"github.com/anthropics/altera/internal/daemon"
 	"github.com/anthropics/altera/internal/events"
 	"github.com/anthropics/altera/internal/git"
@@ -30,7 +33,7 @@ func init() {
 var statusCmd = &cobra.Command{
 	Use:   "status",
 	Short: "Show project status overview",
-	Long:  `Displays a formatted table of tasks, agents, worktrees, branches, tmux sessions, merge queue, daemon status, and recent events.`,
+	Long:  `Displays a formatted table of tasks, agents, worktrees, branches, tmux sessions, merge queue, daemon status, budget, host resources, and recent events.`,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		altDir, err := resolveAltDir()
 		if err != nil {
@@ -176,6 +179,20 @@ func runStatus(root, altDir string) error {
 
 	fmt.Println()
 
+	// Budget section
+	if err := printBudget(altDir); err != nil {
+		return err
+	}
+
+	fmt.Println()
+
+	// Host section
+	if err := printHost(altDir, st.Running); err != nil {
+		return err
+	}
+
+	fmt.Println()
+
 	// Agents section
 	w = tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
 	agentStore, err := agent.NewStore(filepath.Join(altDir, "agents"))
@@ -243,3 +260,101 @@ func runStatus(root, altDir string) error {
 
 	return nil
 }
+
+// printBudget prints lifetime spend against the ceiling and the allowance
+// left in each rolling budget window.
+func printBudget(altDir string) error {
+	cfg, err := config.Load(altDir)
+	if err != nil {
+		return err
+	}
+	checker := constraints.NewChecker(cfg.Constraints, nil, events.NewReader(filepath.Join(altDir, "events.jsonl")), "")
+	used, err := checker.BudgetUsed()
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	windows, err := checker.Windows(now)
+	if err != nil {
+		return err
+	}
+
+	if cfg.Constraints.BudgetCeiling > 0 {
+		fmt.Printf("BUDGET: $%.2f of $%.2f used\n", used, cfg.Constraints.BudgetCeiling)
+	} else {
+		fmt.Printf("BUDGET: $%.2f used (no lifetime ceiling)\n", used)
+	}
+	if len(windows) == 0 {
+		return nil
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	_, _ = fmt.Fprintln(w, "WINDOW\tLIMIT\tSPENT\tREMAINING\tRESETS")
+	for _, b := range windows {
+		resets := "-"
+		if !b.ResetsAt.IsZero() {
+			resets = b.ResetsAt.Local().Format("2006-01-02 15:04") + " (in " + b.ResetsAt.Sub(now).Round(time.Minute).String() + ")"
+		}
+		remaining := fmt.Sprintf("$%.2f", b.Remaining())
+		if b.Exhausted() {
+			remaining += " (paused)"
+		}
+		_, _ = fmt.Fprintf(w, "%s\t$%.2f\t$%.2f\t%s\t%s\n", b.Name, b.Limit, b.Spent, remaining, resets)
+	}
+	_ = w.Flush()
+	return nil
+}
+
+// printHost prints the host resources the spawn constraints check, with
+// their limits, and why the daemon last could not spawn a worker.
+func printHost(altDir string, daemonRunning bool) error {
+	cfg, err := config.Load(altDir)
+	if err != nil {
+		return err
+	}
+	checker := constraints.NewChecker(cfg.Constraints, nil, nil, filepath.Join(altDir, "merge-queue"))
+	host, err := checker.HostStats()
+	if err != nil {
+		return err
+	}
+	c := cfg.Constraints
+
+	var parts []string
+	if host.LoadAvg != nil {
+		p := fmt.Sprintf("load %.2f", *host.LoadAvg)
+		if c.MaxLoadAvg > 0 {
+			p += fmt.Sprintf(" (max %.2f)", c.MaxLoadAvg)
+		}
+		parts = append(parts, p)
+	}
+	if host.FreeMemoryMB != nil {
+		p := fmt.Sprintf("free memory %d MB", *host.FreeMemoryMB)
+		if c.MinFreeMemoryMB > 0 {
+			p += fmt.Sprintf(" (min %d MB)", c.MinFreeMemoryMB)
+		}
+		parts = append(parts, p)
+	}
+	if host.FreeDiskMB != nil {
+		p := fmt.Sprintf("free disk %d MB", *host.FreeDiskMB)
+		if c.MinFreeDiskMB > 0 {
+			p += fmt.Sprintf(" (min %d MB)", c.MinFreeDiskMB)
+		}
+		parts = append(parts, p)
+	}
+	if len(parts) == 0 {
+		fmt.Println("HOST: (unavailable)")
+	} else {
+		fmt.Printf("HOST: %s\n", strings.Join(parts, ", "))
+	}
+
+	if ok, reason, err := checker.CheckHost(); err != nil {
+		fmt.Printf("  host check error: %v\n", err)
+	} else if !ok {
+		fmt.Printf("  spawning blocked: %s\n", reason)
+	}
+	if daemonRunning {
+		if state, err := daemon.ReadState(altDir); err == nil && state.SpawnBlocked != "" {
+			fmt.Printf("  daemon last blocked: %s\n", state.SpawnBlocked)
+		}
+	}
+	return nil
+}
@@ -4,9 +4,14 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"text/tabwriter"
 
+	"github.com/anthropics/altera/internal/agent"
+	"github.com/anthropics/altera/internal/config"
+	"github.com/anthropics/altera/internal/constraints"
+	"github.com/anthropics/altera/internal/events"
 	"github.com/anthropics/altera/internal/task"
 	"github.com/spf13/cobra"
 )
@@ -16,6 +21,7 @@ func init() {
 	taskCmd.AddCommand(taskListCmd)
 	taskCmd.AddCommand(taskShowCmd)
 	taskCmd.AddCommand(taskCreateCmd)
+	taskCmd.AddCommand(taskBudgetCmd)
 
 	taskListCmd.Flags().StringVar(&taskListStatus, "status", "", "filter by status (open, assigned, in_progress, done, failed)")
 	taskListCmd.Flags().StringVar(&taskListAssignee, "assignee", "", "filter by assignee")
@@ -23,6 +29,8 @@ func init() {
 
 	taskCreateCmd.Flags().StringVar(&taskCreateTitle, "title", "", "task title (required)")
 	taskCreateCmd.Flags().StringVar(&taskCreateDesc, "description", "", "task description")
+	taskCreateCmd.Flags().Float64Var(&taskCreateBudget, "budget", 0, "spend cap for the task in USD (0 means none)")
+	taskCreateCmd.Flags().StringArrayVar(&taskCreateTags, "tag", nil, "tag the task (repeatable); profile:<name> picks an agent profile")
 }
 
 var (
@@ -30,14 +38,16 @@ var (
 	taskListAssignee string
 	taskListTag      string
 
-	taskCreateTitle string
-	taskCreateDesc  string
+	taskCreateTitle  string
+	taskCreateDesc   string
+	taskCreateBudget float64
+	taskCreateTags   []string
 )
 
 var taskCmd = &cobra.Command{
 	Use:   "task",
 	Short: "Manage tasks",
-	Long:  `Create, list, and show tasks.`,
+	Long:  `Create, list, and show tasks, and set their budgets.`,
 }
 
 var taskListCmd = &cobra.Command{
@@ -142,6 +152,13 @@ var taskShowCmd = &cobra.Command{
 		if t.Checkpoint != "" {
 			fmt.Printf("Checkpoint:  %s\n", t.Checkpoint)
 		}
+		if t.Budget > 0 {
+			spent, err := taskSpend(root, t.ID)
+			if err != nil {
+				return err
+			}
+			fmt.Printf("Budget:      $%.2f ($%.2f spent)\n", t.Budget, spent)
+		}
 
 		return nil
 	},
@@ -150,11 +167,17 @@ var taskShowCmd = &cobra.Command{
 var taskCreateCmd = &cobra.Command{
 	Use:   "create",
 	Short: "Create a new task",
-	Long:  `Create a new task with --title and optional --description.`,
+	Long: `Create a new task with --title and optional --description.
+
+A "profile:<name>" tag runs the task's worker with the named agent profile
+from the agents config key instead of the worker profile.`,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		if taskCreateTitle == "" {
 			return fmt.Errorf("--title is required")
 		}
+		if taskCreateBudget < 0 {
+			return fmt.Errorf("--budget must be >= 0")
+		}
 
 		root, err := projectRoot()
 		if err != nil {
@@ -166,9 +189,21 @@ var taskCreateCmd = &cobra.Command{
 			return fmt.Errorf("opening task store: %w", err)
 		}
 
+		if name := agent.ProfileName(taskCreateTags); name != "" {
+			cfg, err := config.Load(filepath.Join(root, config.DirName))
+			if err != nil {
+				return err
+			}
+			if _, err := agent.Profile(cfg, agent.RoleWorker, name); err != nil {
+				return err
+			}
+		}
+
 		t := &task.Task{
 			Title:       taskCreateTitle,
 			Description: taskCreateDesc,
+			Budget:      taskCreateBudget,
+			Tags:        taskCreateTags,
 		}
 		if err := store.Create(t); err != nil {
 			return fmt.Errorf("creating task: %w", err)
@@ -182,3 +217,61 @@ var taskCreateCmd = &cobra.Command{
 		return nil
 	},
 }
+
+var taskBudgetCmd = &cobra.Command{
+	Use:   "budget <id> <amount>",
+	Short: "Set a task's budget",
+	Long: `Set the spend cap for a task in USD; 0 removes it.
+
+The daemon warns the worker at 80% of the budget. At 100% it commits the
+worker's changes to its branch, stops it, and returns the task to open with a
+checkpoint. The task is not reassigned until its budget is raised above what
+it has already spent.`,
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		amount, err := strconv.ParseFloat(args[1], 64)
+		if err != nil || amount < 0 {
+			return fmt.Errorf("invalid amount %q: must be a number >= 0", args[1])
+		}
+
+		root, err := projectRoot()
+		if err != nil {
+			return fmt.Errorf("not an altera project: %w", err)
+		}
+
+		store, err := task.NewStore(root)
+		if err != nil {
+			return fmt.Errorf("opening task store: %w", err)
+		}
+
+		if err := store.Update(args[0], func(t *task.Task) error {
+			t.Budget = amount
+			return nil
+		}); err != nil {
+			return fmt.Errorf("updating task: %w", err)
+		}
+
+		spent, err := taskSpend(root, args[0])
+		if err != nil {
+			return err
+		}
+		out := cmd.OutOrStdout()
+		if amount == 0 {
+			_, _ = fmt.Fprintf(out, "Removed budget for task %s ($%.2f spent)\n", args[0], spent)
+			return nil
+		}
+		_, _ = fmt.Fprintf(out, "Set budget for task %s to $%.2f ($%.2f spent)\n", args[0], amount, spent)
+		return nil
+	},
+}
+
+// taskSpend sums the token cost recorded against a task.
+func taskSpend(root, taskID string) (float64, error) {
+	altDir := filepath.Join(root, ".alt")
+	cfg, err := config.Load(altDir)
+	if err != nil {
+		return 0, err
+	}
+	checker := constraints.NewChecker(cfg.Constraints, nil, events.NewReader(filepath.Join(altDir, "events.jsonl")), "")
+	return checker.TaskSpend(taskID)
+}
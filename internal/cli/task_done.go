@@ -34,12 +34,8 @@ var taskDoneCmd = &cobra.Command{
 		taskID := args[0]
 		agentID := args[1]
 
-		var payload map[string]any
-		if taskDoneResult != "" {
-			payload = map[string]any{"result": taskDoneResult}
-		}
-
-		if _, err := store.Create(message.TypeTaskDone, agentID, "daemon", taskID, payload); err != nil {
+		payload := message.TaskDonePayload{Result: taskDoneResult}
+		if _, err := store.Send(agentID, "daemon", taskID, payload); err != nil {
 			return fmt.Errorf("creating task_done message: %w", err)
 		}
 
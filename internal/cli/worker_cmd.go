@@ -285,7 +285,7 @@ The agent ID can be provided as an argument or via the ALT_AGENT_ID environment
 			return fmt.Errorf("not an altera project: %w", err)
 		}
 
-		store, err := message.NewStore(filepath.Join(altDir, "messages"))
+		store, err := openMessageStore(altDir)
 		if err != nil {
 			return fmt.Errorf("opening message store: %w", err)
 		}
@@ -318,15 +318,16 @@ The agent ID can be provided as an argument or via the ALT_AGENT_ID environment
 
 			// For merge_result with success, append exit instruction.
 			if m.Type == message.TypeMergeResult {
-				if success, ok := m.Payload["success"].(bool); ok && success {
+				var result message.MergeResultPayload
+				if err := m.DecodePayload(&result); err == nil && result.Success {
 					b.WriteString("\n**Your changes have been merged to main. Please exit.**\n")
 				}
 			}
 
 			b.WriteString("\n")
 
-			// Archive after display.
-			if err := store.Archive(m.ID); err != nil {
+			// Acknowledge after display so the message does not repeat.
+			if err := store.Ack(m.ID, agentID); err != nil {
 				fmt.Fprintf(os.Stderr, "warning: failed to archive message %s: %v\n", m.ID, err)
 			}
 		}
@@ -1,15 +1,24 @@
 // Package config handles loading and saving Altera configuration from the
 // .alt/ directory. It provides path resolution (walking up from cwd to find
-// .alt/), CRUD operations on the root config, and atomic file writes to
-// prevent corruption.
+// .alt/), layered resolution of the root config (defaults, global file,
+// project file, environment, flags), key-based get/set, and atomic file
+// writes to prevent corruption.
 package config
 
 import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"maps"
+	"net"
+	"net/url"
 	"os"
 	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"text/template"
+	"time"
 )
 
 // DirName is the name of the Altera project directory.
@@ -17,9 +26,20 @@ const DirName = ".alt"
 
 // Constraints holds resource limits for the orchestration system.
 type Constraints struct {
-	BudgetCeiling float64 `json:"budget_ceiling"`
+	BudgetCeiling float64 `json:"budget_ceiling"` // lifetime spend cap; 0 means none
 	MaxWorkers    int     `json:"max_workers"`
 	MaxQueueDepth int     `json:"max_queue_depth"`
+	AgentBudget   float64 `json:"agent_budget,omitempty"` // per-agent spend cap; 0 means none
+
+	// Rolling spend limits over the last hour, day and week; 0 means none.
+	HourlyBudget float64 `json:"hourly_budget,omitempty"`
+	DailyBudget  float64 `json:"daily_budget,omitempty"`
+	WeeklyBudget float64 `json:"weekly_budget,omitempty"`
+
+	// Host limits checked before spawning a worker; 0 means none.
+	MaxLoadAvg      float64 `json:"max_load_avg,omitempty"`       // 1-minute load average
+	MinFreeMemoryMB int64   `json:"min_free_memory_mb,omitempty"` // MemAvailable in /proc/meminfo
+	MinFreeDiskMB   int64   `json:"min_free_disk_mb,omitempty"`   // on the .alt/worktrees filesystem
 }
 
 // Validate checks that constraint values are within acceptable ranges.
@@ -33,26 +53,423 @@ func (c Constraints) Validate() error {
 	if c.MaxQueueDepth < 1 {
 		return fmt.Errorf("max_queue_depth must be >= 1, got %d", c.MaxQueueDepth)
 	}
+	if c.AgentBudget < 0 {
+		return fmt.Errorf("agent_budget must be >= 0, got %v", c.AgentBudget)
+	}
+	if c.HourlyBudget < 0 {
+		return fmt.Errorf("hourly_budget must be >= 0, got %v", c.HourlyBudget)
+	}
+	if c.DailyBudget < 0 {
+		return fmt.Errorf("daily_budget must be >= 0, got %v", c.DailyBudget)
+	}
+	if c.WeeklyBudget < 0 {
+		return fmt.Errorf("weekly_budget must be >= 0, got %v", c.WeeklyBudget)
+	}
+	if c.MaxLoadAvg < 0 {
+		return fmt.Errorf("max_load_avg must be >= 0, got %v", c.MaxLoadAvg)
+	}
+	if c.MinFreeMemoryMB < 0 {
+		return fmt.Errorf("min_free_memory_mb must be >= 0, got %d", c.MinFreeMemoryMB)
+	}
+	if c.MinFreeDiskMB < 0 {
+		return fmt.Errorf("min_free_disk_mb must be >= 0, got %d", c.MinFreeDiskMB)
+	}
+	return nil
+}
+
+// Duration is a time.Duration stored in JSON as a Go duration string
+// such as "90s" or "24h".
+type Duration time.Duration
+
+// MarshalJSON encodes the duration as a string.
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+// UnmarshalJSON accepts a duration string ("30m").
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("duration must be a string like \"30m\": %w", err)
+	}
+	v, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = Duration(v)
+	return nil
+}
+
+// Daemon defaults, used by NewConfig.
+const (
+	DefaultTickInterval        = Duration(60 * time.Second)
+	DefaultStalledThreshold    = Duration(30 * time.Minute)
+	DefaultHeartbeatWarn       = Duration(3 * time.Minute)
+	DefaultHeartbeatCritical   = Duration(6 * time.Minute)
+	DefaultHeartbeatDead       = Duration(10 * time.Minute)
+	DefaultMaxResolverAttempts = 3
+	DefaultPIDSettleDelay      = Duration(500 * time.Millisecond)
+	DefaultMaxMessageAttempts  = 5
+)
+
+// Daemon holds the daemon's timing thresholds and retry limits. Keys a
+// config file leaves out keep their defaults.
+type Daemon struct {
+	TickInterval     Duration `json:"tick_interval,omitempty"`     // time between ticks
+	StalledThreshold Duration `json:"stalled_threshold,omitempty"` // no commits for this long means stalled
+
+	// A worker whose heartbeat is older than HeartbeatWarn is warned,
+	// older than HeartbeatCritical is escalated to the liaison, and older
+	// than HeartbeatDead is marked dead.
+	HeartbeatWarn     Duration `json:"heartbeat_warn,omitempty"`
+	HeartbeatCritical Duration `json:"heartbeat_critical,omitempty"`
+	HeartbeatDead     Duration `json:"heartbeat_dead,omitempty"`
+
+	MaxResolverAttempts int      `json:"max_resolver_attempts,omitempty"` // per merge before escalating to the liaison
+	PIDSettleDelay      Duration `json:"pid_settle_delay,omitempty"`      // wait after starting an agent before reading its pane PID
+	MaxMessageAttempts  int      `json:"max_message_attempts,omitempty"`  // before a failing message is dead-lettered
+}
+
+// Validate checks that intervals are positive, heartbeat thresholds
+// escalate in order, and retry limits allow at least one attempt.
+func (d Daemon) Validate() error {
+	if d.TickInterval <= 0 {
+		return fmt.Errorf("daemon.tick_interval must be > 0, got %s", time.Duration(d.TickInterval))
+	}
+	if d.StalledThreshold <= 0 {
+		return fmt.Errorf("daemon.stalled_threshold must be > 0, got %s", time.Duration(d.StalledThreshold))
+	}
+	if d.HeartbeatWarn <= 0 {
+		return fmt.Errorf("daemon.heartbeat_warn must be > 0, got %s", time.Duration(d.HeartbeatWarn))
+	}
+	if d.HeartbeatCritical <= d.HeartbeatWarn || d.HeartbeatDead <= d.HeartbeatCritical {
+		return fmt.Errorf("daemon heartbeat thresholds must increase: warn %s < critical %s < dead %s",
+			time.Duration(d.HeartbeatWarn), time.Duration(d.HeartbeatCritical), time.Duration(d.HeartbeatDead))
+	}
+	if d.MaxResolverAttempts < 0 {
+		return fmt.Errorf("daemon.max_resolver_attempts must be >= 0, got %d", d.MaxResolverAttempts)
+	}
+	if d.PIDSettleDelay < 0 {
+		return fmt.Errorf("daemon.pid_settle_delay must be >= 0, got %s", time.Duration(d.PIDSettleDelay))
+	}
+	if d.MaxMessageAttempts < 1 {
+		return fmt.Errorf("daemon.max_message_attempts must be >= 1, got %d", d.MaxMessageAttempts)
+	}
+	return nil
+}
+
+// Where worker branches start; see Branches.
+const (
+	BaseLocal  = "local"  // the local default branch, where the daemon merges
+	BaseRemote = "remote" // <remote>/<default_branch>, fetched first
+	BaseHead   = "head"   // whatever the project checkout has checked out
+)
+
+// DefaultRemote is the remote fetched for base "remote".
+const DefaultRemote = "origin"
+
+// Branches controls where worker branches start and whether in-flight
+// ones follow the default branch as it moves.
+type Branches struct {
+	Base   string `json:"base,omitempty"`   // "local" (default), "remote" or "head"
+	Remote string `json:"remote,omitempty"` // for base "remote"; default "origin"
+
+	// RebaseInterval is how often active workers' branches are rebased
+	// onto the base; 0 means never.
+	RebaseInterval Duration `json:"rebase_interval,omitempty"`
+}
+
+// RemoteName returns the remote to fetch, defaulting to DefaultRemote.
+func (b Branches) RemoteName() string {
+	if b.Remote == "" {
+		return DefaultRemote
+	}
+	return b.Remote
+}
+
+// Validate checks the base mode, remote name and interval.
+func (b Branches) Validate() error {
+	switch b.Base {
+	case "", BaseLocal, BaseRemote, BaseHead:
+	default:
+		return fmt.Errorf("branches.base must be %q, %q or %q, got %q", BaseLocal, BaseRemote, BaseHead, b.Base)
+	}
+	if strings.ContainsAny(b.Remote, " \t/") || strings.HasPrefix(b.Remote, "-") {
+		return fmt.Errorf("branches.remote: invalid remote name %q", b.Remote)
+	}
+	if b.RebaseInterval < 0 {
+		return fmt.Errorf("branches.rebase_interval must be >= 0, got %s", time.Duration(b.RebaseInterval))
+	}
+	return nil
+}
+
+// EventLog holds rotation settings for .alt/events.jsonl. With both limits
+// zero the log is never rotated.
+type EventLog struct {
+	MaxBytes int64    `json:"max_bytes,omitempty"`
+	MaxAge   Duration `json:"max_age,omitempty"`
+	Compress bool     `json:"compress,omitempty"`
+}
+
+// Validate checks that rotation limits are non-negative.
+func (e EventLog) Validate() error {
+	if e.MaxBytes < 0 {
+		return fmt.Errorf("events.max_bytes must be >= 0, got %d", e.MaxBytes)
+	}
+	if e.MaxAge < 0 {
+		return fmt.Errorf("events.max_age must be >= 0, got %s", time.Duration(e.MaxAge))
+	}
+	return nil
+}
+
+// Notification sink kinds.
+const (
+	SinkWebhook    = "webhook"
+	SinkCommand    = "command"
+	SinkNotifySend = "notify-send"
+)
+
+// Webhook body formats.
+const (
+	WebhookJSON  = "json"
+	WebhookSlack = "slack"
+)
+
+// Sink configures a notification sink fed from the event log. Events are
+// delivered if they match every filter that is set.
+type Sink struct {
+	Name    string `json:"name"`
+	Kind    string `json:"kind"`
+	URL     string `json:"url,omitempty"`     // webhook target
+	Format  string `json:"format,omitempty"`  // webhook body: "json" (default) or "slack"
+	Command string `json:"command,omitempty"` // run with sh -c, event JSON on stdin
+
+	Types   []string          `json:"types,omitempty"` // event types; empty matches all
+	AgentID string            `json:"agent_id,omitempty"`
+	TaskID  string            `json:"task_id,omitempty"`
+	Data    map[string]string `json:"data,omitempty"`
+
+	MaxAttempts int      `json:"max_attempts,omitempty"` // default 3
+	Backoff     Duration `json:"backoff,omitempty"`      // first retry delay, doubled per retry; default 2s
+	Timeout     Duration `json:"timeout,omitempty"`      // per attempt; default 10s
+}
+
+// Validate checks that the sink has a usable name and the fields its kind
+// requires.
+func (s Sink) Validate() error {
+	if s.Name == "" {
+		return errors.New("notify: sink name is required")
+	}
+	for _, r := range s.Name {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '_') {
+			return fmt.Errorf("notify: sink name %q may only contain letters, digits, '-' and '_'", s.Name)
+		}
+	}
+	switch s.Kind {
+	case SinkWebhook:
+		if s.URL == "" {
+			return fmt.Errorf("notify: sink %q: url is required for webhook sinks", s.Name)
+		}
+		switch s.Format {
+		case "", WebhookJSON, WebhookSlack:
+		default:
+			return fmt.Errorf("notify: sink %q: format must be %q or %q, got %q", s.Name, WebhookJSON, WebhookSlack, s.Format)
+		}
+	case SinkCommand:
+		if s.Command == "" {
+			return fmt.Errorf("notify: sink %q: command is required for command sinks", s.Name)
+		}
+	case SinkNotifySend:
+	default:
+		return fmt.Errorf("notify: sink %q: kind must be %s, %s or %s, got %q",
+			s.Name, SinkWebhook, SinkCommand, SinkNotifySend, s.Kind)
+	}
+	if s.MaxAttempts < 0 {
+		return fmt.Errorf("notify: sink %q: max_attempts must be >= 0, got %d", s.Name, s.MaxAttempts)
+	}
+	if s.Backoff < 0 || s.Timeout < 0 {
+		return fmt.Errorf("notify: sink %q: backoff and timeout must be >= 0", s.Name)
+	}
+	return nil
+}
+
+// ValidateSinks validates each sink and checks that names are unique.
+func ValidateSinks(sinks []Sink) error {
+	seen := make(map[string]bool, len(sinks))
+	for _, s := range sinks {
+		if err := s.Validate(); err != nil {
+			return err
+		}
+		if seen[s.Name] {
+			return fmt.Errorf("notify: duplicate sink name %q", s.Name)
+		}
+		seen[s.Name] = true
+	}
+	return nil
+}
+
+// Tracing configures the daemon's OTLP/HTTP trace exporter. It is off
+// unless Endpoint is set.
+type Tracing struct {
+	Endpoint    string `json:"endpoint,omitempty"` // e.g. http://localhost:4318
+	ServiceName string `json:"service_name,omitempty"`
+}
+
+// Validate checks that the endpoint, if set, is an http(s) URL.
+func (t Tracing) Validate() error {
+	if t.Endpoint == "" {
+		return nil
+	}
+	u, err := url.Parse(t.Endpoint)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("tracing.endpoint must be an http(s) URL, got %q", t.Endpoint)
+	}
+	return nil
+}
+
+// Metrics configures the daemon's Prometheus endpoint. It is off unless
+// Listen is set.
+type Metrics struct {
+	Listen string `json:"listen,omitempty"` // e.g. 127.0.0.1:9464; /metrics is served there
+}
+
+// Validate checks that the listen address, if set, is a host:port pair.
+func (m Metrics) Validate() error {
+	if m.Listen == "" {
+		return nil
+	}
+	if _, port, err := net.SplitHostPort(m.Listen); err != nil || port == "" {
+		return fmt.Errorf("metrics.listen must be a host:port address, got %q", m.Listen)
+	}
+	return nil
+}
+
+// ModelPrice is the USD cost per million tokens of one model.
+type ModelPrice struct {
+	Input      float64 `json:"input"`
+	Output     float64 `json:"output"`
+	CacheWrite float64 `json:"cache_write,omitempty"`
+	CacheRead  float64 `json:"cache_read,omitempty"`
+}
+
+// ValidatePricing checks that every price is non-negative.
+func ValidatePricing(p map[string]ModelPrice) error {
+	for model, mp := range p {
+		if model == "" {
+			return errors.New("pricing: model name is required")
+		}
+		if mp.Input < 0 || mp.Output < 0 || mp.CacheWrite < 0 || mp.CacheRead < 0 {
+			return fmt.Errorf("pricing: %s: prices must be >= 0", model)
+		}
+	}
+	return nil
+}
+
+// AgentProfile configures how an agent process is started. Profiles named
+// after a role (worker, resolver, liaison) are that role's defaults; other
+// profiles are picked per task and fill unset fields from the role's.
+type AgentProfile struct {
+	// Command is the program to run and its leading arguments, as a
+	// text/template over the agent's ID, task, role and directory.
+	Command string            `json:"command,omitempty"`
+	Model   string            `json:"model,omitempty"`  // passed as --model
+	Args    []string          `json:"args,omitempty"`   // appended after the command and model
+	Env     map[string]string `json:"env,omitempty"`    // exported before starting
+	Prompt  string            `json:"prompt,omitempty"` // initial prompt template, passed as the last argument
+}
+
+// envName matches environment variable names that are safe to write
+// unquoted into the shell line that launches an agent.
+var envName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// ValidateAgents checks that profile names are set, that command and
+// prompt templates parse, and that env keys are shell variable names.
+// Profiles are checked in name order so the error reported is stable.
+func ValidateAgents(profiles map[string]AgentProfile) error {
+	for _, name := range slices.Sorted(maps.Keys(profiles)) {
+		p := profiles[name]
+		if name == "" {
+			return errors.New("agents: profile name is required")
+		}
+		for _, f := range []struct{ field, text string }{{"command", p.Command}, {"prompt", p.Prompt}} {
+			if _, err := template.New(name).Parse(f.text); err != nil {
+				return fmt.Errorf("agents: %s: invalid %s template: %w", name, f.field, err)
+			}
+		}
+		for _, k := range slices.Sorted(maps.Keys(p.Env)) {
+			if !envName.MatchString(k) {
+				return fmt.Errorf("agents: %s: invalid environment variable name %q", name, k)
+			}
+		}
+	}
+	return nil
+}
+
+// ValidatePrompts checks that snippet agent types and topics are usable
+// names: non-empty, not hidden, and "/" only between subtopics.
+func ValidatePrompts(prompts map[string]map[string]string) error {
+	for agentType, topics := range prompts {
+		if agentType == "" || strings.HasPrefix(agentType, ".") || strings.ContainsAny(agentType, `/\`) {
+			return fmt.Errorf("prompts: invalid agent type %q", agentType)
+		}
+		for topic := range topics {
+			for _, part := range strings.Split(topic, "/") {
+				if part == "" || strings.HasPrefix(part, ".") || strings.Contains(part, `\`) {
+					return fmt.Errorf("prompts: %s: invalid topic %q", agentType, topic)
+				}
+			}
+		}
+	}
 	return nil
 }
 
 // Config is the root configuration stored in .alt/config.json.
 type Config struct {
+	SchemaVersion int         `json:"schema_version"` // see SchemaVersion
 	RepoPath      string      `json:"repo_path"`
 	DefaultBranch string      `json:"default_branch"`
 	TestCommand   string      `json:"test_command"`
 	Constraints   Constraints `json:"constraints"`
+	Daemon        Daemon      `json:"daemon,omitzero"`
+	Branches      Branches    `json:"branches,omitzero"`
+	Events        EventLog    `json:"events,omitzero"`
+	Notify        []Sink      `json:"notify,omitempty"`
+	Tracing       Tracing     `json:"tracing,omitzero"`
+	Metrics       Metrics     `json:"metrics,omitzero"`
+
+	// Pricing maps model name prefixes to prices. Entries override the
+	// built-in table in the usage package; the longest matching prefix wins.
+	Pricing map[string]ModelPrice `json:"pricing,omitempty"`
+
+	// Agents maps profile names to how agents are started; see AgentProfile.
+	Agents map[string]AgentProfile `json:"agents,omitempty"`
+
+	// Prompts holds inline help snippets by agent type and topic, appended
+	// to that topic in `alt help` and `alt prime`. A topic with no built-in
+	// or .alt/prompts/ file becomes a new topic.
+	Prompts map[string]map[string]string `json:"prompts,omitempty"`
 }
 
 // NewConfig returns a Config with sensible defaults.
 func NewConfig() Config {
 	return Config{
+		SchemaVersion: SchemaVersion,
 		DefaultBranch: "main",
 		Constraints: Constraints{
 			BudgetCeiling: 100.0,
 			MaxWorkers:    4,
 			MaxQueueDepth: 10,
 		},
+		Daemon: Daemon{
+			TickInterval:        DefaultTickInterval,
+			StalledThreshold:    DefaultStalledThreshold,
+			HeartbeatWarn:       DefaultHeartbeatWarn,
+			HeartbeatCritical:   DefaultHeartbeatCritical,
+			HeartbeatDead:       DefaultHeartbeatDead,
+			MaxResolverAttempts: DefaultMaxResolverAttempts,
+			PIDSettleDelay:      DefaultPIDSettleDelay,
+			MaxMessageAttempts:  DefaultMaxMessageAttempts,
+		},
 	}
 }
 
@@ -124,21 +541,12 @@ func SetDebug(altDir string, enabled bool) error {
 	return err
 }
 
-// Load reads and parses the root config.json from the given .alt/ directory.
+// Load returns the effective config for the project in altDir: built-in
+// defaults overlaid with the global config, the project's config.json,
+// ALT_* environment variables and --set flags. See Resolve.
 func Load(altDir string) (Config, error) {
-	path := filepath.Join(altDir, "config.json")
-	data, err := os.ReadFile(path)
-	if err != nil {
-		if errors.Is(err, os.ErrNotExist) {
-			return NewConfig(), nil
-		}
-		return Config{}, fmt.Errorf("reading config: %w", err)
-	}
-	var cfg Config
-	if err := json.Unmarshal(data, &cfg); err != nil {
-		return Config{}, fmt.Errorf("parsing config: %w", err)
-	}
-	return cfg, nil
+	cfg, _, err := Resolve(altDir)
+	return cfg, err
 }
 
 // Save writes the root config.json to the given .alt/ directory using an
@@ -3,7 +3,9 @@ package config
 import (
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestNewConfig(t *testing.T) {
@@ -195,6 +197,32 @@ func TestConstraintsValidate_NegativeBudget(t *testing.T) {
 	}
 }
 
+func TestConstraintsValidate_NegativeAgentBudget(t *testing.T) {
+	c := Constraints{BudgetCeiling: 100, MaxWorkers: 4, MaxQueueDepth: 10, AgentBudget: -1}
+	if err := c.Validate(); err == nil {
+		t.Fatal("Validate: expected error for negative agent budget")
+	}
+}
+
+func TestConstraintsValidate_NegativeWindowBudget(t *testing.T) {
+	c := Constraints{BudgetCeiling: 100, MaxWorkers: 4, MaxQueueDepth: 10, DailyBudget: -5}
+	if err := c.Validate(); err == nil {
+		t.Fatal("Validate: expected error for negative daily budget")
+	}
+}
+
+func TestConstraintsValidate_NegativeHostLimits(t *testing.T) {
+	for _, c := range []Constraints{
+		{BudgetCeiling: 100, MaxWorkers: 4, MaxQueueDepth: 10, MaxLoadAvg: -1},
+		{BudgetCeiling: 100, MaxWorkers: 4, MaxQueueDepth: 10, MinFreeMemoryMB: -1},
+		{BudgetCeiling: 100, MaxWorkers: 4, MaxQueueDepth: 10, MinFreeDiskMB: -1},
+	} {
+		if err := c.Validate(); err == nil {
+			t.Errorf("Validate(%+v): expected error", c)
+		}
+	}
+}
+
 func TestConstraintsValidate_ZeroWorkers(t *testing.T) {
 	c := Constraints{BudgetCeiling: 100, MaxWorkers: 0, MaxQueueDepth: 10}
 	if err := c.Validate(); err == nil {
@@ -211,6 +239,7 @@ func TestConstraintsValidate_ZeroQueueDepth(t *testing.T) {
 
 func TestLoadMinimalJSON(t *testing.T) {
 	// Verify that a minimal config can be loaded without error.
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
 	tmp := t.TempDir()
 	altDir, _ := EnsureDir(tmp)
 	path := filepath.Join(altDir, "config.json")
@@ -221,7 +250,212 @@ func TestLoadMinimalJSON(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
-	if cfg.DefaultBranch != "" {
-		t.Fatalf("expected empty default_branch from minimal JSON, got %q", cfg.DefaultBranch)
+	// Keys the file leaves out fall through to the defaults.
+	if cfg.DefaultBranch != "main" {
+		t.Fatalf("expected default_branch %q from defaults, got %q", "main", cfg.DefaultBranch)
+	}
+}
+
+func TestEventLogRoundtrip(t *testing.T) {
+	dir := t.TempDir()
+	cfg := NewConfig()
+	cfg.Events = EventLog{MaxBytes: 1 << 20, MaxAge: Duration(24 * time.Hour), Compress: true}
+	if err := Save(dir, cfg); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	data, _ := os.ReadFile(filepath.Join(dir, "config.json"))
+	if !strings.Contains(string(data), `"max_age": "24h0m0s"`) {
+		t.Errorf("max_age not stored as a duration string:\n%s", data)
+	}
+
+	got, err := Load(dir)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if got.Events != cfg.Events {
+		t.Errorf("Events = %+v, want %+v", got.Events, cfg.Events)
+	}
+}
+
+func TestEventLogOmittedWhenUnset(t *testing.T) {
+	dir := t.TempDir()
+	if err := Save(dir, NewConfig()); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	data, _ := os.ReadFile(filepath.Join(dir, "config.json"))
+	if strings.Contains(string(data), `"events"`) {
+		t.Errorf("unset event log settings written to config:\n%s", data)
+	}
+}
+
+func TestDurationRejectsNumbers(t *testing.T) {
+	var d Duration
+	if err := d.UnmarshalJSON([]byte("30")); err == nil {
+		t.Error("expected error for numeric duration")
+	}
+	if err := d.UnmarshalJSON([]byte(`"90s"`)); err != nil || time.Duration(d) != 90*time.Second {
+		t.Errorf("UnmarshalJSON(90s) = %v, %v", time.Duration(d), err)
+	}
+}
+
+func TestEventLogValidate(t *testing.T) {
+	if err := (EventLog{}).Validate(); err != nil {
+		t.Errorf("zero EventLog invalid: %v", err)
+	}
+	if err := (EventLog{MaxBytes: -1}).Validate(); err == nil {
+		t.Error("expected error for negative max_bytes")
+	}
+}
+
+func TestSinkValidate(t *testing.T) {
+	valid := []Sink{
+		{Name: "ops", Kind: SinkWebhook, URL: "https://example.com/hook"},
+		{Name: "slack_1", Kind: SinkWebhook, URL: "https://hooks.slack.com/x", Format: WebhookSlack},
+		{Name: "log", Kind: SinkCommand, Command: "cat >> /tmp/alt-events"},
+		{Name: "desk", Kind: SinkNotifySend, Types: []string{"agent_died"}},
+	}
+	for _, s := range valid {
+		if err := s.Validate(); err != nil {
+			t.Errorf("Validate(%+v): %v", s, err)
+		}
+	}
+
+	invalid := []Sink{
+		{Kind: SinkNotifySend},
+		{Name: "a/b", Kind: SinkNotifySend},
+		{Name: "x", Kind: "email"},
+		{Name: "x", Kind: SinkWebhook},
+		{Name: "x", Kind: SinkWebhook, URL: "http://h", Format: "xml"},
+		{Name: "x", Kind: SinkCommand},
+		{Name: "x", Kind: SinkNotifySend, MaxAttempts: -1},
+	}
+	for _, s := range invalid {
+		if err := s.Validate(); err == nil {
+			t.Errorf("Validate(%+v): expected error", s)
+		}
+	}
+}
+
+func TestValidateSinksDuplicateName(t *testing.T) {
+	sinks := []Sink{
+		{Name: "a", Kind: SinkNotifySend},
+		{Name: "a", Kind: SinkCommand, Command: "true"},
+	}
+	if err := ValidateSinks(sinks); err == nil {
+		t.Error("expected error for duplicate sink names")
+	}
+}
+
+func TestNotifyRoundtrip(t *testing.T) {
+	dir := t.TempDir()
+	cfg := NewConfig()
+	cfg.Notify = []Sink{{
+		Name: "ops", Kind: SinkWebhook, URL: "https://example.com/hook",
+		Types: []string{"merge_conflict"}, Backoff: Duration(5 * time.Second),
+	}}
+	if err := Save(dir, cfg); err != nil {
+		t.Fatal(err)
+	}
+	got, err := Load(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got.Notify) != 1 || got.Notify[0].URL != cfg.Notify[0].URL ||
+		time.Duration(got.Notify[0].Backoff) != 5*time.Second {
+		t.Errorf("Notify = %+v, want %+v", got.Notify, cfg.Notify)
+	}
+}
+
+func TestTracingValidate(t *testing.T) {
+	for _, ok := range []Tracing{{}, {Endpoint: "http://localhost:4318"}, {Endpoint: "https://otel.local/v1/traces"}} {
+		if err := ok.Validate(); err != nil {
+			t.Errorf("Validate(%+v): %v", ok, err)
+		}
+	}
+	for _, bad := range []Tracing{{Endpoint: "localhost:4318"}, {Endpoint: "ftp://x"}} {
+		if err := bad.Validate(); err == nil {
+			t.Errorf("Validate(%+v): expected error", bad)
+		}
+	}
+}
+
+func TestMetricsValidate(t *testing.T) {
+	for _, ok := range []Metrics{{}, {Listen: "127.0.0.1:9464"}, {Listen: ":9464"}} {
+		if err := ok.Validate(); err != nil {
+			t.Errorf("Validate(%+v): %v", ok, err)
+		}
+	}
+	for _, bad := range []Metrics{{Listen: "9464"}, {Listen: "localhost:"}} {
+		if err := bad.Validate(); err == nil {
+			t.Errorf("Validate(%+v): expected error", bad)
+		}
+	}
+}
+
+func TestValidatePricing(t *testing.T) {
+	if err := ValidatePricing(map[string]ModelPrice{"claude-sonnet-4": {Input: 3, Output: 15}}); err != nil {
+		t.Errorf("valid pricing: %v", err)
+	}
+	if err := ValidatePricing(map[string]ModelPrice{"m": {Input: -1}}); err == nil {
+		t.Error("expected error for negative price")
+	}
+	if err := ValidatePricing(map[string]ModelPrice{"": {}}); err == nil {
+		t.Error("expected error for empty model")
+	}
+}
+
+func TestDaemonValidate(t *testing.T) {
+	if err := NewConfig().Daemon.Validate(); err != nil {
+		t.Fatalf("default daemon config invalid: %v", err)
+	}
+	for name, mutate := range map[string]func(*Daemon){
+		"zero tick":          func(d *Daemon) { d.TickInterval = 0 },
+		"zero stall":         func(d *Daemon) { d.StalledThreshold = 0 },
+		"critical <= warn":   func(d *Daemon) { d.HeartbeatCritical = d.HeartbeatWarn },
+		"dead <= critical":   func(d *Daemon) { d.HeartbeatDead = d.HeartbeatCritical },
+		"negative resolvers": func(d *Daemon) { d.MaxResolverAttempts = -1 },
+		"negative pid delay": func(d *Daemon) { d.PIDSettleDelay = -1 },
+		"zero msg attempts":  func(d *Daemon) { d.MaxMessageAttempts = 0 },
+	} {
+		d := NewConfig().Daemon
+		mutate(&d)
+		if err := d.Validate(); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
+
+func TestBranchesValidate(t *testing.T) {
+	for _, b := range []Branches{{}, {Base: BaseRemote, Remote: "upstream"}, {Base: BaseHead, RebaseInterval: Duration(time.Hour)}} {
+		if err := b.Validate(); err != nil {
+			t.Errorf("%+v: %v", b, err)
+		}
+	}
+	for _, b := range []Branches{{Base: "origin"}, {Remote: "a b"}, {Remote: "-x"}, {RebaseInterval: -1}} {
+		if err := b.Validate(); err == nil {
+			t.Errorf("%+v: expected error", b)
+		}
+	}
+	if got := (Branches{}).RemoteName(); got != DefaultRemote {
+		t.Errorf("RemoteName() = %q, want %q", got, DefaultRemote)
+	}
+}
+
+func TestDaemonDefaultsFillOldConfigs(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	altDir := t.TempDir()
+	// A config written before the daemon section existed.
+	if err := os.WriteFile(filepath.Join(altDir, "config.json"), []byte(`{"repo_path": "/x", "constraints": {"budget_ceiling": 10, "max_workers": 2, "max_queue_depth": 3}}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := Load(altDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Daemon != NewConfig().Daemon {
+		t.Errorf("daemon = %+v, want defaults", cfg.Daemon)
+	}
+	if v, _ := Get(cfg, "daemon.stalled_threshold"); v != "30m0s" {
+		t.Errorf("daemon.stalled_threshold = %q", v)
 	}
 }
@@ -0,0 +1,190 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// keyInfo describes one config key: the dotted JSON path of a field in
+// Config, such as "constraints.max_workers". Nested structs contribute
+// their fields; maps and slices are single keys holding JSON.
+type keyInfo struct {
+	name  string
+	index []int
+	typ   reflect.Type
+}
+
+var durationType = reflect.TypeFor[Duration]()
+
+// keyAliases maps the short names `alt config` accepted before keys were
+// namespaced to their full names.
+var keyAliases = map[string]string{
+	"budget_ceiling":  "constraints.budget_ceiling",
+	"max_workers":     "constraints.max_workers",
+	"max_queue_depth": "constraints.max_queue_depth",
+}
+
+var allKeys = collectKeys(reflect.TypeFor[Config](), "", nil)
+
+func collectKeys(t reflect.Type, prefix string, index []int) []keyInfo {
+	var out []keyInfo
+	for i := range t.NumField() {
+		f := t.Field(i)
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if !f.IsExported() || name == "" || name == "-" {
+			continue
+		}
+		idx := append(slices.Clone(index), i)
+		if f.Type.Kind() == reflect.Struct {
+			out = append(out, collectKeys(f.Type, prefix+name+".", idx)...)
+			continue
+		}
+		out = append(out, keyInfo{name: prefix + name, index: idx, typ: f.Type})
+	}
+	return out
+}
+
+// Keys returns the name of every config key in declaration order.
+func Keys() []string {
+	names := make([]string, len(allKeys))
+	for i, k := range allKeys {
+		names[i] = k.name
+	}
+	return names
+}
+
+// CanonicalKey resolves aliases and reports whether key exists.
+func CanonicalKey(key string) (string, error) {
+	k, err := lookupKey(key)
+	return k.name, err
+}
+
+func lookupKey(name string) (keyInfo, error) {
+	if full, ok := keyAliases[name]; ok {
+		name = full
+	}
+	for _, k := range allKeys {
+		if k.name == name {
+			return k, nil
+		}
+	}
+	return keyInfo{}, fmt.Errorf("unknown config key %q (run 'alt config list' for valid keys)", name)
+}
+
+// Get returns the value of key in cfg as `alt config` prints it. Maps and
+// slices are printed as JSON; unset ones as an empty string.
+func Get(cfg Config, key string) (string, error) {
+	k, err := lookupKey(key)
+	if err != nil {
+		return "", err
+	}
+	return formatValue(reflect.ValueOf(cfg).FieldByIndex(k.index)), nil
+}
+
+// Set parses value for key and stores it in cfg. Durations take Go
+// duration strings ("30s"); maps and slices take JSON.
+func Set(cfg *Config, key, value string) error {
+	k, err := lookupKey(key)
+	if err != nil {
+		return err
+	}
+	v, err := parseValue(k, value)
+	if err != nil {
+		return err
+	}
+	reflect.ValueOf(cfg).Elem().FieldByIndex(k.index).Set(v)
+	return nil
+}
+
+func parseValue(k keyInfo, s string) (reflect.Value, error) {
+	v := reflect.New(k.typ).Elem()
+	if k.typ == durationType {
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return v, fmt.Errorf("invalid duration for %s: %w", k.name, err)
+		}
+		v.SetInt(int64(d))
+		return v, nil
+	}
+	switch k.typ.Kind() {
+	case reflect.String:
+		v.SetString(s)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return v, fmt.Errorf("invalid boolean for %s: %w", k.name, err)
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(s, 10, k.typ.Bits())
+		if err != nil {
+			return v, fmt.Errorf("invalid integer for %s: %w", k.name, err)
+		}
+		v.SetInt(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(s, k.typ.Bits())
+		if err != nil {
+			return v, fmt.Errorf("invalid float for %s: %w", k.name, err)
+		}
+		v.SetFloat(f)
+	default:
+		if err := json.Unmarshal([]byte(s), v.Addr().Interface()); err != nil {
+			return v, fmt.Errorf("invalid JSON for %s: %w", k.name, err)
+		}
+	}
+	return v, nil
+}
+
+func formatValue(v reflect.Value) string {
+	if v.Type() == durationType {
+		if v.Int() == 0 {
+			return ""
+		}
+		return time.Duration(v.Int()).String()
+	}
+	switch v.Kind() {
+	case reflect.String:
+		return v.String()
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10)
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'f', -1, v.Type().Bits())
+	}
+	if v.IsZero() || (v.Kind() == reflect.Map || v.Kind() == reflect.Slice) && v.Len() == 0 {
+		return ""
+	}
+	data, err := json.Marshal(v.Interface())
+	if err != nil {
+		return fmt.Sprintf("<%v>", err)
+	}
+	return string(data)
+}
+
+// Change is a config key whose value differs between two configs, with
+// both values formatted as Get returns them.
+type Change struct {
+	Key string
+	Old string
+	New string
+}
+
+// Diff returns the keys whose values differ from one config to the next,
+// in key order.
+func Diff(from, to Config) []Change {
+	ov, nv := reflect.ValueOf(from), reflect.ValueOf(to)
+	var changes []Change
+	for _, k := range allKeys {
+		o, n := formatValue(ov.FieldByIndex(k.index)), formatValue(nv.FieldByIndex(k.index))
+		if o != n {
+			changes = append(changes, Change{Key: k.name, Old: o, New: n})
+		}
+	}
+	return changes
+}
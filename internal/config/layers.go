@@ -0,0 +1,210 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Config layers, lowest precedence first. Each layer overrides the keys it
+// sets and leaves the rest alone.
+const (
+	LayerDefault = "default" // NewConfig
+	LayerGlobal  = "global"  // ~/.config/altera/config.json
+	LayerProject = "project" // .alt/config.json
+	LayerEnv     = "env"     // ALT_* environment variables
+	LayerFlag    = "flag"    // --set key=value
+)
+
+// EnvPrefix prefixes environment overrides. A key's variable is the prefix
+// followed by the key in upper case with dots as underscores:
+// constraints.max_workers is ALT_CONSTRAINTS_MAX_WORKERS.
+const EnvPrefix = "ALT_"
+
+// Origin records which layer set a config value.
+type Origin struct {
+	Layer  string // one of the Layer constants
+	Source string // file path, environment variable or flag; empty for defaults
+}
+
+// String formats the origin as "layer" or "layer:source".
+func (o Origin) String() string {
+	if o.Source == "" {
+		return o.Layer
+	}
+	return o.Layer + ":" + o.Source
+}
+
+// EnvName returns the environment variable that overrides key.
+func EnvName(key string) string {
+	return EnvPrefix + strings.ToUpper(strings.ReplaceAll(key, ".", "_"))
+}
+
+// GlobalPath returns the per-user config file shared by every project:
+// $XDG_CONFIG_HOME/altera/config.json, or ~/.config/altera/config.json.
+// It returns "" if neither directory can be determined.
+func GlobalPath() string {
+	dir := os.Getenv("XDG_CONFIG_HOME")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return ""
+		}
+		dir = filepath.Join(home, ".config")
+	}
+	return filepath.Join(dir, "altera", "config.json")
+}
+
+// flagOverrides holds the key=value pairs given with --set, in order.
+var flagOverrides [][2]string
+
+// SetFlagOverrides records key=value pairs from the command line. They
+// apply to every later Load in this process, above all other layers.
+func SetFlagOverrides(pairs []string) error {
+	overrides := make([][2]string, 0, len(pairs))
+	for _, p := range pairs {
+		key, value, ok := strings.Cut(p, "=")
+		if !ok {
+			return fmt.Errorf("invalid override %q: want key=value", p)
+		}
+		full, err := CanonicalKey(key)
+		if err != nil {
+			return err
+		}
+		overrides = append(overrides, [2]string{full, value})
+	}
+	flagOverrides = overrides
+	return nil
+}
+
+// Resolve builds the effective config for the project in altDir from the
+// built-in defaults, the global config file, the project's config.json,
+// ALT_* environment variables and --set flags, in that order. It also
+// returns the origin of every key. Missing files are skipped, and files
+// written in an older schema are migrated in memory.
+//
+// Objects merge key by key, so a project can override one field of the
+// global "constraints" or one model of its "pricing"; arrays such as
+// "notify" are replaced whole.
+func Resolve(altDir string) (Config, map[string]Origin, error) {
+	cfg := NewConfig()
+	origins := make(map[string]Origin, len(allKeys))
+	for _, k := range allKeys {
+		origins[k.name] = Origin{Layer: LayerDefault}
+	}
+
+	files := []struct{ layer, path string }{
+		{LayerGlobal, GlobalPath()},
+		{LayerProject, filepath.Join(altDir, "config.json")},
+	}
+	for _, f := range files {
+		if f.path == "" {
+			continue
+		}
+		data, err := os.ReadFile(f.path)
+		if err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				continue
+			}
+			return Config{}, nil, fmt.Errorf("reading config: %w", err)
+		}
+		data, raw, _, err := decodeFile(data)
+		if err != nil {
+			return Config{}, nil, fmt.Errorf("parsing config %s: %w", f.path, err)
+		}
+		if err := json.Unmarshal(data, &cfg); err != nil {
+			return Config{}, nil, fmt.Errorf("parsing config %s: %w", f.path, err)
+		}
+		for _, key := range presentKeys(raw, "") {
+			origins[key] = Origin{Layer: f.layer, Source: f.path}
+		}
+	}
+
+	for _, k := range allKeys {
+		name := EnvName(k.name)
+		value, ok := os.LookupEnv(name)
+		if !ok {
+			continue
+		}
+		if err := Set(&cfg, k.name, value); err != nil {
+			return Config{}, nil, fmt.Errorf("%s: %w", name, err)
+		}
+		origins[k.name] = Origin{Layer: LayerEnv, Source: name}
+	}
+
+	for _, kv := range flagOverrides {
+		if err := Set(&cfg, kv[0], kv[1]); err != nil {
+			return Config{}, nil, fmt.Errorf("--set %s: %w", kv[0], err)
+		}
+		origins[kv[0]] = Origin{Layer: LayerFlag, Source: "--set " + kv[0]}
+	}
+	return cfg, origins, nil
+}
+
+// presentKeys returns the config keys set in a decoded JSON object.
+func presentKeys(raw map[string]any, prefix string) []string {
+	var out []string
+	for name, v := range raw {
+		full := prefix + name
+		if _, err := lookupKey(full); err == nil && keyAliases[full] == "" {
+			out = append(out, full)
+			continue
+		}
+		if obj, ok := v.(map[string]any); ok {
+			out = append(out, presentKeys(obj, full+".")...)
+		}
+	}
+	return out
+}
+
+// SetProject writes one key to the project's config.json, leaving every
+// other key in the file as it was. Keys the file does not set keep coming
+// from lower layers.
+func SetProject(altDir, key, value string) error {
+	k, err := lookupKey(key)
+	if err != nil {
+		return err
+	}
+	v, err := parseValue(k, value)
+	if err != nil {
+		return err
+	}
+	encoded, err := json.Marshal(v.Interface())
+	if err != nil {
+		return fmt.Errorf("encoding %s: %w", k.name, err)
+	}
+
+	path := filepath.Join(altDir, "config.json")
+	raw := map[string]any{}
+	data, err := os.ReadFile(path)
+	switch {
+	case err == nil:
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.UseNumber() // keep integers exact when writing back
+		if err := dec.Decode(&raw); err != nil {
+			return fmt.Errorf("parsing config: %w", err)
+		}
+		if _, err := migrate(raw); err != nil {
+			return fmt.Errorf("parsing config: %w", err)
+		}
+	case !errors.Is(err, os.ErrNotExist):
+		return fmt.Errorf("reading config: %w", err)
+	}
+
+	obj := raw
+	parts := strings.Split(k.name, ".")
+	for _, p := range parts[:len(parts)-1] {
+		child, ok := obj[p].(map[string]any)
+		if !ok {
+			child = map[string]any{}
+			obj[p] = child
+		}
+		obj = child
+	}
+	obj[parts[len(parts)-1]] = json.RawMessage(encoded)
+	return atomicWriteJSON(path, raw)
+}
@@ -0,0 +1,174 @@
+package config
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestKeysGetSet(t *testing.T) {
+	keys := Keys()
+	for _, want := range []string{"repo_path", "constraints.max_workers", "events.max_age", "metrics.listen", "pricing"} {
+		found := false
+		for _, k := range keys {
+			found = found || k == want
+		}
+		if !found {
+			t.Errorf("Keys() missing %q", want)
+		}
+	}
+
+	cfg := NewConfig()
+	for key, value := range map[string]string{
+		"max_workers":     "7", // alias for constraints.max_workers
+		"events.max_age":  "36h",
+		"events.compress": "true",
+		"pricing":         `{"local":{"input":1,"output":2}}`,
+	} {
+		if err := Set(&cfg, key, value); err != nil {
+			t.Fatalf("Set(%s): %v", key, err)
+		}
+	}
+	if cfg.Constraints.MaxWorkers != 7 || time.Duration(cfg.Events.MaxAge) != 36*time.Hour ||
+		!cfg.Events.Compress || cfg.Pricing["local"].Output != 2 {
+		t.Errorf("cfg after Set = %+v", cfg)
+	}
+	if v, _ := Get(cfg, "constraints.max_workers"); v != "7" {
+		t.Errorf("Get max_workers = %q", v)
+	}
+	if v, _ := Get(cfg, "events.max_age"); v != "36h0m0s" {
+		t.Errorf("Get events.max_age = %q", v)
+	}
+
+	if err := Set(&cfg, "constraints.max_workers", "many"); err == nil {
+		t.Error("expected error for non-integer")
+	}
+	if _, err := Get(cfg, "no_such_key"); err == nil {
+		t.Error("expected error for unknown key")
+	}
+}
+
+func writeJSON(t *testing.T, path, data string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestResolveLayers(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", home)
+	global := filepath.Join(home, "altera", "config.json")
+	writeJSON(t, global, `{
+		"test_command": "make test",
+		"constraints": {"max_workers": 2, "max_queue_depth": 5},
+		"pricing": {"team-model": {"input": 1, "output": 1}}
+	}`)
+	altDir := filepath.Join(t.TempDir(), ".alt")
+	project := filepath.Join(altDir, "config.json")
+	writeJSON(t, project, `{
+		"repo_path": "/src/app",
+		"constraints": {"max_workers": 3},
+		"pricing": {"project-model": {"input": 2, "output": 2}}
+	}`)
+	t.Setenv("ALT_CONSTRAINTS_MAX_QUEUE_DEPTH", "9")
+	if err := SetFlagOverrides([]string{"default_branch=trunk"}); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = SetFlagOverrides(nil) })
+
+	cfg, origins, err := Resolve(altDir)
+	if err != nil {
+		t.Fatalf("Resolve: %v", err)
+	}
+	if cfg.TestCommand != "make test" || cfg.RepoPath != "/src/app" || cfg.DefaultBranch != "trunk" {
+		t.Errorf("strings = %q %q %q", cfg.TestCommand, cfg.RepoPath, cfg.DefaultBranch)
+	}
+	if cfg.Constraints.MaxWorkers != 3 || cfg.Constraints.MaxQueueDepth != 9 || cfg.Constraints.BudgetCeiling != 100 {
+		t.Errorf("constraints = %+v", cfg.Constraints)
+	}
+	if len(cfg.Pricing) != 2 {
+		t.Errorf("pricing should merge across files: %+v", cfg.Pricing)
+	}
+
+	want := map[string]Origin{
+		"test_command":                {LayerGlobal, global},
+		"repo_path":                   {LayerProject, project},
+		"constraints.max_workers":     {LayerProject, project},
+		"constraints.max_queue_depth": {LayerEnv, "ALT_CONSTRAINTS_MAX_QUEUE_DEPTH"},
+		"constraints.budget_ceiling":  {LayerDefault, ""},
+		"default_branch":              {LayerFlag, "--set default_branch"},
+	}
+	for key, o := range want {
+		if origins[key] != o {
+			t.Errorf("origin of %s = %v, want %v", key, origins[key], o)
+		}
+	}
+
+	t.Setenv("ALT_CONSTRAINTS_MAX_WORKERS", "lots")
+	if _, _, err := Resolve(altDir); err == nil || !strings.Contains(err.Error(), "ALT_CONSTRAINTS_MAX_WORKERS") {
+		t.Errorf("bad env value error = %v", err)
+	}
+}
+
+func TestSetProjectKeepsOtherKeys(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	altDir := t.TempDir()
+	path := filepath.Join(altDir, "config.json")
+	writeJSON(t, path, `{"repo_path": "/src/app", "events": {"max_bytes": 9007199254740993}}`)
+
+	if err := SetProject(altDir, "max_workers", "6"); err != nil {
+		t.Fatalf("SetProject: %v", err)
+	}
+	if err := SetProject(altDir, "events.max_age", "48h"); err != nil {
+		t.Fatalf("SetProject: %v", err)
+	}
+
+	data, _ := os.ReadFile(path)
+	var raw map[string]any
+	if err := json.Unmarshal(data, &raw); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := raw["default_branch"]; ok {
+		t.Error("SetProject wrote keys it was not asked to set")
+	}
+	if !strings.Contains(string(data), "9007199254740993") {
+		t.Errorf("large integer was not preserved: %s", data)
+	}
+	cfg, err := Load(altDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Constraints.MaxWorkers != 6 || time.Duration(cfg.Events.MaxAge) != 48*time.Hour || cfg.RepoPath != "/src/app" {
+		t.Errorf("cfg = %+v", cfg)
+	}
+	if err := SetProject(altDir, "bogus", "1"); err == nil {
+		t.Error("expected error for unknown key")
+	}
+}
+
+func TestDiff(t *testing.T) {
+	from := NewConfig()
+	to := from
+	if got := Diff(from, to); len(got) != 0 {
+		t.Fatalf("Diff of equal configs = %+v", got)
+	}
+	to.Constraints.MaxWorkers = from.Constraints.MaxWorkers + 1
+	to.TestCommand = "go test ./..."
+	got := Diff(from, to)
+	if len(got) != 2 || got[0].Key != "test_command" && got[1].Key != "test_command" {
+		t.Fatalf("Diff = %+v", got)
+	}
+	for _, c := range got {
+		if c.Key == "constraints.max_workers" && c.New != strconv.Itoa(to.Constraints.MaxWorkers) {
+			t.Errorf("max_workers change = %+v", c)
+		}
+	}
+}
@@ -0,0 +1,202 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/anthropics/altera/internal/git"
+)
+
+// SchemaVersion is the config.json layout this version of alt reads and
+// writes. Files without a schema_version are version 0.
+const SchemaVersion = 1
+
+// migrations[i] upgrades a decoded config file from version i to i+1.
+var migrations = []func(raw map[string]any){
+	migrateV0,
+}
+
+// migrateV0 moves the constraint keys `alt config` once accepted at the
+// top level into the constraints object. Values already in constraints win.
+func migrateV0(raw map[string]any) {
+	cons, _ := raw["constraints"].(map[string]any)
+	for short, full := range keyAliases {
+		v, ok := raw[short]
+		if !ok {
+			continue
+		}
+		delete(raw, short)
+		if cons == nil {
+			cons = map[string]any{}
+			raw["constraints"] = cons
+		}
+		name := strings.TrimPrefix(full, "constraints.")
+		if _, set := cons[name]; !set {
+			cons[name] = v
+		}
+	}
+}
+
+// migrate upgrades a decoded config file in place to SchemaVersion and
+// returns the version it started at. A file written by a newer alt is an
+// error, since its keys may mean something this version does not know.
+func migrate(raw map[string]any) (int, error) {
+	from := 0
+	switch v := raw["schema_version"].(type) {
+	case nil:
+	case json.Number:
+		n, err := v.Int64()
+		if err != nil || n < 0 {
+			return 0, fmt.Errorf("schema_version must be a non-negative integer, got %s", v)
+		}
+		from = int(n)
+	default:
+		return 0, fmt.Errorf("schema_version must be a non-negative integer, got %v", v)
+	}
+	if from > SchemaVersion {
+		return from, fmt.Errorf("schema_version %d is newer than this alt supports (%d); upgrade alt", from, SchemaVersion)
+	}
+	for _, m := range migrations[from:] {
+		m(raw)
+	}
+	raw["schema_version"] = json.Number(fmt.Sprint(SchemaVersion))
+	return from, nil
+}
+
+// decodeFile decodes a config file and migrates it to SchemaVersion,
+// returning the upgraded JSON and the version the file was written at.
+func decodeFile(data []byte) ([]byte, map[string]any, int, error) {
+	raw := map[string]any{}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber() // keep integers exact when re-encoding
+	if err := dec.Decode(&raw); err != nil {
+		return nil, nil, 0, err
+	}
+	from, err := migrate(raw)
+	if err != nil {
+		return nil, nil, from, err
+	}
+	upgraded, err := json.Marshal(raw)
+	if err != nil {
+		return nil, nil, from, err
+	}
+	return upgraded, raw, from, nil
+}
+
+// FileVersion returns the schema version of the project's config.json, or
+// SchemaVersion if the file does not exist.
+func FileVersion(altDir string) (int, error) {
+	data, err := os.ReadFile(filepath.Join(altDir, "config.json"))
+	if errors.Is(err, os.ErrNotExist) {
+		return SchemaVersion, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("reading config: %w", err)
+	}
+	_, _, from, err := decodeFile(data)
+	if err != nil {
+		return from, fmt.Errorf("parsing config: %w", err)
+	}
+	return from, nil
+}
+
+// Migrate rewrites the project's config.json in the current schema and
+// returns the version it was upgraded from. Only the keys the file sets
+// are written; a current or missing file is left alone.
+func Migrate(altDir string) (int, error) {
+	path := filepath.Join(altDir, "config.json")
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return SchemaVersion, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("reading config: %w", err)
+	}
+	_, raw, from, err := decodeFile(data)
+	if err != nil {
+		return from, fmt.Errorf("parsing config: %w", err)
+	}
+	if from == SchemaVersion {
+		return from, nil
+	}
+	return from, atomicWriteJSON(path, raw)
+}
+
+// Validate checks every section of the config and reports all problems
+// found, not just the first.
+func (c Config) Validate() error {
+	var errs []error
+	if c.SchemaVersion != SchemaVersion {
+		errs = append(errs, fmt.Errorf("schema_version must be %d, got %d", SchemaVersion, c.SchemaVersion))
+	}
+	if c.DefaultBranch == "" {
+		errs = append(errs, errors.New("default_branch is required"))
+	}
+	for _, err := range []error{
+		c.Constraints.Validate(),
+		c.Daemon.Validate(),
+		c.Branches.Validate(),
+		c.Events.Validate(),
+		ValidateSinks(c.Notify),
+		c.Tracing.Validate(),
+		c.Metrics.Validate(),
+		ValidatePricing(c.Pricing),
+		ValidateAgents(c.Agents),
+		ValidatePrompts(c.Prompts),
+	} {
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// ValidateProject runs Validate and then checks the config against the
+// project at projectRoot: repo_path (the project root when empty) must be
+// a git repository, default_branch must exist there, the remote worker
+// branches are fetched from must be configured, and test_command must be
+// valid shell syntax.
+func ValidateProject(cfg Config, projectRoot string) error {
+	errs := []error{cfg.Validate()}
+
+	repo := cfg.RepoPath
+	if repo == "" {
+		repo = projectRoot
+	} else if !filepath.IsAbs(repo) {
+		repo = filepath.Join(projectRoot, repo)
+	}
+	switch {
+	case !git.IsRepo(repo):
+		errs = append(errs, fmt.Errorf("repo_path: %s is not a git repository", repo))
+	case cfg.DefaultBranch != "" && !branchExists(repo, cfg.DefaultBranch):
+		errs = append(errs, fmt.Errorf("default_branch: no branch %q in %s", cfg.DefaultBranch, repo))
+	case cfg.Branches.Base == BaseRemote && !git.HasRemote(repo, cfg.Branches.RemoteName()):
+		errs = append(errs, fmt.Errorf("branches.remote: no remote %q in %s", cfg.Branches.RemoteName(), repo))
+	}
+
+	if cfg.TestCommand != "" {
+		var stderr bytes.Buffer
+		cmd := exec.Command("sh", "-n", "-c", cfg.TestCommand)
+		cmd.Stderr = &stderr
+		if err := cmd.Run(); err != nil {
+			errs = append(errs, fmt.Errorf("test_command does not parse: %s", strings.TrimSpace(stderr.String())))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// branchExists reports whether name is a local branch, a branch on origin,
+// or the branch HEAD points at in a repository with no commits yet.
+func branchExists(repo, name string) bool {
+	if git.RefExists(repo, "refs/heads/"+name) || git.RefExists(repo, "refs/remotes/origin/"+name) {
+		return true
+	}
+	head, err := git.HeadBranch(repo)
+	return err == nil && head == name && !git.RefExists(repo, "HEAD")
+}
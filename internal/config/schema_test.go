@@ -0,0 +1,154 @@
+package config
+
+import (
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestMigrateV0(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	altDir := t.TempDir()
+	writeJSON(t, filepath.Join(altDir, "config.json"),
+		`{"repo_path": "/repo", "max_workers": 7, "budget_ceiling": 12.5, "constraints": {"max_queue_depth": 3}}`)
+
+	if v, err := FileVersion(altDir); err != nil || v != 0 {
+		t.Fatalf("FileVersion = %d, %v; want 0", v, err)
+	}
+	cfg, err := Load(altDir)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if cfg.SchemaVersion != SchemaVersion || cfg.Constraints.MaxWorkers != 7 ||
+		cfg.Constraints.BudgetCeiling != 12.5 || cfg.Constraints.MaxQueueDepth != 3 {
+		t.Errorf("migrated cfg = %+v", cfg)
+	}
+
+	from, err := Migrate(altDir)
+	if err != nil || from != 0 {
+		t.Fatalf("Migrate = %d, %v", from, err)
+	}
+	data, _ := os.ReadFile(filepath.Join(altDir, "config.json"))
+	var raw map[string]any
+	if err := json.Unmarshal(data, &raw); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := raw["max_workers"]; ok || raw["schema_version"] != float64(SchemaVersion) || raw["repo_path"] != "/repo" {
+		t.Errorf("migrated file = %s", data)
+	}
+	if from, _ := Migrate(altDir); from != SchemaVersion {
+		t.Errorf("second Migrate from = %d", from)
+	}
+}
+
+func TestLoadRejectsNewerSchema(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	altDir := t.TempDir()
+	writeJSON(t, filepath.Join(altDir, "config.json"), `{"schema_version": 99}`)
+	if _, err := Load(altDir); err == nil || !strings.Contains(err.Error(), "newer") {
+		t.Errorf("Load err = %v, want newer-schema error", err)
+	}
+}
+
+func TestValidateReportsAllProblems(t *testing.T) {
+	cfg := NewConfig()
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("default config invalid: %v", err)
+	}
+	cfg.Constraints.MaxWorkers = 0
+	cfg.Metrics.Listen = "nope"
+	cfg.DefaultBranch = ""
+	err := cfg.Validate()
+	if err == nil {
+		t.Fatal("expected errors")
+	}
+	for _, want := range []string{"max_workers", "metrics.listen", "default_branch"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("Validate error missing %q: %v", want, err)
+		}
+	}
+}
+
+func TestValidateProject(t *testing.T) {
+	root := t.TempDir()
+	cfg := NewConfig()
+	if err := ValidateProject(cfg, root); err == nil || !strings.Contains(err.Error(), "not a git repository") {
+		t.Errorf("non-repo err = %v", err)
+	}
+
+	if out, err := exec.Command("git", "init", "-q", "-b", "trunk", root).CombinedOutput(); err != nil {
+		t.Fatalf("git init: %v: %s", err, out)
+	}
+	if err := ValidateProject(cfg, root); err == nil || !strings.Contains(err.Error(), `no branch "main"`) {
+		t.Errorf("missing branch err = %v", err)
+	}
+
+	// An unborn HEAD counts as the branch it names.
+	cfg.DefaultBranch = "trunk"
+	cfg.TestCommand = "go test ./... && echo ok"
+	if err := ValidateProject(cfg, root); err != nil {
+		t.Errorf("valid project: %v", err)
+	}
+
+	cfg.TestCommand = "go test ./... &&"
+	if err := ValidateProject(cfg, root); err == nil || !strings.Contains(err.Error(), "test_command") {
+		t.Errorf("bad test command err = %v", err)
+	}
+
+	cfg.TestCommand = ""
+	cfg.Branches.Base = BaseRemote
+	if err := ValidateProject(cfg, root); err == nil || !strings.Contains(err.Error(), `no remote "origin"`) {
+		t.Errorf("missing remote err = %v", err)
+	}
+}
+
+func TestValidateAgents(t *testing.T) {
+	ok := map[string]AgentProfile{
+		"worker": {Command: "claude {{.AgentID}}", Env: map[string]string{"X": "1"}},
+		"cheap":  {Model: "haiku"},
+	}
+	if err := ValidateAgents(ok); err != nil {
+		t.Errorf("valid profiles: %v", err)
+	}
+	for name, p := range map[string]AgentProfile{
+		"bad-template": {Prompt: "{{.TaskID"},
+		"bad-env":      {Env: map[string]string{"A=B": "1"}},
+		"shell-env":    {Env: map[string]string{"X;touch pwned;Y": "1"}},
+		"subst-env":    {Env: map[string]string{"$(id)": "1"}},
+		"digit-env":    {Env: map[string]string{"1X": "1"}},
+	} {
+		if err := ValidateAgents(map[string]AgentProfile{name: p}); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
+
+func TestValidateAgentsDeterministic(t *testing.T) {
+	profiles := map[string]AgentProfile{}
+	for _, name := range []string{"e", "d", "c", "b", "a"} {
+		profiles[name] = AgentProfile{Env: map[string]string{"BAD-" + name: "1"}}
+	}
+	for range 10 {
+		if err := ValidateAgents(profiles); err == nil || !strings.Contains(err.Error(), "agents: a:") {
+			t.Fatalf("err = %v, want the first profile by name", err)
+		}
+	}
+}
+
+func TestValidatePrompts(t *testing.T) {
+	if err := ValidatePrompts(map[string]map[string]string{"worker": {"startup": "x", "deploy/prod": "y"}}); err != nil {
+		t.Errorf("valid prompts: %v", err)
+	}
+	for _, bad := range []map[string]map[string]string{
+		{"": {"startup": "x"}},
+		{"worker": {"../secret": "x"}},
+		{"worker": {"a//b": "x"}},
+	} {
+		if err := ValidatePrompts(bad); err == nil {
+			t.Errorf("ValidatePrompts(%v) = nil", bad)
+		}
+	}
+}
@@ -1,6 +1,7 @@
 // Package constraints checks system resource constraints before spawning
 // new worker agents. It integrates with the events log (budget tracking),
-// agent store (worker count), and merge queue directory (queue depth).
+// agent store (worker count), merge queue directory (queue depth), and the
+// host's load, memory and disk.
 package constraints
 
 import (
@@ -8,6 +9,8 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
+	"time"
 
 	"github.com/anthropics/altera/internal/agent"
 	"github.com/anthropics/altera/internal/config"
@@ -20,6 +23,8 @@ type Checker struct {
 	agents        *agent.Store
 	eventsReader  *events.Reader
 	mergeQueueDir string // path to .alt/merge-queue/
+	worktreeDir   string // path to .alt/worktrees/, a sibling of the merge queue
+	procDir       string // /proc, replaced in tests
 }
 
 // NewChecker creates a Checker with the given dependencies.
@@ -29,9 +34,18 @@ func NewChecker(cfg config.Constraints, agents *agent.Store, evReader *events.Re
 		agents:        agents,
 		eventsReader:  evReader,
 		mergeQueueDir: mergeQueueDir,
+		worktreeDir:   worktreesDir(mergeQueueDir),
+		procDir:       "/proc",
 	}
 }
 
+func worktreesDir(mergeQueueDir string) string {
+	if mergeQueueDir == "" {
+		return ""
+	}
+	return filepath.Join(filepath.Dir(mergeQueueDir), "worktrees")
+}
+
 // UpdateConstraints replaces the constraint configuration. This allows
 // the daemon to pick up config changes without restarting.
 func (c *Checker) UpdateConstraints(cfg config.Constraints) {
@@ -39,9 +53,31 @@ func (c *Checker) UpdateConstraints(cfg config.Constraints) {
 }
 
 // BudgetUsed sums the "token_cost" field from all events in the log.
-// Events without a token_cost data field are skipped.
+// Events without a token_cost data field are skipped. Rotated segments
+// contribute their persisted totals, so only the active file is scanned.
 func (c *Checker) BudgetUsed() (float64, error) {
-	all, err := c.eventsReader.ReadAll()
+	total, err := c.eventsReader.SumData("token_cost")
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return 0, nil
+		}
+		return 0, fmt.Errorf("constraints: read events: %w", err)
+	}
+	return total, nil
+}
+
+// TaskSpend sums token_cost recorded against one task.
+func (c *Checker) TaskSpend(taskID string) (float64, error) {
+	return c.sumCost(events.Filter{TaskID: taskID})
+}
+
+// AgentSpend sums token_cost recorded against one agent.
+func (c *Checker) AgentSpend(agentID string) (float64, error) {
+	return c.sumCost(events.Filter{AgentID: agentID})
+}
+
+func (c *Checker) sumCost(f events.Filter) (float64, error) {
+	evts, err := c.eventsReader.Read(f)
 	if err != nil {
 		if errors.Is(err, os.ErrNotExist) {
 			return 0, nil
@@ -49,11 +85,9 @@ func (c *Checker) BudgetUsed() (float64, error) {
 		return 0, fmt.Errorf("constraints: read events: %w", err)
 	}
 	var total float64
-	for _, ev := range all {
-		if cost, ok := ev.Data["token_cost"]; ok {
-			if v, ok := cost.(float64); ok {
-				total += v
-			}
+	for _, ev := range evts {
+		if v, ok := ev.Data["token_cost"].(float64); ok {
+			total += v
 		}
 	}
 	return total, nil
@@ -87,18 +121,120 @@ func (c *Checker) QueueDepth() (int, error) {
 	return n, nil
 }
 
-// CheckBudget returns true if budget usage is below the ceiling.
+// CheckBudget returns true if budget usage is below the ceiling and below
+// every configured rolling window limit. A ceiling of 0 is no lifetime
+// cap, leaving the windows to limit spend.
 func (c *Checker) CheckBudget() (bool, string, error) {
-	used, err := c.BudgetUsed()
+	if c.cfg.BudgetCeiling > 0 {
+		used, err := c.BudgetUsed()
+		if err != nil {
+			return false, "", err
+		}
+		if used >= c.cfg.BudgetCeiling {
+			return false, fmt.Sprintf("budget exhausted: %.2f/%.2f", used, c.cfg.BudgetCeiling), nil
+		}
+	}
+
+	windows, err := c.Windows(time.Now())
 	if err != nil {
 		return false, "", err
 	}
-	if used >= c.cfg.BudgetCeiling {
-		return false, fmt.Sprintf("budget exhausted: %.2f/%.2f", used, c.cfg.BudgetCeiling), nil
+	for _, w := range windows {
+		if w.Exhausted() {
+			return false, fmt.Sprintf("%s budget exhausted: %.2f/%.2f, resets at %s",
+				w.Name, w.Spent, w.Limit, w.ResetsAt.Local().Format(time.RFC3339)), nil
+		}
 	}
 	return true, "", nil
 }
 
+// WindowBudget is the state of one rolling budget window.
+type WindowBudget struct {
+	Name   string        `json:"name"` // "hourly", "daily" or "weekly"
+	Window time.Duration `json:"window"`
+	Limit  float64       `json:"limit"`
+	Spent  float64       `json:"spent"`
+
+	// ResetsAt is when spend in the window next falls: once exhausted, the
+	// time enough spend ages out to drop below the limit; otherwise the
+	// time the oldest spend in the window ages out. Zero when nothing was
+	// spent in the window.
+	ResetsAt time.Time `json:"resets_at,omitzero"`
+}
+
+// Remaining is the allowance left in the window, never negative.
+func (w WindowBudget) Remaining() float64 {
+	return max(w.Limit-w.Spent, 0)
+}
+
+// Exhausted reports whether spend in the window has reached the limit.
+func (w WindowBudget) Exhausted() bool {
+	return w.Spent >= w.Limit
+}
+
+// Windows returns the configured rolling budget windows, shortest first,
+// with the spend in each as of now.
+func (c *Checker) Windows(now time.Time) ([]WindowBudget, error) {
+	var windows []WindowBudget
+	for _, w := range []WindowBudget{
+		{Name: "hourly", Window: time.Hour, Limit: c.cfg.HourlyBudget},
+		{Name: "daily", Window: 24 * time.Hour, Limit: c.cfg.DailyBudget},
+		{Name: "weekly", Window: 7 * 24 * time.Hour, Limit: c.cfg.WeeklyBudget},
+	} {
+		if w.Limit > 0 {
+			windows = append(windows, w)
+		}
+	}
+	if len(windows) == 0 {
+		return nil, nil
+	}
+
+	// One read covers every window: the longest is last.
+	evts, err := c.eventsReader.Read(events.Filter{After: now.Add(-windows[len(windows)-1].Window)})
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("constraints: read events: %w", err)
+	}
+	type spend struct {
+		at   time.Time
+		cost float64
+	}
+	var costs []spend
+	for _, ev := range evts {
+		if v, ok := ev.Data["token_cost"].(float64); ok && v != 0 {
+			costs = append(costs, spend{ev.Timestamp, v})
+		}
+	}
+	sort.Slice(costs, func(i, j int) bool { return costs[i].at.Before(costs[j].at) })
+
+	for i := range windows {
+		w := &windows[i]
+		start := now.Add(-w.Window)
+		var in []spend
+		for _, s := range costs {
+			if s.at.After(start) {
+				in = append(in, s)
+				w.Spent += s.cost
+			}
+		}
+		if len(in) == 0 {
+			continue
+		}
+		w.ResetsAt = in[0].at.Add(w.Window)
+		if w.Exhausted() {
+			// Age out the oldest spend until the window is under its limit.
+			left := w.Spent
+			for _, s := range in {
+				left -= s.cost
+				w.ResetsAt = s.at.Add(w.Window)
+				if left < w.Limit {
+					break
+				}
+			}
+		}
+	}
+	return windows, nil
+}
+
 // CheckMaxWorkers returns true if the worker count is below the maximum.
 func (c *Checker) CheckMaxWorkers() (bool, string, error) {
 	count, err := c.WorkerCount()
@@ -144,5 +280,11 @@ func (c *Checker) CanSpawnWorker() (bool, string) {
 		return false, reason
 	}
 
+	if ok, reason, err := c.CheckHost(); err != nil {
+		return false, fmt.Sprintf("host check error: %v", err)
+	} else if !ok {
+		return false, reason
+	}
+
 	return true, ""
 }
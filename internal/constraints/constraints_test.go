@@ -3,6 +3,7 @@ package constraints
 import (
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
@@ -509,3 +510,120 @@ func TestCanSpawnWorkerBudgetCheckedFirst(t *testing.T) {
 		t.Error("CanSpawnWorker: expected reason string")
 	}
 }
+
+// --- TaskSpend / AgentSpend tests ---
+
+func TestTaskAndAgentSpend(t *testing.T) {
+	now := time.Now().UTC()
+	r := writeEvents(t,
+		events.Event{Timestamp: now, Type: events.TokenUsage, AgentID: "a1", TaskID: "t1", Data: map[string]any{"token_cost": 1.5}},
+		events.Event{Timestamp: now, Type: events.TokenUsage, AgentID: "a1", TaskID: "t2", Data: map[string]any{"token_cost": 2.0}},
+		events.Event{Timestamp: now, Type: events.TokenUsage, AgentID: "a2", TaskID: "t1", Data: map[string]any{"token_cost": 0.5}},
+	)
+	c := NewChecker(defaultCfg(), makeAgentStore(t), r, t.TempDir())
+
+	if got, err := c.TaskSpend("t1"); err != nil || got != 2.0 {
+		t.Errorf("TaskSpend(t1) = %v, %v; want 2", got, err)
+	}
+	if got, err := c.AgentSpend("a1"); err != nil || got != 3.5 {
+		t.Errorf("AgentSpend(a1) = %v, %v; want 3.5", got, err)
+	}
+	if got, err := c.TaskSpend("none"); err != nil || got != 0 {
+		t.Errorf("TaskSpend(none) = %v, %v; want 0", got, err)
+	}
+}
+
+// --- Rolling window tests ---
+
+func TestWindowsSpendAndReset(t *testing.T) {
+	now := time.Date(2026, 3, 2, 12, 0, 0, 0, time.UTC)
+	cost := func(ago time.Duration, v float64) events.Event {
+		return events.Event{Timestamp: now.Add(-ago), Type: events.TokenUsage, AgentID: "a1", Data: map[string]any{"token_cost": v}}
+	}
+	r := writeEvents(t,
+		cost(30*time.Hour, 50), // outside the day
+		cost(20*time.Hour, 4),
+		cost(10*time.Hour, 3),
+		cost(30*time.Minute, 2),
+	)
+	cfg := defaultCfg()
+	cfg.HourlyBudget = 5
+	cfg.DailyBudget = 8
+	c := NewChecker(cfg, makeAgentStore(t), r, t.TempDir())
+
+	windows, err := c.Windows(now)
+	if err != nil {
+		t.Fatalf("Windows: %v", err)
+	}
+	if len(windows) != 2 {
+		t.Fatalf("got %d windows, want 2", len(windows))
+	}
+	hourly, daily := windows[0], windows[1]
+	if hourly.Name != "hourly" || hourly.Spent != 2 || hourly.Remaining() != 3 || hourly.Exhausted() {
+		t.Errorf("hourly = %+v", hourly)
+	}
+	if !hourly.ResetsAt.Equal(now.Add(30 * time.Minute)) {
+		t.Errorf("hourly resets at %v", hourly.ResetsAt)
+	}
+	// 9 spent of 8: the window is under its limit once the oldest 4 ages out.
+	if daily.Spent != 9 || !daily.Exhausted() || daily.Remaining() != 0 {
+		t.Errorf("daily = %+v", daily)
+	}
+	if !daily.ResetsAt.Equal(now.Add(4 * time.Hour)) {
+		t.Errorf("daily resets at %v, want %v", daily.ResetsAt, now.Add(4*time.Hour))
+	}
+}
+
+func TestCheckBudgetWindowExhausted(t *testing.T) {
+	r := writeEvents(t, events.Event{
+		Timestamp: time.Now().UTC().Add(-time.Minute),
+		Type:      events.TokenUsage,
+		AgentID:   "a1",
+		Data:      map[string]any{"token_cost": 6.0},
+	})
+	cfg := defaultCfg()
+	cfg.HourlyBudget = 5
+	c := NewChecker(cfg, makeAgentStore(t), r, t.TempDir())
+
+	ok, reason, err := c.CheckBudget()
+	if err != nil {
+		t.Fatalf("CheckBudget: %v", err)
+	}
+	if ok || !strings.Contains(reason, "hourly budget exhausted") {
+		t.Errorf("CheckBudget = %v, %q", ok, reason)
+	}
+
+	cfg.HourlyBudget = 10
+	c.UpdateConstraints(cfg)
+	if ok, reason, _ := c.CheckBudget(); !ok {
+		t.Errorf("CheckBudget under window limit = false, %q", reason)
+	}
+}
+
+func TestCheckBudgetWindowsWithoutCeiling(t *testing.T) {
+	old := time.Now().UTC().Add(-30 * 24 * time.Hour)
+	r := writeEvents(t,
+		events.Event{Timestamp: old, Type: events.TokenUsage, AgentID: "a1", Data: map[string]any{"token_cost": 500.0}},
+		events.Event{Timestamp: time.Now().UTC().Add(-time.Minute), Type: events.TokenUsage, AgentID: "a1", Data: map[string]any{"token_cost": 2.0}},
+	)
+	for _, limits := range []struct{ hourly, daily, weekly float64 }{
+		{hourly: 5},
+		{daily: 50},
+		{weekly: 200},
+	} {
+		cfg := defaultCfg()
+		cfg.BudgetCeiling = 0
+		cfg.HourlyBudget, cfg.DailyBudget, cfg.WeeklyBudget = limits.hourly, limits.daily, limits.weekly
+		c := NewChecker(cfg, makeAgentStore(t), r, t.TempDir())
+
+		// 502 spent in all, far over the default ceiling of 100, but only 2
+		// within any window.
+		ok, reason, err := c.CheckBudget()
+		if err != nil {
+			t.Fatalf("CheckBudget: %v", err)
+		}
+		if !ok {
+			t.Errorf("CheckBudget with %+v and no ceiling = false, %q", limits, reason)
+		}
+	}
+}
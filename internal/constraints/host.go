@@ -0,0 +1,129 @@
+package constraints
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// HostStats is a snapshot of the host resources the spawn constraints look
+// at. A field is nil when it could not be read on this platform.
+type HostStats struct {
+	LoadAvg      *float64 `json:"load_avg,omitempty"`       // 1-minute load average
+	FreeMemoryMB *int64   `json:"free_memory_mb,omitempty"` // MemAvailable
+	FreeDiskMB   *int64   `json:"free_disk_mb,omitempty"`   // on the worktrees filesystem
+}
+
+// HostStats reads the load average and free memory from /proc and the free
+// space on the filesystem holding .alt/worktrees. Sources that do not exist
+// (no /proc outside Linux) are left nil rather than reported as errors.
+func (c *Checker) HostStats() (HostStats, error) {
+	var st HostStats
+	if load, err := readLoadAvg(filepath.Join(c.procDir, "loadavg")); err == nil {
+		st.LoadAvg = &load
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return st, err
+	}
+	if mem, err := readMemAvailableMB(filepath.Join(c.procDir, "meminfo")); err == nil {
+		st.FreeMemoryMB = &mem
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return st, err
+	}
+	if c.worktreeDir != "" {
+		disk, err := freeDiskMB(c.worktreeDir)
+		if err != nil {
+			return st, err
+		}
+		st.FreeDiskMB = &disk
+	}
+	return st, nil
+}
+
+// CheckHost returns true if the load average, free memory and free disk
+// are all within the configured limits. Unset limits are not checked.
+func (c *Checker) CheckHost() (bool, string, error) {
+	if c.cfg.MaxLoadAvg == 0 && c.cfg.MinFreeMemoryMB == 0 && c.cfg.MinFreeDiskMB == 0 {
+		return true, "", nil
+	}
+	st, err := c.HostStats()
+	if err != nil {
+		return false, "", err
+	}
+	if c.cfg.MaxLoadAvg > 0 && st.LoadAvg != nil && *st.LoadAvg >= c.cfg.MaxLoadAvg {
+		return false, fmt.Sprintf("load average too high: %.2f/%.2f", *st.LoadAvg, c.cfg.MaxLoadAvg), nil
+	}
+	if c.cfg.MinFreeMemoryMB > 0 && st.FreeMemoryMB != nil && *st.FreeMemoryMB < c.cfg.MinFreeMemoryMB {
+		return false, fmt.Sprintf("free memory too low: %d MB < %d MB", *st.FreeMemoryMB, c.cfg.MinFreeMemoryMB), nil
+	}
+	if c.cfg.MinFreeDiskMB > 0 && st.FreeDiskMB != nil && *st.FreeDiskMB < c.cfg.MinFreeDiskMB {
+		return false, fmt.Sprintf("free disk too low: %d MB < %d MB on %s", *st.FreeDiskMB, c.cfg.MinFreeDiskMB, c.worktreeDir), nil
+	}
+	return true, "", nil
+}
+
+// readLoadAvg returns the 1-minute load average from /proc/loadavg.
+func readLoadAvg(path string) (float64, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, err
+	}
+	fields := strings.Fields(string(data))
+	if len(fields) == 0 {
+		return 0, fmt.Errorf("constraints: parse %s: empty", path)
+	}
+	load, err := strconv.ParseFloat(fields[0], 64)
+	if err != nil {
+		return 0, fmt.Errorf("constraints: parse %s: %w", path, err)
+	}
+	return load, nil
+}
+
+// readMemAvailableMB returns MemAvailable from /proc/meminfo in megabytes.
+func readMemAvailableMB(path string) (int64, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer func() { _ = f.Close() }()
+
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		// MemAvailable:    8048576 kB
+		fields := strings.Fields(sc.Text())
+		if len(fields) < 2 || fields[0] != "MemAvailable:" {
+			continue
+		}
+		kb, err := strconv.ParseInt(fields[1], 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("constraints: parse %s: %w", path, err)
+		}
+		return kb / 1024, nil
+	}
+	if err := sc.Err(); err != nil {
+		return 0, fmt.Errorf("constraints: read %s: %w", path, err)
+	}
+	return 0, fmt.Errorf("constraints: parse %s: no MemAvailable", path)
+}
+
+// freeDiskMB returns the space available to unprivileged users on the
+// filesystem holding dir. Before the first worker is spawned dir may not
+// exist yet, so the nearest existing parent is used.
+func freeDiskMB(dir string) (int64, error) {
+	for {
+		var st syscall.Statfs_t
+		err := syscall.Statfs(dir, &st)
+		if err == nil {
+			return int64(uint64(st.Bavail) * uint64(st.Bsize) / (1 << 20)), nil
+		}
+		parent := filepath.Dir(dir)
+		if !errors.Is(err, os.ErrNotExist) || parent == dir {
+			return 0, fmt.Errorf("constraints: statfs %s: %w", dir, err)
+		}
+		dir = parent
+	}
+}
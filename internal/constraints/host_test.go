@@ -0,0 +1,97 @@
+package constraints
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const fakeMeminfo = `MemTotal:       16384000 kB
+MemFree:          512000 kB
+MemAvailable:    2048000 kB
+Buffers:          100000 kB
+`
+
+// helper: a Checker reading fake /proc files with the given load average.
+func fakeHost(t *testing.T, load string) *Checker {
+	t.Helper()
+	proc := t.TempDir()
+	if err := os.WriteFile(filepath.Join(proc, "loadavg"), []byte(load+" 1.00 0.50 2/300 12345\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(proc, "meminfo"), []byte(fakeMeminfo), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	c := NewChecker(defaultCfg(), makeAgentStore(t), emptyReader(t), filepath.Join(t.TempDir(), "merge-queue"))
+	c.procDir = proc
+	return c
+}
+
+func TestHostStats(t *testing.T) {
+	c := fakeHost(t, "3.50")
+	st, err := c.HostStats()
+	if err != nil {
+		t.Fatalf("HostStats: %v", err)
+	}
+	if st.LoadAvg == nil || *st.LoadAvg != 3.5 {
+		t.Errorf("LoadAvg = %v", st.LoadAvg)
+	}
+	if st.FreeMemoryMB == nil || *st.FreeMemoryMB != 2000 {
+		t.Errorf("FreeMemoryMB = %v", st.FreeMemoryMB)
+	}
+	// The worktrees dir does not exist yet; its parent's filesystem is used.
+	if st.FreeDiskMB == nil || *st.FreeDiskMB <= 0 {
+		t.Errorf("FreeDiskMB = %v", st.FreeDiskMB)
+	}
+}
+
+func TestHostStatsWithoutProc(t *testing.T) {
+	c := fakeHost(t, "1.0")
+	c.procDir = filepath.Join(t.TempDir(), "missing")
+	st, err := c.HostStats()
+	if err != nil {
+		t.Fatalf("HostStats: %v", err)
+	}
+	if st.LoadAvg != nil || st.FreeMemoryMB != nil {
+		t.Errorf("stats without /proc = %+v", st)
+	}
+}
+
+func TestCheckHost(t *testing.T) {
+	tests := []struct {
+		name   string
+		load   string
+		set    func(*Checker)
+		reason string
+	}{
+		{"no limits", "50.0", func(c *Checker) {}, ""},
+		{"load under", "3.5", func(c *Checker) { c.cfg.MaxLoadAvg = 4 }, ""},
+		{"load over", "4.5", func(c *Checker) { c.cfg.MaxLoadAvg = 4 }, "load average too high"},
+		{"memory low", "1.0", func(c *Checker) { c.cfg.MinFreeMemoryMB = 4096 }, "free memory too low"},
+		{"memory ok", "1.0", func(c *Checker) { c.cfg.MinFreeMemoryMB = 1024 }, ""},
+		{"disk low", "1.0", func(c *Checker) { c.cfg.MinFreeDiskMB = 1 << 40 }, "free disk too low"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := fakeHost(t, tt.load)
+			tt.set(c)
+			ok, reason, err := c.CheckHost()
+			if err != nil {
+				t.Fatalf("CheckHost: %v", err)
+			}
+			if tt.reason == "" {
+				if !ok {
+					t.Errorf("CheckHost = false, %q", reason)
+				}
+				return
+			}
+			if ok || !strings.Contains(reason, tt.reason) {
+				t.Errorf("CheckHost = %v, %q; want reason containing %q", ok, reason, tt.reason)
+			}
+			if spawn, why := c.CanSpawnWorker(); spawn || why != reason {
+				t.Errorf("CanSpawnWorker = %v, %q", spawn, why)
+			}
+		})
+	}
+}
@@ -0,0 +1,135 @@
+package daemon
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/anthropics/altera/internal/agent"
+	"github.com/anthropics/altera/internal/config"
+	"github.com/anthropics/altera/internal/events"
+	"github.com/anthropics/altera/internal/git"
+	"github.com/anthropics/altera/internal/task"
+)
+
+// workerBase returns the ref new worker branches start from, chosen by
+// branches.base. For "remote" the default branch is fetched first; if the
+// fetch fails, the last fetched copy is used. For "local", a default
+// branch that does not exist locally falls back to HEAD.
+func (d *Daemon) workerBase() (string, error) {
+	branch := d.cfg.DefaultBranch
+	switch d.cfg.Branches.Base {
+	case config.BaseHead:
+		return "HEAD", nil
+	case config.BaseRemote:
+		remote := d.cfg.Branches.RemoteName()
+		ref := remote + "/" + branch
+		if err := git.Fetch(d.rootDir, remote, branch); err != nil {
+			if !git.RefExists(d.rootDir, "refs/remotes/"+ref) {
+				return "", err
+			}
+			d.logger.Warn("branches: fetch failed, using last fetched base", "base", ref, "error", err)
+		}
+		return ref, nil
+	default:
+		if branch == "" || !git.RefExists(d.rootDir, "refs/heads/"+branch) {
+			d.logger.Warn("branches: default branch not found locally, using HEAD", "branch", branch)
+			return "HEAD", nil
+		}
+		return branch, nil
+	}
+}
+
+// --- Step 5e: RebaseWorkers ---
+
+// rebaseWorkers rebases the branches of workers still working on their
+// task onto the current base, every branches.rebase_interval, so long
+// tasks don't drift into conflicts. Worktrees with uncommitted changes are
+// left for a later pass, and a rebase that conflicts is aborted and left
+// for the merge step to resolve. Workers whose branch moved are told.
+func (d *Daemon) rebaseWorkers(tickEvents *[]events.Event) {
+	interval := time.Duration(d.cfg.Branches.RebaseInterval)
+	if interval <= 0 || time.Since(d.lastRebase) < interval {
+		return
+	}
+	d.lastRebase = time.Now()
+
+	workers, err := d.agents.ListByRole(agent.RoleWorker)
+	if err != nil {
+		d.logger.Error("rebase: list workers", "error", err)
+		return
+	}
+
+	var base, rev string // base names the ref for humans; rev is its commit
+	for _, a := range workers {
+		if a.Status != agent.StatusActive || a.Worktree == "" || a.CurrentTask == "" {
+			continue
+		}
+		t, err := d.tasks.Get(a.CurrentTask)
+		if err != nil || (t.Status != task.StatusAssigned && t.Status != task.StatusInProgress) {
+			continue
+		}
+
+		// Resolve the base once per pass, and only if there is a worker
+		// to rebase, so an idle daemon does not fetch. It is resolved in
+		// the project checkout: in a worktree, HEAD is the worker's own
+		// branch.
+		if rev == "" {
+			if base, rev, err = d.rebaseBase(); err != nil {
+				d.logger.Error("rebase: resolve base", "error", err)
+				d.recordError("rebase: " + err.Error())
+				return
+			}
+		}
+		if git.IsAncestor(a.Worktree, rev, "HEAD") {
+			continue
+		}
+		if dirty, err := git.HasUncommittedChanges(a.Worktree); err != nil || dirty {
+			d.logger.Info("rebase: skipping worker with uncommitted changes", "agent", a.ID)
+			continue
+		}
+
+		before, _ := git.Rev(a.Worktree, "HEAD")
+		conflicts, err := git.Rebase(a.Worktree, rev)
+		if errors.Is(err, git.ErrConflict) {
+			d.logger.Info("rebase: conflicts, leaving branch as is", "agent", a.ID, "base", base, "conflicts", conflicts)
+			continue
+		}
+		if err != nil {
+			d.logger.Error("rebase: rebase branch", "agent", a.ID, "base", base, "error", err)
+			continue
+		}
+		after, _ := git.Rev(a.Worktree, "HEAD")
+
+		d.logger.Info("rebase: rebased worker branch", "agent", a.ID, "task", a.CurrentTask, "base", base)
+		*tickEvents = append(*tickEvents, events.Event{
+			Timestamp: time.Now(),
+			Type:      events.BranchRebased,
+			AgentID:   a.ID,
+			TaskID:    a.CurrentTask,
+			Data:      map[string]any{"base": base, "base_rev": rev, "from": before, "to": after},
+		})
+		d.notifyWorker(a, fmt.Sprintf(
+			"Your branch was rebased onto the latest %s; your commits are on top of it. Run git log to see what changed, and re-run the tests before finishing.",
+			base))
+	}
+}
+
+// rebaseBase returns the worker base for a rebase pass as a name and the
+// commit it points at in the project checkout. A base of HEAD is named
+// after the branch checked out there.
+func (d *Daemon) rebaseBase() (name, rev string, err error) {
+	name, err = d.workerBase()
+	if err != nil {
+		return "", "", err
+	}
+	if rev, err = git.Rev(d.rootDir, name); err != nil {
+		return "", "", err
+	}
+	if name == "HEAD" {
+		if branch, err := git.HeadBranch(d.rootDir); err == nil {
+			name = branch
+		}
+	}
+	return name, rev, nil
+}
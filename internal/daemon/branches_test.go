@@ -0,0 +1,149 @@
+package daemon
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/anthropics/altera/internal/agent"
+	"github.com/anthropics/altera/internal/config"
+	"github.com/anthropics/altera/internal/events"
+	"github.com/anthropics/altera/internal/git"
+	"github.com/anthropics/altera/internal/task"
+)
+
+func TestWorkerBase(t *testing.T) {
+	root := setupE2EProject(t)
+	d, err := New(root)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	branch, _ := git.HeadBranch(root)
+	d.cfg.DefaultBranch = branch
+
+	for _, tc := range []struct {
+		base, defaultBranch, want string
+	}{
+		{"", branch, branch},
+		{config.BaseLocal, "no-such-branch", "HEAD"},
+		{config.BaseHead, branch, "HEAD"},
+	} {
+		d.cfg.Branches.Base, d.cfg.DefaultBranch = tc.base, tc.defaultBranch
+		if got, err := d.workerBase(); err != nil || got != tc.want {
+			t.Errorf("base %q, default %q: workerBase = %q, %v; want %q", tc.base, tc.defaultBranch, got, err, tc.want)
+		}
+	}
+
+	d.cfg.Branches.Base, d.cfg.DefaultBranch = config.BaseRemote, branch
+	if _, err := d.workerBase(); err == nil {
+		t.Fatal("expected error for base remote without a remote")
+	}
+
+	// Upstream gains a commit the project checkout lacks; a spawn fetches it.
+	upstream := filepath.Join(t.TempDir(), "upstream")
+	gitCmd(t, root, "clone", "-q", root, upstream)
+	gitCmd(t, root, "remote", "add", "origin", upstream)
+	writeTestFile(t, upstream, "upstream.txt", "new\n")
+	gitCmd(t, upstream, "-c", "user.name=test", "-c", "user.email=test@test.local", "add", "-A")
+	gitCmd(t, upstream, "-c", "user.name=test", "-c", "user.email=test@test.local", "commit", "-qm", "upstream change")
+	want, _ := git.Rev(upstream, "HEAD")
+
+	got, err := d.workerBase()
+	if err != nil || got != "origin/"+branch {
+		t.Fatalf("workerBase = %q, %v; want origin/%s", got, err, branch)
+	}
+	if rev, _ := git.Rev(root, got); rev != want {
+		t.Errorf("%s = %s, want fetched %s", got, rev, want)
+	}
+}
+
+func TestRebaseWorkers(t *testing.T) {
+	root := setupE2EProject(t)
+	d, err := New(root)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	branch, _ := git.HeadBranch(root)
+	d.cfg.DefaultBranch = branch
+	d.cfg.Branches.RebaseInterval = config.Duration(time.Hour)
+
+	// A worker with one commit on its branch...
+	wt := filepath.Join(root, ".alt", "worktrees", "w-rebase")
+	if err := git.CreateBranch(root, "worker/w-rebase", branch); err != nil {
+		t.Fatal(err)
+	}
+	if err := git.CreateWorktree(root, "worker/w-rebase", wt); err != nil {
+		t.Fatal(err)
+	}
+	writeTestFile(t, wt, "feature.txt", "feature\n")
+	gitCmd(t, wt, "add", "feature.txt")
+	gitCmd(t, wt, "commit", "-qm", "feature")
+
+	tk := &task.Task{Title: "long task", Status: task.StatusInProgress, AssignedTo: "w-rebase", Branch: "worker/w-rebase"}
+	if err := d.tasks.Create(tk); err != nil {
+		t.Fatalf("create task: %v", err)
+	}
+	a := &agent.Agent{
+		ID:          "w-rebase",
+		Role:        agent.RoleWorker,
+		Status:      agent.StatusActive,
+		CurrentTask: tk.ID,
+		Worktree:    wt,
+		Heartbeat:   time.Now(),
+		StartedAt:   time.Now(),
+	}
+	if err := d.agents.Create(a); err != nil {
+		t.Fatalf("create agent: %v", err)
+	}
+
+	// ...while the default branch moves on.
+	writeTestFile(t, root, "other.txt", "merged meanwhile\n")
+	gitCmd(t, root, "add", "other.txt")
+	gitCmd(t, root, "commit", "-qm", "other task")
+
+	var tickEvents []events.Event
+	d.rebaseWorkers(&tickEvents)
+
+	if !git.IsAncestor(wt, branch, "HEAD") {
+		t.Fatal("worker branch was not rebased onto the default branch")
+	}
+	if log := gitCmd(t, wt, "log", "--format=%s", "-2"); log != "feature\nother task\n" {
+		t.Errorf("worker log = %q", log)
+	}
+	if len(tickEvents) != 1 || tickEvents[0].Type != events.BranchRebased || tickEvents[0].AgentID != a.ID {
+		t.Fatalf("events = %+v, want one branch_rebased", tickEvents)
+	}
+	if base, _ := tickEvents[0].Data["base"].(string); base != branch {
+		t.Errorf("event base = %q, want %q", base, branch)
+	}
+
+	// The next pass waits for the interval.
+	gitCmd(t, root, "commit", "-q", "--allow-empty", "-m", "later")
+	tickEvents = nil
+	d.rebaseWorkers(&tickEvents)
+	if len(tickEvents) != 0 || git.IsAncestor(wt, branch, "HEAD") {
+		t.Error("rebased again before rebase_interval elapsed")
+	}
+
+	// Uncommitted work is never rebased under the worker.
+	d.lastRebase = time.Time{}
+	writeTestFile(t, wt, "feature.txt", "editing\n")
+	d.rebaseWorkers(&tickEvents)
+	if len(tickEvents) != 0 || !strings.Contains(gitCmd(t, wt, "status", "--short"), "feature.txt") {
+		t.Error("rebased a worktree with uncommitted changes")
+	}
+
+	// With branches.base=head the base is the project checkout's HEAD,
+	// not the worktree's.
+	gitCmd(t, wt, "checkout", "--", "feature.txt")
+	d.cfg.Branches.Base = config.BaseHead
+	d.lastRebase = time.Time{}
+	d.rebaseWorkers(&tickEvents)
+	if !git.IsAncestor(wt, branch, "HEAD") {
+		t.Fatal("base head: worker branch was not rebased onto the project HEAD")
+	}
+	if len(tickEvents) != 1 || tickEvents[0].Data["base"] != branch {
+		t.Errorf("base head: events = %+v, want one naming %s", tickEvents, branch)
+	}
+}
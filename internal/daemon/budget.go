@@ -0,0 +1,212 @@
+package daemon
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/anthropics/altera/internal/agent"
+	"github.com/anthropics/altera/internal/events"
+	"github.com/anthropics/altera/internal/git"
+	"github.com/anthropics/altera/internal/message"
+	"github.com/anthropics/altera/internal/task"
+	"github.com/anthropics/altera/internal/tmux"
+)
+
+// BudgetWarnFraction is the share of a task or agent budget at which the
+// worker is told to wrap up. At 100% it is checkpointed and stopped.
+const BudgetWarnFraction = 0.8
+
+// budgetLimit is a budget that applies to a worker and its spend so far.
+type budgetLimit struct {
+	scope string // "task" or "agent"
+	spent float64
+	limit float64
+}
+
+func (b budgetLimit) fraction() float64 { return b.spent / b.limit }
+
+// --- Step 5d: CheckBudgets ---
+
+// checkBudgets enforces task budgets and the per-agent cap on active
+// workers. Usage recorded earlier in this tick but not yet written to the
+// log is included.
+func (d *Daemon) checkBudgets(tickEvents *[]events.Event) {
+	workers, err := d.agents.ListByRole(agent.RoleWorker)
+	if err != nil {
+		d.logger.Error("budget: list workers", "error", err)
+		return
+	}
+	pending := pendingCosts(*tickEvents)
+	for _, a := range workers {
+		if a.Status != agent.StatusActive || a.CurrentTask == "" {
+			continue
+		}
+		lim, ok := d.workerBudget(a, pending)
+		if !ok {
+			continue
+		}
+		switch {
+		case lim.fraction() >= 1:
+			d.stopOverBudget(a, lim, tickEvents)
+		case lim.fraction() >= BudgetWarnFraction && a.BudgetWarned != lim.limit:
+			d.warnBudget(a, lim, tickEvents)
+		}
+	}
+}
+
+// pendingCosts sums token_cost in events not yet appended to the log,
+// keyed by "task:<id>" and "agent:<id>".
+func pendingCosts(evts []events.Event) map[string]float64 {
+	out := map[string]float64{}
+	for _, ev := range evts {
+		cost, ok := ev.Data["token_cost"].(float64)
+		if !ok {
+			continue
+		}
+		if ev.TaskID != "" {
+			out["task:"+ev.TaskID] += cost
+		}
+		if ev.AgentID != "" {
+			out["agent:"+ev.AgentID] += cost
+		}
+	}
+	return out
+}
+
+// workerBudget returns the budget closest to exhaustion for a worker: its
+// task's budget or the configured per-agent cap. ok is false when neither
+// is set.
+func (d *Daemon) workerBudget(a *agent.Agent, pending map[string]float64) (budgetLimit, bool) {
+	var best budgetLimit
+	found := false
+	consider := func(b budgetLimit) {
+		if !found || b.fraction() > best.fraction() {
+			best, found = b, true
+		}
+	}
+
+	if t, err := d.tasks.Get(a.CurrentTask); err == nil && t.Budget > 0 {
+		spent, err := d.checker.TaskSpend(t.ID)
+		if err != nil {
+			d.logger.Error("budget: task spend", "task", t.ID, "error", err)
+		} else {
+			consider(budgetLimit{scope: "task", spent: spent + pending["task:"+t.ID], limit: t.Budget})
+		}
+	}
+	if limit := d.cfg.Constraints.AgentBudget; limit > 0 {
+		spent, err := d.checker.AgentSpend(a.ID)
+		if err != nil {
+			d.logger.Error("budget: agent spend", "agent", a.ID, "error", err)
+		} else {
+			consider(budgetLimit{scope: "agent", spent: spent + pending["agent:"+a.ID], limit: limit})
+		}
+	}
+	return best, found
+}
+
+// warnBudget tells the worker it is near its budget. The warning is sent
+// once per limit, so raising a task's budget re-arms it.
+func (d *Daemon) warnBudget(a *agent.Agent, lim budgetLimit, tickEvents *[]events.Event) {
+	d.logger.Warn("budget: worker near limit", "agent", a.ID, "task", a.CurrentTask,
+		"scope", lim.scope, "spent", lim.spent, "limit", lim.limit)
+
+	a.BudgetWarned = lim.limit
+	if err := d.agents.Update(a); err != nil {
+		d.logger.Error("budget: update agent", "agent", a.ID, "error", err)
+		return
+	}
+	d.notifyWorker(a, fmt.Sprintf(
+		"You have used %.0f%% of your %s budget ($%.2f of $%.2f). Commit your work and wrap up; you will be stopped at 100%%.",
+		lim.fraction()*100, lim.scope, lim.spent, lim.limit))
+
+	*tickEvents = append(*tickEvents, events.Event{
+		Timestamp: time.Now(),
+		Type:      events.AgentWarning,
+		AgentID:   a.ID,
+		TaskID:    a.CurrentTask,
+		Data:      map[string]any{"reason": "budget", "scope": lim.scope, "spent": lim.spent, "limit": lim.limit},
+	})
+}
+
+// stopOverBudget checkpoints a worker that has reached its budget and
+// stops it. Uncommitted work is committed to the worker branch, which is
+// kept; the task goes back to open with a checkpoint naming the branch,
+// and is not reassigned until its budget is raised.
+func (d *Daemon) stopOverBudget(a *agent.Agent, lim budgetLimit, tickEvents *[]events.Event) {
+	d.logger.Warn("budget: stopping worker", "agent", a.ID, "task", a.CurrentTask,
+		"scope", lim.scope, "spent", lim.spent, "limit", lim.limit)
+	reason := fmt.Sprintf("%s budget exceeded: $%.2f of $%.2f", lim.scope, lim.spent, lim.limit)
+
+	// Stop the worker before committing on its behalf.
+	if a.TmuxSession != "" && tmux.SessionExists(a.TmuxSession) {
+		if err := tmux.KillSession(a.TmuxSession); err != nil {
+			d.logger.Error("budget: kill tmux session", "session", a.TmuxSession, "error", err)
+		}
+	}
+
+	// Checkpoint: commit whatever the worker left uncommitted.
+	if a.Worktree != "" {
+		if dirty, err := git.HasUncommittedChanges(a.Worktree); err == nil && dirty {
+			if err := git.Add(a.Worktree, nil); err == nil {
+				if err := git.Commit(a.Worktree, "wip: checkpoint before budget stop"); err != nil {
+					d.logger.Error("budget: checkpoint commit", "agent", a.ID, "error", err)
+				}
+			}
+		}
+	}
+
+	// Detach the branch from the task so markAgentDead reopens the task
+	// without deleting the branch the checkpoint points to.
+	if t, err := d.tasks.Get(a.CurrentTask); err == nil {
+		t.Checkpoint = "stopped by daemon: " + reason
+		if t.Branch != "" {
+			t.Checkpoint += "; work so far is on branch " + t.Branch
+		}
+		t.Branch = ""
+		t.UpdatedAt = time.Now().UTC()
+		if err := d.tasks.ForceWrite(t); err != nil {
+			d.logger.Error("budget: checkpoint task", "task", t.ID, "error", err)
+		}
+	}
+
+	*tickEvents = append(*tickEvents, events.Event{
+		Timestamp: time.Now(),
+		Type:      events.BudgetExceeded,
+		AgentID:   a.ID,
+		TaskID:    a.CurrentTask,
+		Data:      map[string]any{"reason": reason, "scope": lim.scope, "spent": lim.spent, "limit": lim.limit},
+	})
+	d.markAgentDead(a, "budget_exceeded", tickEvents)
+
+	if liaisons, err := d.agents.ListByRole(agent.RoleLiaison); err == nil && len(liaisons) > 0 {
+		_, _ = d.messages.Send("daemon", liaisons[0].ID, a.CurrentTask, message.HelpPayload{
+			WorkerID: a.ID,
+			Message: fmt.Sprintf("worker %s was stopped (%s). Raise the budget with `alt task budget %s <amount>` to resume the task.",
+				a.ID, reason, a.CurrentTask),
+		}, message.WithPriority(message.PriorityHigh))
+	}
+
+	// The worktree goes; the branch stays as the checkpoint.
+	if a.Worktree != "" {
+		if _, err := os.Stat(a.Worktree); err == nil {
+			if err := git.DeleteWorktree(d.rootDir, a.Worktree); err != nil {
+				d.logger.Error("budget: delete worktree", "path", a.Worktree, "error", err)
+			}
+		}
+	}
+}
+
+// overBudget reports whether a task has spent its whole budget. Such tasks
+// are not assigned until the budget is raised.
+func (d *Daemon) overBudget(t *task.Task) bool {
+	if t.Budget <= 0 {
+		return false
+	}
+	spent, err := d.checker.TaskSpend(t.ID)
+	if err != nil {
+		d.logger.Error("budget: task spend", "task", t.ID, "error", err)
+		return false
+	}
+	return spent >= t.Budget
+}
@@ -0,0 +1,156 @@
+package daemon
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/anthropics/altera/internal/agent"
+	"github.com/anthropics/altera/internal/events"
+	"github.com/anthropics/altera/internal/git"
+	"github.com/anthropics/altera/internal/task"
+	"github.com/anthropics/altera/internal/usage"
+)
+
+func TestCheckBudgets(t *testing.T) {
+	root := setupE2EProject(t)
+	d, err := New(root)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	tk := &task.Task{Title: "budgeted", Budget: 10}
+	if err := d.tasks.Create(tk); err != nil {
+		t.Fatal(err)
+	}
+	if err := git.CreateBranch(root, "worker/w-1", "HEAD"); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.tasks.Update(tk.ID, func(t *task.Task) error {
+		t.Status = task.StatusAssigned
+		t.AssignedTo = "w-1"
+		t.Branch = "worker/w-1"
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	a := &agent.Agent{ID: "w-1", Role: agent.RoleWorker, Status: agent.StatusActive, CurrentTask: tk.ID,
+		Heartbeat: time.Now(), StartedAt: time.Now()}
+	if err := d.agents.Create(a); err != nil {
+		t.Fatal(err)
+	}
+
+	// 85% of the task budget: warn once.
+	tickEvents := []events.Event{usage.NewEvent("w-1", tk.ID, usage.Usage{Model: "m"}, 8.5, usage.SourceAgent)}
+	d.checkBudgets(&tickEvents)
+	if len(tickEvents) != 2 || tickEvents[1].Type != events.AgentWarning || tickEvents[1].Data["reason"] != "budget" {
+		t.Fatalf("events after warning = %+v", tickEvents)
+	}
+	d.emitEvents(tickEvents)
+	if got, _ := d.agents.Get("w-1"); got.BudgetWarned != 10 {
+		t.Errorf("BudgetWarned = %v, want 10", got.BudgetWarned)
+	}
+	tickEvents = nil
+	d.checkBudgets(&tickEvents)
+	if len(tickEvents) != 0 {
+		t.Errorf("warned twice: %+v", tickEvents)
+	}
+
+	// Reaching the budget stops the worker and reopens the task.
+	tickEvents = []events.Event{usage.NewEvent("w-1", tk.ID, usage.Usage{Model: "m"}, 2, usage.SourceAgent)}
+	d.checkBudgets(&tickEvents)
+	var exceeded, died bool
+	for _, ev := range tickEvents {
+		switch ev.Type {
+		case events.BudgetExceeded:
+			exceeded = ev.TaskID == tk.ID && ev.Data["scope"] == "task"
+		case events.AgentDied:
+			died = ev.Data["reason"] == "budget_exceeded"
+		}
+	}
+	if !exceeded || !died {
+		t.Errorf("events after stop = %+v", tickEvents)
+	}
+	d.emitEvents(tickEvents)
+	if got, _ := d.agents.Get("w-1"); got.Status != agent.StatusDead {
+		t.Errorf("agent status = %s, want dead", got.Status)
+	}
+	got, _ := d.tasks.Get(tk.ID)
+	if got.Status != task.StatusOpen || got.AssignedTo != "" || !strings.Contains(got.Checkpoint, "worker/w-1") {
+		t.Errorf("task after stop = %+v", got)
+	}
+	if !git.RefExists(root, "refs/heads/worker/w-1") {
+		t.Error("checkpoint branch deleted")
+	}
+
+	// An exhausted task is held back from assignment until raised.
+	if !d.overBudget(got) {
+		t.Error("overBudget = false for exhausted task")
+	}
+	got.Budget = 20
+	if d.overBudget(got) {
+		t.Error("overBudget = true after raising the budget")
+	}
+}
+
+func TestCheckBudgetsAgentCap(t *testing.T) {
+	root := setupTestProject(t)
+	d, err := New(root)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	d.cfg.Constraints.AgentBudget = 5
+
+	a := &agent.Agent{ID: "w-1", Role: agent.RoleWorker, Status: agent.StatusActive, CurrentTask: "t-1",
+		Heartbeat: time.Now(), StartedAt: time.Now()}
+	if err := d.agents.Create(a); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.events.Append(usage.NewEvent("w-1", "t-0", usage.Usage{Model: "m"}, 5, usage.SourceAgent)); err != nil {
+		t.Fatal(err)
+	}
+
+	var tickEvents []events.Event
+	d.checkBudgets(&tickEvents)
+	if len(tickEvents) == 0 || tickEvents[0].Type != events.BudgetExceeded || tickEvents[0].Data["scope"] != "agent" {
+		t.Errorf("events = %+v", tickEvents)
+	}
+}
+
+func TestCheckConstraintsPausesOnWindowBudget(t *testing.T) {
+	root := setupTestProject(t)
+	d, err := New(root)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	cfg := d.cfg.Constraints
+	cfg.HourlyBudget = 1
+	d.checker.UpdateConstraints(cfg)
+	if err := d.events.Append(usage.NewEvent("w-1", "t-1", usage.Usage{Model: "m"}, 2, usage.SourceAgent)); err != nil {
+		t.Fatal(err)
+	}
+
+	var tickEvents []events.Event
+	d.checkConstraints(&tickEvents)
+	if d.budgetPaused == "" || len(tickEvents) != 1 || tickEvents[0].Type != events.BudgetExceeded {
+		t.Fatalf("paused = %q, events = %+v", d.budgetPaused, tickEvents)
+	}
+	if ok, _ := d.checker.CanSpawnWorker(); ok {
+		t.Error("CanSpawnWorker = true while the hourly budget is spent")
+	}
+
+	// Staying paused is not news.
+	tickEvents = nil
+	d.checkConstraints(&tickEvents)
+	if len(tickEvents) != 0 {
+		t.Errorf("events while still paused = %+v, want none", tickEvents)
+	}
+
+	// Once the window has room again, assignment resumes on its own.
+	cfg.HourlyBudget = 5
+	d.checker.UpdateConstraints(cfg)
+	d.checkConstraints(&tickEvents)
+	if d.budgetPaused != "" {
+		t.Errorf("still paused: %q", d.budgetPaused)
+	}
+}
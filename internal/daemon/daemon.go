@@ -5,9 +5,11 @@
 package daemon
 
 import (
+	"context"
 	"crypto/rand"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log/slog"
@@ -17,6 +19,7 @@ import (
 	"path/filepath"
 	"strconv"
 	"strings"
+	"sync"
 	"syscall"
 	"time"
 
@@ -27,19 +30,15 @@ import (
 	"github.com/anthropics/altera/internal/git"
 	"github.com/anthropics/altera/internal/merge"
 	"github.com/anthropics/altera/internal/message"
+	"github.com/anthropics/altera/internal/notify"
 	"github.com/anthropics/altera/internal/resolver"
 	"github.com/anthropics/altera/internal/session"
 	"github.com/anthropics/altera/internal/task"
 	"github.com/anthropics/altera/internal/tmux"
+	"github.com/anthropics/altera/internal/tracing"
+	"github.com/anthropics/altera/internal/usage"
 )
 
-// TickInterval is the duration between daemon ticks.
-const TickInterval = 60 * time.Second
-
-// StalledThreshold is the duration after which a worker with no recent
-// commits is considered stalled.
-const StalledThreshold = 30 * time.Minute
-
 // Daemon is the main orchestration process. It coordinates agent
 // lifecycle, task assignment, merge queue processing, and event logging.
 type Daemon struct {
@@ -54,6 +53,10 @@ type Daemon struct {
 	evReader    *events.Reader
 	checker     *constraints.Checker
 	resolverMgr *resolver.Manager
+	notifier    *notify.Manager // nil when no sinks are configured
+	tracer      *tracing.Runner // nil when no trace endpoint is configured
+	metrics     *daemonMetrics
+	usage       *usage.Tracker
 
 	pidFile  string   // path to .alt/daemon.pid
 	lockFile *os.File // held flock on pid file
@@ -63,14 +66,28 @@ type Daemon struct {
 	tickNum  int64
 	shutdown chan struct{} // closed to signal shutdown
 	tickNow  chan struct{} // buffered(1), receives SIGUSR1 forced ticks
+	reload   chan struct{} // buffered(1), receives SIGHUP config reloads
+
+	tickInterval time.Duration // configurable tick interval (default daemon.tick_interval)
+	tickOverride bool          // tickInterval was set by WithTickInterval, not config
+	cfgStamp     string        // config file fingerprint at the last load; see configStamp
+	lastRebase   time.Time     // start of the last rebaseWorkers pass
 
-	tickInterval      time.Duration // configurable tick interval (default TickInterval)
-	workerCmdTemplate string        // custom worker command (empty = use Claude Code)
+	// selfReported caches agents that record their own token usage, whose
+	// transcripts are therefore not counted.
+	selfReported map[string]bool
+
+	// groupRetries counts failed attempts at group messages by ID. They
+	// are kept here rather than on the shared message file, which other
+	// recipients read too.
+	groupRetries map[string]int
 
 	// State tracking for observability (written to daemon-state.json each tick).
 	lastSpawnTask  string
 	lastSpawnError string
 	recentErrors   []string // capped at 10
+	budgetPaused   string   // why assignment is paused on budget; empty when not
+	spawnBlocked   string   // why the last assignment pass could not spawn a worker
 }
 
 // Option configures a Daemon.
@@ -80,16 +97,7 @@ type Option func(*Daemon)
 func WithTickInterval(interval time.Duration) Option {
 	return func(d *Daemon) {
 		d.tickInterval = interval
-	}
-}
-
-// WithWorkerCommand sets a custom worker command. When set, workers run
-// this command instead of Claude Code. The command runs in the worktree
-// with ALT_AGENT_ID set in the environment. The command should NOT use
-// exec so that the shell stays alive as the pane process.
-func WithWorkerCommand(cmd string) Option {
-	return func(d *Daemon) {
-		d.workerCmdTemplate = cmd
+		d.tickOverride = true
 	}
 }
 
@@ -105,6 +113,10 @@ func New(rootDir string, opts ...Option) (*Daemon, error) {
 	if err != nil {
 		return nil, fmt.Errorf("daemon: load config: %w", err)
 	}
+	// Validate everything reload will, so a config that starts also reloads.
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("daemon: invalid config: %w", err)
+	}
 
 	agentStore, err := agent.NewStore(filepath.Join(altDir, "agents"))
 	if err != nil {
@@ -120,9 +132,14 @@ func New(rootDir string, opts ...Option) (*Daemon, error) {
 	if err != nil {
 		return nil, fmt.Errorf("daemon: create message store: %w", err)
 	}
+	msgStore.SetDirectory(agentStore)
 
 	evPath := filepath.Join(altDir, "events.jsonl")
-	evWriter := events.NewWriter(evPath)
+	evWriter := events.NewWriter(evPath, events.WithRotation(events.RotationPolicy{
+		MaxBytes: cfg.Events.MaxBytes,
+		MaxAge:   time.Duration(cfg.Events.MaxAge),
+		Compress: cfg.Events.Compress,
+	}))
 	evReader := events.NewReader(evPath)
 
 	mergeQueueDir := filepath.Join(altDir, "merge-queue")
@@ -130,10 +147,6 @@ func New(rootDir string, opts ...Option) (*Daemon, error) {
 		return nil, fmt.Errorf("daemon: create merge-queue dir: %w", err)
 	}
 
-	if err := cfg.Constraints.Validate(); err != nil {
-		return nil, fmt.Errorf("daemon: invalid constraints: %w", err)
-	}
-
 	checker := constraints.NewChecker(cfg.Constraints, agentStore, evReader, mergeQueueDir)
 
 	resolverMgr := resolver.NewManager(rootDir, agentStore, evWriter)
@@ -148,7 +161,27 @@ func New(rootDir string, opts ...Option) (*Daemon, error) {
 		return nil, fmt.Errorf("daemon: open log file: %w", err)
 	}
 	logWriter := io.MultiWriter(os.Stderr, logFile)
-	logger := slog.New(slog.NewTextHandler(logWriter, nil)).With("component", "daemon")
+	logHandler := slog.NewTextHandler(logWriter, nil)
+	logger := slog.New(logHandler).With("component", "daemon")
+
+	var notifier *notify.Manager
+	if len(cfg.Notify) > 0 {
+		notifier, err = notify.NewManager(altDir, evReader, cfg.Notify, slog.New(logHandler).With("component", "notify"))
+		if err != nil {
+			_ = logFile.Close()
+			return nil, fmt.Errorf("daemon: invalid notification sinks: %w", err)
+		}
+	}
+
+	var tracer *tracing.Runner
+	if cfg.Tracing.Endpoint != "" {
+		exporter, err := tracing.NewExporter(cfg.Tracing.Endpoint, cfg.Tracing.ServiceName)
+		if err != nil {
+			_ = logFile.Close()
+			return nil, fmt.Errorf("daemon: invalid tracing settings: %w", err)
+		}
+		tracer = tracing.NewRunner(altDir, evReader, exporter, slog.New(logHandler).With("component", "tracing"))
+	}
 
 	d := &Daemon{
 		altDir:       altDir,
@@ -161,16 +194,24 @@ func New(rootDir string, opts ...Option) (*Daemon, error) {
 		evReader:     evReader,
 		checker:      checker,
 		resolverMgr:  resolverMgr,
+		notifier:     notifier,
+		tracer:       tracer,
+		metrics:      newDaemonMetrics(),
+		usage:        usage.NewTracker(filepath.Join(altDir, "usage")),
+		selfReported: map[string]bool{},
+		groupRetries: map[string]int{},
 		pidFile:      filepath.Join(altDir, "daemon.pid"),
 		logFile:      logFile,
 		logger:       logger,
 		shutdown:     make(chan struct{}),
 		tickNow:      make(chan struct{}, 1),
-		tickInterval: TickInterval,
+		reload:       make(chan struct{}, 1),
+		tickInterval: time.Duration(cfg.Daemon.TickInterval),
 	}
 	for _, opt := range opts {
 		opt(d)
 	}
+	d.cfgStamp = d.configStamp()
 	return d, nil
 }
 
@@ -197,12 +238,58 @@ func (d *Daemon) Run() error {
 	})
 	d.logger.Info("started")
 
+	// Notification sinks and the trace exporter follow the event log on
+	// their own goroutines and stop when Run returns; anything undelivered
+	// is picked up from their cursors on the next start. The metrics
+	// endpoint runs alongside them.
+	var followers []func(context.Context)
+	if d.notifier != nil {
+		followers = append(followers, d.notifier.Run)
+	}
+	if d.tracer != nil {
+		followers = append(followers, d.tracer.Run)
+	}
+	if addr := d.cfg.Metrics.Listen; addr != "" {
+		followers = append(followers, func(ctx context.Context) { d.serveMetrics(ctx, addr) })
+	}
+	if len(followers) > 0 {
+		ctx, cancel := context.WithCancel(context.Background())
+		var wg sync.WaitGroup
+		for _, run := range followers {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				run(ctx)
+			}()
+		}
+		defer func() {
+			cancel()
+			wg.Wait()
+		}()
+	}
+
 	// Run one tick immediately, then loop on the interval.
 	d.tick()
 
 	ticker := time.NewTicker(d.tickInterval)
 	defer ticker.Stop()
 
+	// Config changes are picked up between ticks; a new tick interval
+	// restarts the ticker.
+	configPoll := time.NewTicker(configPollInterval)
+	defer configPoll.Stop()
+	reloaded := func(trigger string) {
+		interval := d.tickInterval
+		if trigger == "sighup" {
+			d.reloadConfig(trigger)
+		} else {
+			d.reloadIfChanged()
+		}
+		if d.tickInterval != interval {
+			ticker.Reset(d.tickInterval)
+		}
+	}
+
 	for {
 		select {
 		case <-d.shutdown:
@@ -222,6 +309,10 @@ func (d *Daemon) Run() error {
 			})
 			d.tick()
 			ticker.Reset(d.tickInterval)
+		case <-d.reload:
+			reloaded("sighup")
+		case <-configPoll.C:
+			reloaded("file")
 		}
 	}
 }
@@ -248,7 +339,8 @@ func (d *Daemon) reconcile() {
 }
 
 // reconcileAgents checks all active agents for liveness, marking dead
-// ones and killing orphaned tmux sessions.
+// ones and killing orphaned tmux sessions. An agent is dead once its
+// process is gone or its heartbeat is older than daemon.heartbeat_dead.
 func (d *Daemon) reconcileAgents() {
 	active, err := d.agents.ListByStatus(agent.StatusActive)
 	if err != nil {
@@ -260,7 +352,7 @@ func (d *Daemon) reconcileAgents() {
 		if a.Role == agent.RoleLiaison {
 			continue
 		}
-		if agent.CheckLiveness(a) {
+		if agent.CheckPID(a) && agent.HeartbeatStaleness(a) <= time.Duration(d.cfg.Daemon.HeartbeatDead) {
 			continue
 		}
 		d.logger.Info("reconcile agents: marking dead", "agent", a.ID, "role", a.Role)
@@ -362,13 +454,8 @@ func (d *Daemon) tick() {
 	d.logger.Info("tick start", "tick", d.tickNum)
 	start := time.Now()
 
-	// Reload config from disk so runtime changes (e.g. max_workers) take effect.
-	if cfg, err := config.Load(d.altDir); err == nil {
-		d.cfg = cfg
-		d.checker.UpdateConstraints(cfg.Constraints)
-	} else {
-		d.logger.Error("tick: reload config", "error", err)
-	}
+	// Pick up config edits made since the last poll (e.g. max_workers).
+	d.reloadIfChanged()
 
 	var tickEvents []events.Event
 
@@ -378,11 +465,17 @@ func (d *Daemon) tick() {
 	d.processMessages(&tickEvents)
 	d.processMergeQueue(&tickEvents)
 	d.checkResolvers(&tickEvents)
+	d.recordUsage(&tickEvents)
+	d.checkBudgets(&tickEvents)
+	d.rebaseWorkers(&tickEvents)
 	d.checkConstraints(&tickEvents)
 	d.emitEvents(tickEvents)
 	d.writeState()
+	d.collectMetrics()
 
-	d.logger.Info("tick complete", "tick", d.tickNum, "duration", time.Since(start).Round(time.Millisecond))
+	elapsed := time.Since(start)
+	d.metrics.tickDuration.Observe(elapsed.Seconds())
+	d.logger.Info("tick complete", "tick", d.tickNum, "duration", elapsed.Round(time.Millisecond))
 }
 
 // --- Step 1: CheckAgentLiveness ---
@@ -417,7 +510,7 @@ func (d *Daemon) checkAgentLiveness(tickEvents *[]events.Event) {
 		}
 
 		// PID alive, heartbeat fresh — clear any prior escalation.
-		if staleness <= agent.HeartbeatWarnTimeout {
+		if staleness <= time.Duration(d.cfg.Daemon.HeartbeatWarn) {
 			if a.EscalationLevel != "" {
 				d.logger.Info("liveness: heartbeat recovered", "agent", a.ID, "was", a.EscalationLevel)
 				a.EscalationLevel = ""
@@ -430,13 +523,13 @@ func (d *Daemon) checkAgentLiveness(tickEvents *[]events.Event) {
 		}
 
 		// PID alive, heartbeat stale > DeadTimeout → dead.
-		if staleness > agent.HeartbeatDeadTimeout {
+		if staleness > time.Duration(d.cfg.Daemon.HeartbeatDead) {
 			d.markAgentDead(a, "heartbeat_timeout", tickEvents)
 			continue
 		}
 
 		// PID alive, heartbeat stale > CriticalTimeout → critical.
-		if staleness > agent.HeartbeatCriticalTimeout {
+		if staleness > time.Duration(d.cfg.Daemon.HeartbeatCritical) {
 			if a.EscalationLevel != agent.EscalationCritical {
 				d.escalateCritical(a, tickEvents)
 			}
@@ -510,7 +603,9 @@ func (d *Daemon) markAgentDead(a *agent.Agent, reason string, tickEvents *[]even
 		}
 	}
 
-	// Copy JSONL transcript to .alt/logs/ before destroying resources.
+	// Record the agent's final token usage and copy its JSONL transcript
+	// to .alt/logs/ before destroying resources.
+	d.collectUsage(a, tickEvents)
 	d.copyTranscript(a)
 
 	if a.TmuxSession != "" && tmux.SessionExists(a.TmuxSession) {
@@ -564,17 +659,17 @@ func (d *Daemon) escalateCritical(a *agent.Agent, tickEvents *[]events.Event) {
 	// Notify liaison that worker appears unresponsive.
 	liaisons, err := d.agents.ListByRole(agent.RoleLiaison)
 	if err == nil && len(liaisons) > 0 {
-		_, err := d.messages.Create(
-			message.TypeHelp,
+		_, err := d.messages.Send(
 			"daemon",
 			liaisons[0].ID,
 			a.CurrentTask,
-			map[string]any{
-				"worker_id":        a.ID,
-				"escalation_level": agent.EscalationCritical,
-				"message": fmt.Sprintf("worker %s appears unresponsive (heartbeat stale for %s)",
+			message.HelpPayload{
+				WorkerID:        a.ID,
+				EscalationLevel: agent.EscalationCritical,
+				Message: fmt.Sprintf("worker %s appears unresponsive (heartbeat stale for %s)",
 					a.ID, agent.HeartbeatStaleness(a).Round(time.Second)),
 			},
+			message.WithPriority(message.PriorityHigh),
 		)
 		if err != nil {
 			d.logger.Error("liveness: notify liaison", "error", err)
@@ -626,7 +721,7 @@ func (d *Daemon) reclaimTask(taskID string) error {
 // --- Step 2: CheckProgress ---
 
 // checkProgress checks last commit time in each worker's worktree. If a
-// worker has been stalled for longer than StalledThreshold, a help message
+// worker has been stalled for longer than daemon.stalled_threshold, a help message
 // is sent to the liaison.
 func (d *Daemon) checkProgress(tickEvents *[]events.Event) {
 	workers, err := d.agents.ListByRole(agent.RoleWorker)
@@ -635,6 +730,7 @@ func (d *Daemon) checkProgress(tickEvents *[]events.Event) {
 		return
 	}
 
+	stalled := time.Duration(d.cfg.Daemon.StalledThreshold)
 	for _, w := range workers {
 		if w.Status != agent.StatusActive || w.Worktree == "" {
 			continue
@@ -646,9 +742,9 @@ func (d *Daemon) checkProgress(tickEvents *[]events.Event) {
 			continue
 		}
 
-		if time.Since(lastCommitTime) > StalledThreshold {
+		if time.Since(lastCommitTime) > stalled {
 			// Throttle stall notifications: skip if already notified within threshold.
-			if !w.LastStallNotified.IsZero() && time.Since(w.LastStallNotified) < StalledThreshold {
+			if !w.LastStallNotified.IsZero() && time.Since(w.LastStallNotified) < stalled {
 				continue
 			}
 
@@ -670,15 +766,14 @@ func (d *Daemon) checkProgress(tickEvents *[]events.Event) {
 				d.logger.Info("progress: no liaison to notify", "agent", w.ID)
 				continue
 			}
-			_, err = d.messages.Create(
-				message.TypeHelp,
+			_, err = d.messages.Send(
 				"daemon",
 				liaisons[0].ID,
 				w.CurrentTask,
-				map[string]any{
-					"worker_id":    w.ID,
-					"stalled_since": lastCommitTime.Format(time.RFC3339),
-					"message":       fmt.Sprintf("worker %s stalled for %s", w.ID, time.Since(lastCommitTime).Round(time.Minute)),
+				message.HelpPayload{
+					WorkerID:     w.ID,
+					StalledSince: lastCommitTime.Format(time.RFC3339),
+					Message:      fmt.Sprintf("worker %s stalled for %s", w.ID, time.Since(lastCommitTime).Round(time.Minute)),
 				},
 			)
 			if err != nil {
@@ -723,10 +818,17 @@ func (d *Daemon) assignTasks(tickEvents *[]events.Event) {
 		return
 	}
 
+	d.spawnBlocked = ""
 	for _, t := range ready {
+		if d.overBudget(t) {
+			d.logger.Info("assign: task over budget, waiting for it to be raised", "task", t.ID, "budget", t.Budget)
+			continue
+		}
+
 		ok, reason := d.checker.CanSpawnWorker()
 		if !ok {
 			d.logger.Info("assign: cannot spawn worker", "reason", reason)
+			d.spawnBlocked = reason
 			break // constraints apply globally, no point continuing
 		}
 
@@ -775,8 +877,24 @@ func (d *Daemon) spawnWorker(t *task.Task) (string, error) {
 	worktreePath := filepath.Join(d.rootDir, ".alt", "worktrees", agentID)
 	sessionName := tmux.SessionName("worker", agentID)
 
-	// Create branch from default branch (main).
-	if err := git.CreateBranch(d.rootDir, branchName, "HEAD"); err != nil {
+	// Resolve the agent profile first so a bad profile tag fails cleanly.
+	profile, err := agent.Profile(d.cfg, agent.RoleWorker, agent.ProfileName(t.Tags))
+	if err != nil {
+		return "", err
+	}
+	workerCmd, err := agent.LaunchCommand(profile, agent.LaunchData{
+		AgentID: agentID, TaskID: t.ID, Role: agent.RoleWorker, Dir: worktreePath,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	// Create the branch from the configured base (see branches.base).
+	base, err := d.workerBase()
+	if err != nil {
+		return "", fmt.Errorf("resolve base: %w", err)
+	}
+	if err := git.CreateBranch(d.rootDir, branchName, base); err != nil {
 		return "", fmt.Errorf("create branch: %w", err)
 	}
 
@@ -814,20 +932,8 @@ func (d *Daemon) spawnWorker(t *task.Task) (string, error) {
 		return "", fmt.Errorf("create tmux session: %w", err)
 	}
 
-	// Start the worker process in the tmux session.
-	var workerCmd string
-	if d.workerCmdTemplate != "" {
-		// Custom worker command (e.g. mock script for integration tests).
-		// No exec: shell stays alive as the pane process.
-		workerCmd = fmt.Sprintf("cd %s && export ALT_AGENT_ID=%s && %s && exit 0", worktreePath, agentID, d.workerCmdTemplate)
-	} else {
-		// Claude Code: exec replaces the shell so pane PID = claude PID.
-		initialPrompt := fmt.Sprintf(
-			"Read task.json, then run alt help worker startup for full instructions. When finished, run: alt task-done %s %s",
-			t.ID, agentID,
-		)
-		workerCmd = fmt.Sprintf("cd %s && unset CLAUDECODE && export ALT_AGENT_ID=%s && exec claude --dangerously-skip-permissions %q", worktreePath, agentID, initialPrompt)
-	}
+	// Start the worker process in the tmux session. The launch command
+	// execs the agent, so the pane PID is the agent's PID.
 	if err := tmux.SendKeys(sessionName, workerCmd); err != nil {
 		_ = tmux.KillSession(sessionName)
 		cleanupGit()
@@ -835,7 +941,7 @@ func (d *Daemon) spawnWorker(t *task.Task) (string, error) {
 	}
 
 	// Give the exec a moment to replace the shell process, then read the pane PID.
-	time.Sleep(500 * time.Millisecond)
+	time.Sleep(time.Duration(d.cfg.Daemon.PIDSettleDelay))
 	panePID, err := tmux.PanePID(sessionName)
 	if err != nil {
 		d.logger.Warn("spawn: could not read pane PID", "session", sessionName, "error", err)
@@ -892,9 +998,11 @@ func (d *Daemon) spawnWorker(t *task.Task) (string, error) {
 
 // --- Step 4: ProcessMessages ---
 
-// processMessages reads pending messages addressed to the daemon and
-// dispatches them by type.
+// processMessages dead-letters undeliverable messages, then reads pending
+// messages addressed to the daemon and dispatches them by type.
 func (d *Daemon) processMessages(tickEvents *[]events.Event) {
+	d.sweepMessages()
+
 	msgs, err := d.messages.ListPending("daemon")
 	if err != nil {
 		d.logger.Error("messages: list pending", "error", err)
@@ -905,7 +1013,14 @@ func (d *Daemon) processMessages(tickEvents *[]events.Event) {
 		var ok bool
 		switch msg.Type {
 		case message.TypeTaskDone:
-			ok = d.handleTaskDone(msg, tickEvents)
+			// A payload that does not decode never will, so it is
+			// dead-lettered at once where alt message dlq shows it.
+			var payload message.TaskDonePayload
+			if err := msg.DecodePayload(&payload); err != nil {
+				d.deadLetter(msg, fmt.Sprintf("malformed payload: %v", err))
+				continue
+			}
+			ok = d.handleTaskDone(msg, payload, tickEvents)
 		case message.TypeHelp:
 			d.handleHelp(msg)
 			ok = true
@@ -915,19 +1030,111 @@ func (d *Daemon) processMessages(tickEvents *[]events.Event) {
 		}
 
 		// Only archive if processing succeeded; failed messages are
-		// retried on the next tick.
+		// retried on the next tick until daemon.max_message_attempts is reached.
 		if ok {
-			if err := d.messages.Archive(msg.ID); err != nil {
+			delete(d.groupRetries, msg.ID)
+			if err := d.messages.Ack(msg.ID, "daemon"); err != nil {
 				d.logger.Error("messages: archive", "message", msg.ID, "error", err)
 			}
+			continue
+		}
+		d.recordMessageFailure(msg)
+	}
+}
+
+// recordMessageFailure bumps a failed message's attempt count and moves it
+// to the dead-letter directory once daemon.max_message_attempts is reached.
+// A group message is never dead-lettered, since that would take it from
+// every recipient; the daemon gives up on its own copy by acking it.
+func (d *Daemon) recordMessageFailure(msg *message.Message) {
+	if msg.IsGroup() {
+		d.groupRetries[msg.ID]++
+		attempts := d.groupRetries[msg.ID]
+		if attempts < d.cfg.Daemon.MaxMessageAttempts {
+			return
+		}
+		delete(d.groupRetries, msg.ID)
+		d.logger.Warn("messages: giving up on group message", "message", msg.ID, "type", msg.Type, "attempts", attempts)
+		if err := d.messages.Ack(msg.ID, "daemon"); err != nil {
+			d.logger.Error("messages: ack", "message", msg.ID, "error", err)
+			return
 		}
+		d.recordError(fmt.Sprintf("message %s dropped after %d failed attempts", msg.ID, attempts))
+		return
+	}
+
+	updated, err := d.messages.RecordFailure(msg.ID, fmt.Sprintf("%s handler failed", msg.Type))
+	if err != nil {
+		d.logger.Error("messages: record failure", "message", msg.ID, "error", err)
+		return
+	}
+	if updated.Attempts < d.cfg.Daemon.MaxMessageAttempts {
+		return
 	}
+	d.deadLetter(msg, fmt.Sprintf("max retries exceeded (%d attempts)", updated.Attempts))
 }
 
-// handleTaskDone processes a task_done message by marking the task as done
-// and adding it to the merge queue. Returns true if the message was fully
-// processed and can be archived, false if it should be retried next tick.
-func (d *Daemon) handleTaskDone(msg *message.Message, tickEvents *[]events.Event) bool {
+// deadLetter moves a daemon message the daemon cannot handle to the
+// dead-letter directory and records why.
+func (d *Daemon) deadLetter(msg *message.Message, reason string) {
+	d.logger.Warn("messages: dead-lettering", "message", msg.ID, "type", msg.Type, "reason", reason)
+	if err := d.messages.DeadLetter(msg.ID, reason); err != nil {
+		d.logger.Error("messages: dead-letter", "message", msg.ID, "error", err)
+		return
+	}
+	d.recordError(fmt.Sprintf("message %s dead-lettered: %s", msg.ID, reason))
+}
+
+// sweepMessages moves expired messages and direct messages addressed to
+// dead or unknown agents to the dead-letter directory, and archives group messages
+// whose remaining recipients have died, so they stop accumulating.
+func (d *Daemon) sweepMessages() {
+	expired, err := d.messages.ExpireStale(time.Now())
+	if err != nil {
+		d.logger.Error("messages: expire stale", "error", err)
+	}
+	for _, m := range expired {
+		d.logger.Info("messages: expired", "message", m.ID, "type", m.Type, "to", m.To)
+	}
+
+	retired, err := d.messages.RetireDelivered()
+	if err != nil {
+		d.logger.Error("messages: retire group messages", "error", err)
+	}
+	for _, m := range retired {
+		d.logger.Info("messages: group message delivered", "message", m.ID, "to", m.To)
+	}
+
+	pending, err := d.messages.List()
+	if err != nil {
+		d.logger.Error("messages: list all", "error", err)
+		return
+	}
+	for _, m := range pending {
+		if m.IsGroup() || m.To == "daemon" {
+			continue
+		}
+		var reason string
+		switch a, err := d.agents.Get(m.To); {
+		case errors.Is(err, agent.ErrNotFound):
+			reason = "unknown recipient"
+		case err == nil && a.Status == agent.StatusDead:
+			reason = "recipient " + m.To + " is dead"
+		default:
+			continue
+		}
+		d.logger.Info("messages: undeliverable, dead-lettering", "message", m.ID, "to", m.To, "reason", reason)
+		if err := d.messages.DeadLetter(m.ID, reason); err != nil {
+			d.logger.Error("messages: dead-letter", "message", m.ID, "error", err)
+		}
+	}
+}
+
+// handleTaskDone processes a task_done message, with its decoded payload,
+// by marking the task as done and adding it to the merge queue. Returns
+// true if the message was fully processed and can be archived, false if it
+// should be retried next tick.
+func (d *Daemon) handleTaskDone(msg *message.Message, payload message.TaskDonePayload, tickEvents *[]events.Event) bool {
 	taskID := msg.TaskID
 	if taskID == "" {
 		d.logger.Info("messages: task_done without task_id", "from", msg.From)
@@ -944,8 +1151,8 @@ func (d *Daemon) handleTaskDone(msg *message.Message, tickEvents *[]events.Event
 	}
 
 	t.Status = task.StatusDone
-	if result, ok := msg.Payload["result"].(string); ok {
-		t.Result = result
+	if payload.Result != "" {
+		t.Result = payload.Result
 	}
 	t.UpdatedAt = time.Now().UTC()
 	if err := d.tasks.ForceWrite(t); err != nil {
@@ -1083,22 +1290,23 @@ func (d *Daemon) processMergeQueue(tickEvents *[]events.Event) {
 			})
 
 			// If we've exhausted resolver attempts, escalate to liaison.
-			if item.ResolveAttempts >= 3 {
+			if item.ResolveAttempts >= d.cfg.Daemon.MaxResolverAttempts {
 				d.logger.Warn("merge: resolver retry limit reached, escalating", "task", item.TaskID, "attempts", item.ResolveAttempts)
 				liaisons, lErr := d.agents.ListByRole(agent.RoleLiaison)
 				if lErr == nil && len(liaisons) > 0 {
-					_, _ = d.messages.Create(
-						message.TypeHelp,
+					_, _ = d.messages.Send(
 						"daemon",
 						liaisons[0].ID,
 						item.TaskID,
-						map[string]any{
-							"message":          fmt.Sprintf("merge conflicts for task %s could not be resolved after %d attempts", item.TaskID, item.ResolveAttempts),
-							"conflicts":        result.Conflicts,
-							"resolve_attempts": item.ResolveAttempts,
+						message.HelpPayload{
+							Message:         fmt.Sprintf("merge conflicts for task %s could not be resolved after %d attempts", item.TaskID, item.ResolveAttempts),
+							Conflicts:       result.Conflicts,
+							ResolveAttempts: item.ResolveAttempts,
 						},
+						message.WithPriority(message.PriorityHigh),
 					)
 				}
+				d.metrics.resolverAttempts.Inc("exhausted")
 				_ = os.Remove(itemPath)
 				continue
 			}
@@ -1109,18 +1317,21 @@ func (d *Daemon) processMergeQueue(tickEvents *[]events.Event) {
 			resolverAgent, err := d.resolverMgr.SpawnResolver(ctx)
 			if err != nil {
 				d.logger.Error("merge: spawn resolver", "task", item.TaskID, "error", err)
+				d.metrics.resolverAttempts.Inc("failed")
 				// Fall back to notifying the original agent.
-				_, _ = d.messages.Create(
-					message.TypeMergeResult,
+				_, _ = d.messages.Send(
 					"daemon",
 					item.AgentID,
 					item.TaskID,
-					map[string]any{
-						"success":   false,
-						"conflicts": result.Conflicts,
+					message.MergeResultPayload{
+						Success:   false,
+						Conflicts: result.Conflicts,
 					},
 				)
 			} else {
+				// The resolver manager logs its own agent_spawned event.
+				d.metrics.resolverAttempts.Inc("spawned")
+				d.metrics.spawns.Inc(string(agent.RoleResolver))
 				d.logger.Info("merge: spawned resolver", "resolver", resolverAgent.ID, "task", item.TaskID, "attempt", item.ResolveAttempts+1)
 			}
 
@@ -1148,12 +1359,11 @@ func (d *Daemon) processMergeQueue(tickEvents *[]events.Event) {
 		}
 
 		// Send success notification.
-		_, _ = d.messages.Create(
-			message.TypeMergeResult,
+		_, _ = d.messages.Send(
 			"daemon",
 			item.AgentID,
 			item.TaskID,
-			map[string]any{"success": true},
+			message.MergeResultPayload{Success: true},
 		)
 
 		// Notify worker that merge is complete — it should exit.
@@ -1229,6 +1439,7 @@ func (d *Daemon) checkResolvers(tickEvents *[]events.Event) {
 
 		// Clean up the resolver agent (tmux, worktree, agent record).
 		// The branch is preserved until after re-merge succeeds.
+		d.collectUsage(r, tickEvents)
 		if err := d.resolverMgr.CleanupResolver(r); err != nil {
 			d.logger.Error("resolvers: cleanup", "resolver", r.ID, "error", err)
 		}
@@ -1300,20 +1511,85 @@ func (d *Daemon) enqueueMergeItem(item MergeItem) error {
 	return atomicWrite(path, data)
 }
 
+// --- Step 5c: RecordUsage ---
+
+// recordUsage reads new token usage from active agents' Claude Code
+// transcripts and records it as token_usage events, so the budget ceiling
+// sees spend even from agents that never run alt report-usage.
+func (d *Daemon) recordUsage(tickEvents *[]events.Event) {
+	active, err := d.agents.ListByStatus(agent.StatusActive)
+	if err != nil {
+		d.logger.Error("usage: list active agents", "error", err)
+		return
+	}
+	for _, a := range active {
+		d.collectUsage(a, tickEvents)
+	}
+}
+
+// collectUsage records the usage added to one agent's transcripts since
+// the last collection, priced with the configured table.
+func (d *Daemon) collectUsage(a *agent.Agent, tickEvents *[]events.Event) {
+	if d.reportsOwnUsage(a.ID) {
+		return
+	}
+	usages, err := d.usage.Collect(a.ID, a.SessionDir)
+	if err != nil {
+		d.logger.Error("usage: read transcript", "agent", a.ID, "error", err)
+		return
+	}
+	prices := usage.NewPrices(d.cfg.Pricing)
+	for _, u := range usages {
+		cost, ok := prices.Cost(u)
+		if !ok {
+			d.logger.Warn("usage: no price for model, recording zero cost", "agent", a.ID, "model", u.Model)
+		}
+		*tickEvents = append(*tickEvents, usage.NewEvent(a.ID, a.CurrentTask, u, cost, usage.SourceTranscript))
+	}
+}
+
+// reportsOwnUsage reports whether the agent has recorded usage itself with
+// alt report-usage. Its transcript is then skipped so spend is not counted
+// twice.
+func (d *Daemon) reportsOwnUsage(agentID string) bool {
+	if d.selfReported[agentID] {
+		return true
+	}
+	evts, err := d.evReader.Read(events.Filter{
+		Type:    events.TokenUsage,
+		AgentID: agentID,
+		Data:    map[string]string{"source": usage.SourceAgent},
+	})
+	if err != nil || len(evts) == 0 {
+		return false
+	}
+	d.selfReported[agentID] = true
+	return true
+}
+
 // --- Step 6: CheckConstraints ---
 
 // checkConstraints checks budget ceiling, max workers, and queue depth.
-// If any constraint is violated, it emits an event.
+// When a budget is first exceeded it pauses assignment and emits an event;
+// later ticks stay quiet until the budget is available again.
 func (d *Daemon) checkConstraints(tickEvents *[]events.Event) {
 	if ok, reason, err := d.checker.CheckBudget(); err != nil {
 		d.logger.Error("constraints: budget check", "error", err)
-	} else if !ok {
-		d.logger.Info("constraints: budget exceeded", "reason", reason)
-		*tickEvents = append(*tickEvents, events.Event{
-			Timestamp: time.Now(),
-			Type:      events.BudgetExceeded,
-			Data:      map[string]any{"reason": reason},
-		})
+	} else if ok {
+		if d.budgetPaused != "" {
+			d.logger.Info("constraints: budget available, assignment resumed")
+			d.budgetPaused = ""
+		}
+	} else {
+		if d.budgetPaused == "" {
+			d.logger.Warn("constraints: budget exceeded, assignment paused", "reason", reason)
+			*tickEvents = append(*tickEvents, events.Event{
+				Timestamp: time.Now(),
+				Type:      events.BudgetExceeded,
+				Data:      map[string]any{"reason": reason},
+			})
+		}
+		d.budgetPaused = reason
 	}
 }
 
@@ -1324,6 +1600,7 @@ func (d *Daemon) emitEvents(tickEvents []events.Event) {
 	if len(tickEvents) == 0 {
 		return
 	}
+	d.metrics.observeEvents(tickEvents)
 	if err := d.events.Append(tickEvents...); err != nil {
 		d.logger.Error("events: append", "error", err)
 	}
@@ -1361,6 +1638,11 @@ func (d *Daemon) writeState() {
 		LastSpawnTask:  d.lastSpawnTask,
 		LastSpawnError: d.lastSpawnError,
 		RecentErrors:   d.recentErrors,
+		BudgetPaused:   d.budgetPaused,
+		SpawnBlocked:   d.spawnBlocked,
+	}
+	if host, err := d.checker.HostStats(); err == nil {
+		state.Host = host
 	}
 
 	data, err := json.MarshalIndent(state, "", "  ")
@@ -1419,10 +1701,18 @@ func (d *Daemon) releaseLock() {
 // shutdown. The handler finishes the current tick before exiting.
 func (d *Daemon) installSignalHandler() {
 	sigCh := make(chan os.Signal, 2)
-	signal.Notify(sigCh, syscall.SIGTERM, syscall.SIGINT, syscall.SIGUSR1)
+	signal.Notify(sigCh, syscall.SIGTERM, syscall.SIGINT, syscall.SIGUSR1, syscall.SIGHUP)
 	go func() {
 		for {
 			sig := <-sigCh
+			if sig == syscall.SIGHUP {
+				d.logger.Info("received SIGHUP, reloading config")
+				select {
+				case d.reload <- struct{}{}:
+				default:
+				}
+				continue
+			}
 			if sig == syscall.SIGUSR1 {
 				d.logger.Info("received SIGUSR1, forcing tick")
 				// Non-blocking send: at most one forced tick queued.
@@ -1436,10 +1726,10 @@ func (d *Daemon) installSignalHandler() {
 			d.logger.Info("received signal, shutting down gracefully", "signal", sig)
 			d.Stop()
 
-			// After shutdown, ignore SIGUSR1 while waiting for force-exit.
+			// After shutdown, ignore SIGUSR1 and SIGHUP while waiting for force-exit.
 			for {
 				sig = <-sigCh
-				if sig == syscall.SIGUSR1 {
+				if sig == syscall.SIGUSR1 || sig == syscall.SIGHUP {
 					continue
 				}
 				d.logger.Error("received second signal, forcing exit", "signal", sig)
@@ -1460,13 +1750,16 @@ type Status struct {
 // DaemonState represents the daemon's internal state, written to
 // .alt/daemon-state.json each tick for observability.
 type DaemonState struct {
-	LastTick       time.Time `json:"last_tick"`
-	TickNum        int64     `json:"tick_num"`
-	ActiveWorkers  int       `json:"active_workers"`
-	DeadWorkers    int       `json:"dead_workers"`
-	LastSpawnTask  string    `json:"last_spawn_task,omitempty"`
-	LastSpawnError string    `json:"last_spawn_error,omitempty"`
-	RecentErrors   []string  `json:"recent_errors,omitempty"`
+	LastTick       time.Time             `json:"last_tick"`
+	TickNum        int64                 `json:"tick_num"`
+	ActiveWorkers  int                   `json:"active_workers"`
+	DeadWorkers    int                   `json:"dead_workers"`
+	LastSpawnTask  string                `json:"last_spawn_task,omitempty"`
+	LastSpawnError string                `json:"last_spawn_error,omitempty"`
+	RecentErrors   []string              `json:"recent_errors,omitempty"`
+	BudgetPaused   string                `json:"budget_paused,omitempty"` // why assignment is paused on budget
+	SpawnBlocked   string                `json:"spawn_blocked,omitempty"` // why the last assignment pass could not spawn
+	Host           constraints.HostStats `json:"host,omitzero"`
 }
 
 // ReadState reads the daemon state file from .alt/daemon-state.json.
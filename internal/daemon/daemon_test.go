@@ -524,6 +524,58 @@ func TestCheckAgentLiveness_DeadTimeout(t *testing.T) {
 	}
 }
 
+func TestCheckAgentLiveness_ConfiguredTimeouts(t *testing.T) {
+	root := setupTestProject(t)
+	altDir := filepath.Join(root, ".alt")
+	for key, value := range map[string]string{
+		"daemon.heartbeat_warn":     "30s",
+		"daemon.heartbeat_critical": "1m",
+		"daemon.heartbeat_dead":     "90s",
+	} {
+		if err := config.SetProject(altDir, key, value); err != nil {
+			t.Fatal(err)
+		}
+	}
+	d, err := New(root)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	// Two minutes stale is only a warning by default, but dead here.
+	a := &agent.Agent{
+		ID:        "short-timeout",
+		Role:      agent.RoleWorker,
+		Status:    agent.StatusActive,
+		PID:       os.Getpid(),
+		Heartbeat: time.Now().Add(-2 * time.Minute),
+		StartedAt: time.Now().Add(-30 * time.Minute),
+	}
+	if err := d.agents.Create(a); err != nil {
+		t.Fatalf("create agent: %v", err)
+	}
+
+	var tickEvents []events.Event
+	d.checkAgentLiveness(&tickEvents)
+
+	updated, err := d.agents.Get("short-timeout")
+	if err != nil {
+		t.Fatalf("get agent: %v", err)
+	}
+	if updated.Status != agent.StatusDead {
+		t.Errorf("agent status = %q, want %q", updated.Status, agent.StatusDead)
+	}
+}
+
+func TestNew_InvalidDaemonConfig(t *testing.T) {
+	root := setupTestProject(t)
+	if err := config.SetProject(filepath.Join(root, ".alt"), "daemon.heartbeat_dead", "1m"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := New(root); err == nil || !strings.Contains(err.Error(), "heartbeat") {
+		t.Errorf("New with dead < critical = %v, want heartbeat error", err)
+	}
+}
+
 func TestCheckAgentLiveness_HeartbeatRecovery(t *testing.T) {
 	root := setupTestProject(t)
 	d, err := New(root)
@@ -838,6 +890,168 @@ func TestProcessMessages_TaskDone_FailedNotArchived(t *testing.T) {
 	}
 }
 
+func TestProcessMessages_TaskDone_UnknownFieldDeadLettered(t *testing.T) {
+	root := setupTestProject(t)
+	d, err := New(root)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	tk := &task.Task{Title: "test task", Status: task.StatusAssigned, AssignedTo: "worker-1", Branch: "worker/worker-1"}
+	if err := d.tasks.Create(tk); err != nil {
+		t.Fatalf("create task: %v", err)
+	}
+
+	// Written by a newer worker: Create would reject the extra field, so
+	// the file is written directly.
+	msg := &message.Message{
+		ID: "m-0123456789abcdef", Type: message.TypeTaskDone, From: "worker-1", To: "daemon", TaskID: tk.ID,
+		Payload:   map[string]any{"result": "done", "tests_passed": true},
+		CreatedAt: time.Now().UTC(),
+	}
+	data, _ := json.Marshal(msg)
+	name := fmt.Sprintf("%d-%s-%s.json", msg.CreatedAt.UnixNano(), msg.Type, msg.ID)
+	if err := os.WriteFile(filepath.Join(d.altDir, "messages", name), data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	var tickEvents []events.Event
+	d.processMessages(&tickEvents)
+
+	dead, err := d.messages.GetDeadLetter(msg.ID)
+	if err != nil {
+		t.Fatalf("GetDeadLetter: %v (task_done archived as handled?)", err)
+	}
+	if !strings.Contains(dead.DeadReason, "tests_passed") {
+		t.Errorf("DeadReason = %q, want the decode error", dead.DeadReason)
+	}
+	if updated, _ := d.tasks.Get(tk.ID); updated.Status != task.StatusAssigned {
+		t.Errorf("task status = %q, want %q", updated.Status, task.StatusAssigned)
+	}
+	if len(tickEvents) != 0 {
+		t.Errorf("tick events = %v, want none", tickEvents)
+	}
+}
+
+func TestProcessMessages_DeadLetterAfterMaxAttempts(t *testing.T) {
+	root := setupTestProject(t)
+	d, err := New(root)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	msg, err := d.messages.Create(message.TypeTaskDone, "worker-1", "daemon", "t-nonexistent", nil)
+	if err != nil {
+		t.Fatalf("create message: %v", err)
+	}
+
+	for i := 0; i < d.cfg.Daemon.MaxMessageAttempts; i++ {
+		var tickEvents []events.Event
+		d.processMessages(&tickEvents)
+	}
+
+	pending, _ := d.messages.ListPending("daemon")
+	if len(pending) != 0 {
+		t.Errorf("pending daemon messages = %d, want 0 after %d failures", len(pending), d.cfg.Daemon.MaxMessageAttempts)
+	}
+	dead, err := d.messages.GetDeadLetter(msg.ID)
+	if err != nil {
+		t.Fatalf("GetDeadLetter: %v", err)
+	}
+	if dead.Attempts != d.cfg.Daemon.MaxMessageAttempts {
+		t.Errorf("Attempts = %d, want %d", dead.Attempts, d.cfg.Daemon.MaxMessageAttempts)
+	}
+	if dead.DeadReason == "" {
+		t.Error("DeadReason is empty")
+	}
+}
+
+func TestProcessMessages_DeadRecipientDeadLettered(t *testing.T) {
+	root := setupTestProject(t)
+	d, err := New(root)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	if err := d.agents.Create(&agent.Agent{ID: "w-gone", Role: agent.RoleWorker, Status: agent.StatusDead}); err != nil {
+		t.Fatalf("create agent: %v", err)
+	}
+	msg, err := d.messages.Create(message.TypeMergeResult, "daemon", "w-gone", "t-1", nil)
+	if err != nil {
+		t.Fatalf("create message: %v", err)
+	}
+
+	var tickEvents []events.Event
+	d.processMessages(&tickEvents)
+
+	if _, err := d.messages.Get(msg.ID); err != message.ErrNotFound {
+		t.Errorf("message still pending (err = %v)", err)
+	}
+	if _, err := d.messages.GetDeadLetter(msg.ID); err != nil {
+		t.Errorf("GetDeadLetter: %v", err)
+	}
+}
+
+func TestProcessMessages_UnknownRecipientDeadLettered(t *testing.T) {
+	root := setupTestProject(t)
+	d, err := New(root)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	msg, err := d.messages.Create(message.TypeMergeResult, "daemon", "w-deleted", "t-1", nil)
+	if err != nil {
+		t.Fatalf("create message: %v", err)
+	}
+
+	var tickEvents []events.Event
+	d.processMessages(&tickEvents)
+
+	dead, err := d.messages.GetDeadLetter(msg.ID)
+	if err != nil {
+		t.Fatalf("GetDeadLetter: %v", err)
+	}
+	if dead.DeadReason != "unknown recipient" {
+		t.Errorf("DeadReason = %q, want unknown recipient", dead.DeadReason)
+	}
+}
+
+func TestProcessMessages_GroupFailureKeptForOthers(t *testing.T) {
+	root := setupTestProject(t)
+	d, err := New(root)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	// A broadcast task_done the daemon cannot handle, also sent to a worker.
+	other := &agent.Agent{ID: "w-other", Role: agent.RoleWorker, Status: agent.StatusActive, StartedAt: time.Now().Add(-time.Minute)}
+	if err := d.agents.Create(other); err != nil {
+		t.Fatalf("create agent: %v", err)
+	}
+	msg, err := d.messages.Create(message.TypeTaskDone, "worker-1", message.AddrBroadcast, "t-nonexistent", nil)
+	if err != nil {
+		t.Fatalf("create message: %v", err)
+	}
+
+	for i := 0; i < d.cfg.Daemon.MaxMessageAttempts; i++ {
+		var tickEvents []events.Event
+		d.processMessages(&tickEvents)
+	}
+
+	if pending, _ := d.messages.ListPending("daemon"); len(pending) != 0 {
+		t.Errorf("pending daemon messages = %d, want 0 after %d failures", len(pending), d.cfg.Daemon.MaxMessageAttempts)
+	}
+	if _, err := d.messages.GetDeadLetter(msg.ID); err == nil {
+		t.Error("group message dead-lettered for every recipient")
+	}
+	if pending, _ := d.messages.ListPending("w-other"); len(pending) != 1 {
+		t.Errorf("other recipient has %d pending, want the broadcast", len(pending))
+	}
+	if m, err := d.messages.Get(msg.ID); err != nil || m.Attempts != 0 {
+		t.Errorf("shared message = %+v, %v; want no recorded attempts", m, err)
+	}
+}
+
 func TestProcessMessages_Help(t *testing.T) {
 	root := setupTestProject(t)
 	d, err := New(root)
@@ -1202,6 +1416,42 @@ func TestReconcileAgents_LivePreserved(t *testing.T) {
 	}
 }
 
+func TestReconcileAgents_UsesConfiguredDeadThreshold(t *testing.T) {
+	root := setupTestProject(t)
+	d, err := New(root)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	d.cfg.Daemon.HeartbeatDead = config.Duration(time.Hour)
+
+	// A live worker in a long build, quiet for longer than the default
+	// thresholds but within the configured one.
+	for id, quiet := range map[string]time.Duration{"building": 20 * time.Minute, "silent": 2 * time.Hour} {
+		if err := d.agents.Create(&agent.Agent{
+			ID:        id,
+			Role:      agent.RoleWorker,
+			Status:    agent.StatusActive,
+			PID:       os.Getpid(),
+			Heartbeat: time.Now().Add(-quiet),
+			StartedAt: time.Now().Add(-3 * time.Hour),
+		}); err != nil {
+			t.Fatalf("create agent: %v", err)
+		}
+	}
+
+	d.reconcileAgents()
+
+	for id, want := range map[string]agent.Status{"building": agent.StatusActive, "silent": agent.StatusDead} {
+		a, err := d.agents.Get(id)
+		if err != nil {
+			t.Fatalf("get agent: %v", err)
+		}
+		if a.Status != want {
+			t.Errorf("%s status = %q, want %q", id, a.Status, want)
+		}
+	}
+}
+
 func TestReconcileTasks_ReclaimFromDeadAgent(t *testing.T) {
 	root := setupTestProject(t)
 	d, err := New(root)
@@ -1745,6 +1995,23 @@ func TestNew_InvalidConstraints(t *testing.T) {
 	}
 }
 
+func TestNew_ValidatesWholeConfig(t *testing.T) {
+	root := setupTestProject(t)
+	altDir := filepath.Join(root, ".alt")
+
+	// A section reload validates but the per-section startup checks missed.
+	cfg := config.NewConfig()
+	cfg.Branches.Base = "upstream"
+	data, _ := json.MarshalIndent(cfg, "", "  ")
+	if err := os.WriteFile(filepath.Join(altDir, "config.json"), data, 0o644); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+
+	if _, err := New(root); err == nil || !strings.Contains(err.Error(), "branches.base") {
+		t.Fatalf("New err = %v, want invalid branches.base", err)
+	}
+}
+
 func TestNew_ZeroWorkers(t *testing.T) {
 	root := setupTestProject(t)
 	altDir := filepath.Join(root, ".alt")
@@ -1771,3 +2038,32 @@ func TestSendStop_NotRunning(t *testing.T) {
 		t.Fatal("SendStop: expected error when daemon not running")
 	}
 }
+
+func TestWriteStateReportsSpawnBlocked(t *testing.T) {
+	root := setupTestProject(t)
+	d, err := New(root)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	cfg := d.cfg.Constraints
+	cfg.MinFreeDiskMB = 1 << 40
+	d.checker.UpdateConstraints(cfg)
+	if err := d.tasks.Create(&task.Task{Title: "waits for disk"}); err != nil {
+		t.Fatal(err)
+	}
+
+	var tickEvents []events.Event
+	d.assignTasks(&tickEvents)
+	d.writeState()
+
+	state, err := ReadState(d.altDir)
+	if err != nil {
+		t.Fatalf("ReadState: %v", err)
+	}
+	if !strings.Contains(state.SpawnBlocked, "free disk too low") {
+		t.Errorf("SpawnBlocked = %q", state.SpawnBlocked)
+	}
+	if state.Host.FreeDiskMB == nil {
+		t.Error("Host.FreeDiskMB not recorded")
+	}
+}
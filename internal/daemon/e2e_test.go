@@ -654,6 +654,7 @@ func TestE2E_BudgetEnforcement(t *testing.T) {
 	// Override config with a very low budget.
 	altDir := filepath.Join(root, ".alt")
 	cfg := config.Config{
+		DefaultBranch: "main",
 		Constraints: config.Constraints{
 			BudgetCeiling: 5.0, // Very low.
 			MaxWorkers:    4,
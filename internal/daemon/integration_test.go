@@ -108,14 +108,16 @@ sleep 1
 	return path
 }
 
-// startDaemon creates a daemon with fast tick interval and mock worker
-// command, runs it in a goroutine, and registers cleanup.
+// startDaemon configures the worker agent profile to run the mock worker
+// script, creates a daemon with fast tick interval, runs it in a
+// goroutine, and registers cleanup.
 func startDaemon(t *testing.T, root, mockScript string) *Daemon {
 	t.Helper()
-	d, err := New(root,
-		WithTickInterval(200*time.Millisecond),
-		WithWorkerCommand(mockScript),
-	)
+	profiles, _ := json.Marshal(map[string]config.AgentProfile{"worker": {Command: mockScript}})
+	if err := config.SetProject(filepath.Join(root, config.DirName), "agents", string(profiles)); err != nil {
+		t.Fatalf("startDaemon: configure mock worker: %v", err)
+	}
+	d, err := New(root, WithTickInterval(200*time.Millisecond))
 	if err != nil {
 		t.Fatalf("startDaemon: %v", err)
 	}
@@ -339,6 +341,7 @@ func TestIntegration_MaxWorkersLimit(t *testing.T) {
 	// Set max_workers=2 before starting daemon.
 	altDir := filepath.Join(root, ".alt")
 	cfg := config.Config{
+		DefaultBranch: "main",
 		Constraints: config.Constraints{
 			BudgetCeiling: 100.0,
 			MaxWorkers:    2,
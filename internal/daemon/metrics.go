@@ -0,0 +1,153 @@
+package daemon
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/anthropics/altera/internal/agent"
+	"github.com/anthropics/altera/internal/events"
+	"github.com/anthropics/altera/internal/metrics"
+	"github.com/anthropics/altera/internal/task"
+)
+
+// daemonMetrics holds the series served on /metrics. Gauges are refreshed
+// from the agent, task and merge-queue stores at the end of each tick;
+// counters are fed from the events the tick emits and reset when the
+// daemon restarts, as Prometheus counters do.
+type daemonMetrics struct {
+	registry *metrics.Registry
+
+	agentsActive       *metrics.Gauge // role
+	queueDepth         *metrics.Gauge
+	tasks              *metrics.Gauge // status
+	budgetUsed         *metrics.Gauge
+	budgetCeiling      *metrics.Gauge
+	heartbeatStaleness *metrics.Gauge // agent, role
+
+	spawns           *metrics.Counter // role
+	spawnFailures    *metrics.Counter
+	merges           *metrics.Counter // outcome
+	resolverAttempts *metrics.Counter // result
+	tickDuration     *metrics.Histogram
+}
+
+func newDaemonMetrics() *daemonMetrics {
+	r := metrics.NewRegistry()
+	m := &daemonMetrics{
+		registry:           r,
+		agentsActive:       r.NewGauge("alt_agents_active", "Active agents by role.", "role"),
+		queueDepth:         r.NewGauge("alt_merge_queue_depth", "Items waiting in the merge queue."),
+		tasks:              r.NewGauge("alt_tasks", "Tasks by status.", "status"),
+		budgetUsed:         r.NewGauge("alt_budget_used", "Token cost recorded in the event log."),
+		budgetCeiling:      r.NewGauge("alt_budget_ceiling", "Configured constraints.budget_ceiling; absent when there is no lifetime cap."),
+		heartbeatStaleness: r.NewGauge("alt_agent_heartbeat_staleness_seconds", "Seconds since each active agent's last heartbeat.", "agent", "role"),
+		spawns:             r.NewCounter("alt_agent_spawns_total", "Agents spawned by role.", "role"),
+		spawnFailures:      r.NewCounter("alt_agent_spawn_failures_total", "Worker spawns that failed."),
+		merges:             r.NewCounter("alt_merges_total", "Merge attempts by outcome.", "outcome"),
+		resolverAttempts:   r.NewCounter("alt_resolver_attempts_total", "Conflict resolver spawn attempts by result.", "result"),
+		tickDuration:       r.NewHistogram("alt_daemon_tick_duration_seconds", "Duration of daemon ticks.", metrics.DefBuckets),
+	}
+	// Pre-create the known series so rate() works from the first scrape.
+	for _, role := range []agent.Role{agent.RoleWorker, agent.RoleResolver} {
+		m.spawns.Add(0, string(role))
+	}
+	m.spawnFailures.Add(0)
+	for _, outcome := range []string{"success", "conflict", "failed"} {
+		m.merges.Add(0, outcome)
+	}
+	for _, result := range []string{"spawned", "failed", "exhausted"} {
+		m.resolverAttempts.Add(0, result)
+	}
+	return m
+}
+
+// observeEvents updates counters from the events emitted by a tick.
+func (m *daemonMetrics) observeEvents(evts []events.Event) {
+	for _, ev := range evts {
+		switch ev.Type {
+		case events.AgentSpawned:
+			role, _ := ev.Data["role"].(string)
+			if role == "" {
+				role = string(agent.RoleWorker)
+			}
+			m.spawns.Inc(role)
+		case events.AgentSpawnFailed:
+			m.spawnFailures.Inc()
+		case events.MergeSuccess:
+			m.merges.Inc("success")
+		case events.MergeConflict:
+			m.merges.Inc("conflict")
+		case events.MergeFailed:
+			m.merges.Inc("failed")
+		}
+	}
+}
+
+// collectMetrics refreshes the gauges from the same stores writeState
+// reads.
+func (d *Daemon) collectMetrics() {
+	m := d.metrics
+
+	if active, err := d.agents.ListByStatus(agent.StatusActive); err == nil {
+		counts := map[agent.Role]int{agent.RoleWorker: 0, agent.RoleResolver: 0, agent.RoleLiaison: 0}
+		m.heartbeatStaleness.Reset()
+		for _, a := range active {
+			counts[a.Role]++
+			m.heartbeatStaleness.Set(agent.HeartbeatStaleness(a).Seconds(), a.ID, string(a.Role))
+		}
+		for role, n := range counts {
+			m.agentsActive.Set(float64(n), string(role))
+		}
+	}
+
+	if n, err := d.checker.QueueDepth(); err == nil {
+		m.queueDepth.Set(float64(n))
+	}
+
+	if all, err := d.tasks.List(task.Filter{}); err == nil {
+		counts := map[task.Status]int{
+			task.StatusOpen: 0, task.StatusAssigned: 0, task.StatusInProgress: 0,
+			task.StatusDone: 0, task.StatusFailed: 0,
+		}
+		for _, t := range all {
+			counts[t.Status]++
+		}
+		for status, n := range counts {
+			m.tasks.Set(float64(n), string(status))
+		}
+	}
+
+	if used, err := d.checker.BudgetUsed(); err == nil {
+		m.budgetUsed.Set(used)
+	}
+	if ceiling := d.cfg.Constraints.BudgetCeiling; ceiling > 0 {
+		m.budgetCeiling.Set(ceiling)
+	} else {
+		m.budgetCeiling.Reset() // no lifetime cap
+	}
+}
+
+// serveMetrics serves /metrics on addr until ctx is cancelled. A listen
+// failure is logged rather than fatal: metrics are optional.
+func (d *Daemon) serveMetrics(ctx context.Context, addr string) {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		d.logger.Error("metrics: listen", "addr", addr, "error", err)
+		return
+	}
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", d.metrics.registry.Handler())
+	srv := &http.Server{Handler: mux, ReadHeaderTimeout: 5 * time.Second}
+	go func() {
+		<-ctx.Done()
+		_ = srv.Close()
+	}()
+
+	d.logger.Info("metrics: serving", "addr", ln.Addr().String())
+	if err := srv.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		d.logger.Error("metrics: serve", "error", err)
+	}
+}
@@ -0,0 +1,133 @@
+package daemon
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/anthropics/altera/internal/agent"
+	"github.com/anthropics/altera/internal/events"
+	"github.com/anthropics/altera/internal/task"
+)
+
+func scrape(t *testing.T, d *Daemon) string {
+	t.Helper()
+	var b strings.Builder
+	if err := d.metrics.registry.WriteText(&b); err != nil {
+		t.Fatalf("WriteText: %v", err)
+	}
+	return b.String()
+}
+
+func TestCollectMetrics(t *testing.T) {
+	root := setupTestProject(t)
+	d, err := New(root)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	for _, a := range []*agent.Agent{
+		{ID: "w-1", Role: agent.RoleWorker, Status: agent.StatusActive, Heartbeat: time.Now().Add(-90 * time.Second)},
+		{ID: "w-2", Role: agent.RoleWorker, Status: agent.StatusDead, Heartbeat: time.Now()},
+		{ID: "liaison-01", Role: agent.RoleLiaison, Status: agent.StatusActive, Heartbeat: time.Now()},
+	} {
+		if err := d.agents.Create(a); err != nil {
+			t.Fatalf("create agent: %v", err)
+		}
+	}
+	if err := d.tasks.Create(&task.Task{Title: "open task"}); err != nil {
+		t.Fatalf("create task: %v", err)
+	}
+	if err := d.addToMergeQueueWithBranch("t-1", "worker/w-1", "w-1", 0); err != nil {
+		t.Fatalf("enqueue: %v", err)
+	}
+
+	d.emitEvents([]events.Event{
+		{Timestamp: time.Now(), Type: events.AgentSpawned, AgentID: "w-1", TaskID: "t-1"},
+		{Timestamp: time.Now(), Type: events.AgentSpawnFailed, TaskID: "t-2"},
+		{Timestamp: time.Now(), Type: events.MergeConflict, TaskID: "t-1"},
+		{Timestamp: time.Now(), Type: events.MergeSuccess, TaskID: "t-1"},
+		{Timestamp: time.Now(), Type: events.BudgetExceeded, Data: map[string]any{"token_cost": 12.5}},
+	})
+	d.collectMetrics()
+	d.metrics.tickDuration.Observe(0.2)
+
+	out := scrape(t, d)
+	for _, want := range []string{
+		`alt_agents_active{role="worker"} 1`,
+		`alt_agents_active{role="liaison"} 1`,
+		`alt_agents_active{role="resolver"} 0`,
+		`alt_merge_queue_depth 1`,
+		`alt_tasks{status="open"} 1`,
+		`alt_tasks{status="done"} 0`,
+		`alt_budget_used 12.5`,
+		`alt_budget_ceiling 100`,
+		`alt_agent_spawns_total{role="worker"} 1`,
+		`alt_agent_spawns_total{role="resolver"} 0`,
+		`alt_agent_spawn_failures_total 1`,
+		`alt_merges_total{outcome="conflict"} 1`,
+		`alt_merges_total{outcome="success"} 1`,
+		`alt_merges_total{outcome="failed"} 0`,
+		`alt_daemon_tick_duration_seconds_count 1`,
+	} {
+		if !strings.Contains(out, want+"\n") {
+			t.Errorf("metrics missing %q", want)
+		}
+	}
+	if !strings.Contains(out, `alt_agent_heartbeat_staleness_seconds{agent="w-1",role="worker"} 9`) {
+		t.Errorf("missing staleness for w-1:\n%s", out)
+	}
+	if strings.Contains(out, `agent="w-2"`) {
+		t.Error("dead agents should not report heartbeat staleness")
+	}
+
+	// A ceiling of 0 is no lifetime cap, not a cap of 0.
+	d.cfg.Constraints.BudgetCeiling = 0
+	d.collectMetrics()
+	if out := scrape(t, d); strings.Contains(out, "\nalt_budget_ceiling ") {
+		t.Errorf("alt_budget_ceiling reported with no ceiling:\n%s", out)
+	}
+}
+
+func TestServeMetrics(t *testing.T) {
+	root := setupTestProject(t)
+	d, err := New(root)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	// Reserve a free port, then hand it to serveMetrics.
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := ln.Addr().String()
+	_ = ln.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() { d.serveMetrics(ctx, addr); close(done) }()
+	defer func() { cancel(); <-done }()
+
+	var resp *http.Response
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		resp, err = http.Get("http://" + addr + "/metrics")
+		if err == nil || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if err != nil {
+		t.Fatalf("GET /metrics: %v", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK || !strings.Contains(string(body), "# TYPE alt_merges_total counter") {
+		t.Errorf("status %d, body:\n%s", resp.StatusCode, body)
+	}
+}
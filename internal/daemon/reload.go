@@ -0,0 +1,131 @@
+package daemon
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/anthropics/altera/internal/config"
+	"github.com/anthropics/altera/internal/events"
+)
+
+// configPollInterval is how often the running daemon checks its config
+// files for changes between ticks.
+const configPollInterval = 2 * time.Second
+
+// liveKeys are the config keys, or key prefixes ending in ".", that take
+// effect without a restart. Everything else (repo, event log, sinks,
+// tracing, metrics) is read once in New.
+var liveKeys = []string{"test_command", "constraints.", "daemon.", "branches.", "pricing"}
+
+func isLiveKey(key string) bool {
+	for _, k := range liveKeys {
+		if key == k || strings.HasSuffix(k, ".") && strings.HasPrefix(key, k) {
+			return true
+		}
+	}
+	return false
+}
+
+// configStamp fingerprints the global and project config files by size and
+// modification time, so the daemon can tell when either has changed.
+func (d *Daemon) configStamp() string {
+	var b strings.Builder
+	for _, path := range []string{config.GlobalPath(), filepath.Join(d.altDir, "config.json")} {
+		if path == "" {
+			continue
+		}
+		if info, err := os.Stat(path); err == nil {
+			fmt.Fprintf(&b, "%s:%d:%d;", path, info.Size(), info.ModTime().UnixNano())
+		}
+	}
+	return b.String()
+}
+
+// reloadIfChanged reloads the config when a config file has changed since
+// it was last read.
+func (d *Daemon) reloadIfChanged() {
+	if d.configStamp() != d.cfgStamp {
+		d.reloadConfig("file")
+	}
+}
+
+// reloadConfig re-reads and validates the layered config. Live keys are
+// applied at once; changes to other keys are logged and wait for a
+// restart. An invalid config is rejected and the current one kept. Any
+// change is recorded as a config_reloaded event.
+func (d *Daemon) reloadConfig(trigger string) {
+	d.cfgStamp = d.configStamp()
+
+	cfg, err := config.Load(d.altDir)
+	if err == nil {
+		err = cfg.Validate()
+	}
+	if err != nil {
+		d.logger.Error("config: reload rejected, keeping current config", "trigger", trigger, "error", err)
+		d.recordError("config reload: " + err.Error())
+		return
+	}
+
+	next := d.cfg
+	next.TestCommand = cfg.TestCommand
+	next.Constraints = cfg.Constraints
+	next.Daemon = cfg.Daemon
+	next.Branches = cfg.Branches
+	next.Pricing = cfg.Pricing
+
+	changes := map[string]any{}
+	var pending []string
+	for _, c := range config.Diff(d.cfg, cfg) {
+		if !isLiveKey(c.Key) {
+			pending = append(pending, c.Key)
+			continue
+		}
+		changes[c.Key] = map[string]any{"old": c.Old, "new": c.New}
+		d.logger.Info("config: changed", "key", c.Key, "old", c.Old, "new", c.New)
+	}
+	if len(pending) > 0 {
+		d.logger.Warn("config: changes need a daemon restart", "keys", strings.Join(pending, ", "))
+	}
+
+	d.cfg = next
+	d.checker.UpdateConstraints(next.Constraints)
+	if !d.tickOverride {
+		d.tickInterval = time.Duration(next.Daemon.TickInterval)
+	}
+
+	if len(changes) == 0 && len(pending) == 0 {
+		d.logger.Info("config: reloaded, no changes", "trigger", trigger)
+		return
+	}
+	d.logger.Info("config: reloaded", "trigger", trigger, "applied", len(changes), "restart_required", len(pending))
+	data := map[string]any{"trigger": trigger, "changes": changes}
+	if len(pending) > 0 {
+		data["restart_required"] = pending
+	}
+	d.emitEvents([]events.Event{{
+		Timestamp: time.Now(),
+		Type:      events.ConfigReloaded,
+		Data:      data,
+	}})
+}
+
+// SendReload sends SIGHUP to a running daemon so it reloads its config.
+func SendReload(altDir string) error {
+	st := ReadStatus(altDir)
+	if !st.Running {
+		return fmt.Errorf("daemon is not running")
+	}
+
+	proc, err := os.FindProcess(st.PID)
+	if err != nil {
+		return fmt.Errorf("find daemon process: %w", err)
+	}
+	if err := proc.Signal(syscall.SIGHUP); err != nil {
+		return fmt.Errorf("send SIGHUP to daemon (pid %d): %w", st.PID, err)
+	}
+	return nil
+}
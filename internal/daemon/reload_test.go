@@ -0,0 +1,95 @@
+package daemon
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/anthropics/altera/internal/agent"
+	"github.com/anthropics/altera/internal/config"
+	"github.com/anthropics/altera/internal/events"
+)
+
+func TestReloadConfigAppliesLiveKeys(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	root := setupTestProject(t)
+	d, err := New(root)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	if err := d.agents.Create(&agent.Agent{ID: "w-1", Role: agent.RoleWorker, Status: agent.StatusActive,
+		Heartbeat: time.Now(), StartedAt: time.Now()}); err != nil {
+		t.Fatal(err)
+	}
+
+	d.reloadIfChanged()
+	if all, _ := events.NewReader(filepath.Join(d.altDir, "events.jsonl")).ReadAll(); len(all) != 0 {
+		t.Fatalf("reload without a change emitted %+v", all)
+	}
+
+	for key, value := range map[string]string{
+		"constraints.max_workers": "1",
+		"daemon.tick_interval":    "5s",
+		"test_command":            "make check",
+		"repo_path":               "/elsewhere",
+	} {
+		if err := config.SetProject(d.altDir, key, value); err != nil {
+			t.Fatal(err)
+		}
+	}
+	d.reloadConfig("sighup")
+
+	if d.cfg.Constraints.MaxWorkers != 1 || d.cfg.TestCommand != "make check" || d.tickInterval != 5*time.Second {
+		t.Errorf("live keys not applied: cfg=%+v tick=%s", d.cfg, d.tickInterval)
+	}
+	if d.cfg.RepoPath == "/elsewhere" {
+		t.Error("repo_path applied without a restart")
+	}
+	if ok, _, _ := d.checker.CheckMaxWorkers(); ok {
+		t.Error("checker still allows a second worker after max_workers=1")
+	}
+
+	all, err := events.NewReader(filepath.Join(d.altDir, "events.jsonl")).ReadAll()
+	if err != nil || len(all) != 1 || all[0].Type != events.ConfigReloaded {
+		t.Fatalf("events = %+v, %v", all, err)
+	}
+	changes, _ := all[0].Data["changes"].(map[string]any)
+	mw, _ := changes["constraints.max_workers"].(map[string]any)
+	if mw["new"] != "1" || all[0].Data["trigger"] != "sighup" {
+		t.Errorf("event data = %+v", all[0].Data)
+	}
+	if pending, _ := all[0].Data["restart_required"].([]any); len(pending) != 1 || pending[0] != "repo_path" {
+		t.Errorf("restart_required = %v", all[0].Data["restart_required"])
+	}
+}
+
+func TestReloadConfigRejectsInvalid(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	root := setupTestProject(t)
+	d, err := New(root)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	before := d.cfg.Constraints.MaxWorkers
+
+	if err := config.SetProject(d.altDir, "constraints.max_workers", "-3"); err != nil {
+		t.Fatal(err)
+	}
+	d.reloadIfChanged()
+	if d.cfg.Constraints.MaxWorkers != before {
+		t.Errorf("MaxWorkers = %d, want %d kept", d.cfg.Constraints.MaxWorkers, before)
+	}
+	if len(d.recentErrors) == 0 {
+		t.Error("rejected reload not recorded")
+	}
+
+	// An unparseable file is rejected the same way.
+	if err := os.WriteFile(filepath.Join(d.altDir, "config.json"), []byte("{"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	d.reloadIfChanged()
+	if d.cfg.Constraints.MaxWorkers != before {
+		t.Errorf("MaxWorkers = %d after bad JSON", d.cfg.Constraints.MaxWorkers)
+	}
+}
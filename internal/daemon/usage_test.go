@@ -0,0 +1,63 @@
+package daemon
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/anthropics/altera/internal/agent"
+	"github.com/anthropics/altera/internal/events"
+	"github.com/anthropics/altera/internal/usage"
+)
+
+func TestRecordUsage(t *testing.T) {
+	root := setupTestProject(t)
+	d, err := New(root)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	sessionDir := t.TempDir()
+	line := `{"type":"assistant","message":{"id":"msg_1","model":"claude-sonnet-4-5","usage":{"input_tokens":1000000,"output_tokens":0}}}` + "\n"
+	if err := os.WriteFile(filepath.Join(sessionDir, "s.jsonl"), []byte(line), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	for _, id := range []string{"w-1", "w-2"} {
+		a := &agent.Agent{ID: id, Role: agent.RoleWorker, Status: agent.StatusActive, CurrentTask: "t-" + id,
+			SessionDir: sessionDir, Heartbeat: time.Now(), StartedAt: time.Now()}
+		if err := d.agents.Create(a); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// w-2 reports its own usage, so its transcript is not counted.
+	if err := d.events.Append(usage.NewEvent("w-2", "t-w-2", usage.Usage{Model: "claude-sonnet-4-5", InputTokens: 1}, 0.01, usage.SourceAgent)); err != nil {
+		t.Fatal(err)
+	}
+
+	var tickEvents []events.Event
+	d.recordUsage(&tickEvents)
+	if len(tickEvents) != 1 {
+		t.Fatalf("got %d events, want 1: %+v", len(tickEvents), tickEvents)
+	}
+	ev := tickEvents[0]
+	if ev.Type != events.TokenUsage || ev.AgentID != "w-1" || ev.TaskID != "t-w-1" {
+		t.Errorf("event = %+v", ev)
+	}
+	if ev.Data["token_cost"] != 3.0 || ev.Data["source"] != usage.SourceTranscript {
+		t.Errorf("data = %v", ev.Data)
+	}
+
+	// The budget check sees the recorded cost.
+	d.emitEvents(tickEvents)
+	if used, err := d.checker.BudgetUsed(); err != nil || used != 3.01 {
+		t.Errorf("BudgetUsed = %v, %v; want 3.01", used, err)
+	}
+
+	// Already-read usage is not recorded again.
+	tickEvents = nil
+	d.recordUsage(&tickEvents)
+	if len(tickEvents) != 0 {
+		t.Errorf("second pass recorded %+v", tickEvents)
+	}
+}
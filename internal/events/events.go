@@ -26,11 +26,14 @@ const (
 	MergeSuccess   Type = "merge_success"
 	MergeConflict  Type = "merge_conflict"
 	MergeFailed    Type = "merge_failed"
+	BranchRebased  Type = "branch_rebased"
 	BudgetExceeded Type = "budget_exceeded"
+	TokenUsage     Type = "token_usage"
 	WorkerStalled  Type = "worker_stalled"
 	DaemonStarted    Type = "daemon_started"
 	DaemonShutdown   Type = "daemon_shutdown"
 	DaemonTickForced Type = "daemon_tick_forced"
+	ConfigReloaded   Type = "config_reloaded"
 )
 
 // Event represents a single event in the system log.
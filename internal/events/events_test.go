@@ -1,9 +1,12 @@
 package events
 
 import (
+	"context"
 	"encoding/json"
+	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"sync"
 	"testing"
 	"time"
@@ -639,3 +642,621 @@ func TestEventWithData(t *testing.T) {
 		t.Errorf("Data[retries]: got %v", got.Data["retries"])
 	}
 }
+
+// --- Rotation tests ---
+
+func TestRotationBySize(t *testing.T) {
+	path := tmpPath(t)
+	w := NewWriter(path, WithRotation(RotationPolicy{MaxBytes: 1}))
+
+	for i := 0; i < 3; i++ {
+		mustWrite(t, w, testEvent(TaskCreated, "", fmt.Sprintf("t-%d", i)))
+	}
+
+	segs, err := listSegments(path)
+	if err != nil {
+		t.Fatalf("listSegments: %v", err)
+	}
+	if len(segs) != 3 {
+		t.Fatalf("segments = %d, want 3", len(segs))
+	}
+
+	all, err := NewReader(path).ReadAll()
+	if err != nil {
+		t.Fatalf("ReadAll: %v", err)
+	}
+	if len(all) != 3 {
+		t.Fatalf("ReadAll = %d events, want 3", len(all))
+	}
+	for i, ev := range all {
+		if want := fmt.Sprintf("t-%d", i); ev.TaskID != want {
+			t.Errorf("event %d TaskID = %q, want %q (segments out of order)", i, ev.TaskID, want)
+		}
+	}
+}
+
+func TestRotationByAge(t *testing.T) {
+	path := tmpPath(t)
+	w := NewWriter(path, WithRotation(RotationPolicy{MaxAge: time.Hour}))
+
+	old := testEvent(TaskCreated, "", "t-old")
+	old.Timestamp = time.Now().Add(-2 * time.Hour)
+	mustWrite(t, w, old)
+
+	segs, _ := listSegments(path)
+	if len(segs) != 1 {
+		t.Fatalf("segments = %d, want 1 after appending an old event", len(segs))
+	}
+
+	mustWrite(t, w, testEvent(TaskCreated, "", "t-new"))
+	segs, _ = listSegments(path)
+	if len(segs) != 1 {
+		t.Errorf("segments = %d, want 1 (fresh event should stay active)", len(segs))
+	}
+}
+
+func TestRotationCompressed(t *testing.T) {
+	path := tmpPath(t)
+	w := NewWriter(path, WithRotation(RotationPolicy{MaxBytes: 1, Compress: true}))
+	mustWrite(t, w, testEvent(TaskCreated, "a", "t-1"))
+	mustWrite(t, w, testEvent(TaskDone, "a", "t-1"))
+
+	segs, _ := listSegments(path)
+	if len(segs) != 2 {
+		t.Fatalf("segments = %d, want 2", len(segs))
+	}
+	for _, s := range segs {
+		if filepath.Ext(s.path) != ".gz" {
+			t.Errorf("segment %s not compressed", s.path)
+		}
+	}
+
+	evts, err := NewReader(path).Read(Filter{Type: TaskDone})
+	if err != nil {
+		t.Fatalf("Read: %v", err)
+	}
+	if len(evts) != 1 {
+		t.Errorf("Read(TaskDone) = %d events, want 1", len(evts))
+	}
+}
+
+func TestRotateManual(t *testing.T) {
+	path := tmpPath(t)
+	w := NewWriter(path)
+
+	if err := w.Rotate(); err != nil {
+		t.Fatalf("Rotate on missing log: %v", err)
+	}
+	if segs, _ := listSegments(path); len(segs) != 0 {
+		t.Fatalf("empty log rotated into %d segments", len(segs))
+	}
+
+	mustWrite(t, w, testEvent(TaskCreated, "", "t-1"))
+	if err := w.Rotate(); err != nil {
+		t.Fatalf("Rotate: %v", err)
+	}
+	mustWrite(t, w, testEvent(TaskCreated, "", "t-2"))
+
+	infos, err := NewReader(path).Segments()
+	if err != nil {
+		t.Fatalf("Segments: %v", err)
+	}
+	if len(infos) != 1 || infos[0].Count != 1 || infos[0].Seq != 1 {
+		t.Fatalf("Segments = %+v, want one segment with one event", infos)
+	}
+	all, _ := NewReader(path).ReadAll()
+	if len(all) != 2 {
+		t.Errorf("ReadAll = %d events, want 2", len(all))
+	}
+}
+
+func TestSumDataUsesManifest(t *testing.T) {
+	path := tmpPath(t)
+	w := NewWriter(path, WithRotation(RotationPolicy{MaxBytes: 1, Compress: true}))
+	for _, cost := range []float64{1.5, 2.5} {
+		ev := testEvent(TaskDone, "a", "t-1")
+		ev.Data = map[string]any{"token_cost": cost}
+		mustWrite(t, w, ev)
+	}
+
+	// Remove the segments: the persisted aggregates must still count.
+	segs, _ := listSegments(path)
+	for _, s := range segs {
+		if err := os.Truncate(s.path, 0); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	active := NewWriter(path)
+	ev := testEvent(TaskDone, "a", "t-2")
+	ev.Data = map[string]any{"token_cost": 1.0}
+	mustWrite(t, active, ev)
+
+	got, err := NewReader(path).SumData("token_cost")
+	if err != nil {
+		t.Fatalf("SumData: %v", err)
+	}
+	if got != 5.0 {
+		t.Errorf("SumData = %v, want 5.0", got)
+	}
+}
+
+func TestSumDataScansSegmentMissingFromManifest(t *testing.T) {
+	path := tmpPath(t)
+	w := NewWriter(path)
+	ev := testEvent(TaskDone, "a", "t-1")
+	ev.Data = map[string]any{"token_cost": 2.0}
+	mustWrite(t, w, ev)
+	if err := w.Rotate(); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Remove(manifestPath(path)); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := NewReader(path).SumData("token_cost")
+	if err != nil {
+		t.Fatalf("SumData: %v", err)
+	}
+	if got != 2.0 {
+		t.Errorf("SumData = %v, want 2.0", got)
+	}
+}
+
+func TestReadSkipsSegmentsOutsideTimeRange(t *testing.T) {
+	path := tmpPath(t)
+	w := NewWriter(path)
+	old := testEvent(TaskCreated, "", "t-old")
+	old.Timestamp = time.Now().Add(-48 * time.Hour)
+	mustWrite(t, w, old)
+	if err := w.Rotate(); err != nil {
+		t.Fatal(err)
+	}
+	mustWrite(t, w, testEvent(TaskCreated, "", "t-new"))
+
+	files, err := NewReader(path).files(Filter{After: time.Now().Add(-time.Hour)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 1 || files[0].path != path {
+		t.Errorf("files = %v, want only the active log", files)
+	}
+}
+
+func TestConcurrentAppendWithRotation(t *testing.T) {
+	path := tmpPath(t)
+	const writers, perWriter = 8, 25
+
+	var wg sync.WaitGroup
+	for i := 0; i < writers; i++ {
+		wg.Add(1)
+		go func(id int) {
+			defer wg.Done()
+			w := NewWriter(path, WithRotation(RotationPolicy{MaxBytes: 2048}))
+			for j := 0; j < perWriter; j++ {
+				_ = w.Append(testEvent(TaskCreated, fmt.Sprintf("a-%d", id), ""))
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	all, err := NewReader(path).ReadAll()
+	if err != nil {
+		t.Fatalf("ReadAll: %v", err)
+	}
+	if len(all) != writers*perWriter {
+		t.Errorf("ReadAll = %d events, want %d", len(all), writers*perWriter)
+	}
+}
+
+func TestIndexWrittenOnAppend(t *testing.T) {
+	path := tmpPath(t)
+	w := NewWriter(path)
+	mustWrite(t, w,
+		testEvent(TaskCreated, "", "t1"),
+		testEvent(AgentSpawned, "a1", "t1"),
+		testEvent(TaskCreated, "", "t2"),
+	)
+
+	dir := indexDir(path, activeIndex)
+	offs, ok, err := readPostings(dir, indexKey{keyTask, "t1"})
+	if err != nil || !ok {
+		t.Fatalf("readPostings: ok=%v err=%v", ok, err)
+	}
+	if len(offs) != 2 || offs[0] != 0 {
+		t.Errorf("t1 offsets = %v, want two starting at 0", offs)
+	}
+	st, _ := os.Stat(path)
+	if wm, _ := readWatermark(dir); wm != st.Size() {
+		t.Errorf("watermark = %d, want %d", wm, st.Size())
+	}
+}
+
+func TestIndexedReadMatchesScan(t *testing.T) {
+	path := tmpPath(t)
+	w := NewWriter(path, WithRotation(RotationPolicy{MaxBytes: 400}))
+	base := time.Now().Add(-5 * time.Hour)
+	for i := range 40 {
+		ev := testEvent(TaskCreated, fmt.Sprintf("a%d", i%3), fmt.Sprintf("t%d", i%4))
+		if i%5 == 0 {
+			ev.Type = MergeSuccess
+		}
+		ev.Timestamp = base.Add(time.Duration(i) * 10 * time.Minute)
+		mustWrite(t, w, ev)
+	}
+
+	filters := []Filter{
+		{TaskID: "t1"},
+		{AgentID: "a2"},
+		{Type: MergeSuccess},
+		{TaskID: "t2", AgentID: "a1"},
+		{After: base.Add(2 * time.Hour)},
+		{Before: base.Add(time.Hour), TaskID: "t0"},
+	}
+	for _, f := range filters {
+		got, err := NewReader(path).Read(f)
+		if err != nil {
+			t.Fatalf("Read(%+v): %v", f, err)
+		}
+		var want []Event
+		all, _ := NewReader(path).ReadAll()
+		for _, ev := range all {
+			if f.matches(&ev) {
+				want = append(want, ev)
+			}
+		}
+		if len(got) != len(want) {
+			t.Errorf("Read(%+v) = %d events, want %d", f, len(got), len(want))
+			continue
+		}
+		for i := range got {
+			if !got[i].Timestamp.Equal(want[i].Timestamp) || got[i].TaskID != want[i].TaskID {
+				t.Errorf("Read(%+v)[%d] = %+v, want %+v", f, i, got[i], want[i])
+			}
+		}
+	}
+}
+
+func TestIndexedReadFindsUnindexedTail(t *testing.T) {
+	path := tmpPath(t)
+	w := NewWriter(path)
+	mustWrite(t, w, testEvent(TaskCreated, "", "t1"))
+
+	// An event appended without going through the Writer (e.g. a crash
+	// before indexing) lies past the watermark and must still be found.
+	data, _ := json.Marshal(testEvent(TaskDone, "", "t1"))
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _ = f.Write(append(data, '\n'))
+	_ = f.Close()
+
+	got, err := NewReader(path).Read(Filter{TaskID: "t1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 {
+		t.Fatalf("got %d events, want 2", len(got))
+	}
+
+	// The next append indexes the gap.
+	mustWrite(t, w, testEvent(TaskCreated, "", "t2"))
+	offs, _, _ := readPostings(indexDir(path, activeIndex), indexKey{keyTask, "t1"})
+	if len(offs) != 2 {
+		t.Errorf("t1 postings = %v, want 2 after gap indexed", offs)
+	}
+}
+
+func TestIndexMissingFallsBackToScan(t *testing.T) {
+	path := tmpPath(t)
+	w := NewWriter(path)
+	mustWrite(t, w, testEvent(TaskCreated, "", "t1"), testEvent(TaskCreated, "", "t2"))
+	if err := os.RemoveAll(indexRoot(path)); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := NewReader(path).Read(Filter{TaskID: "t2"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 {
+		t.Errorf("got %d events, want 1", len(got))
+	}
+}
+
+func TestIndexSkipsCompressedSegmentWithoutMatch(t *testing.T) {
+	path := tmpPath(t)
+	w := NewWriter(path, WithRotation(RotationPolicy{Compress: true, MaxBytes: 1 << 20}))
+	mustWrite(t, w, testEvent(TaskCreated, "", "t-old"))
+	if err := w.Rotate(); err != nil {
+		t.Fatal(err)
+	}
+	mustWrite(t, w, testEvent(TaskCreated, "", "t-new"))
+
+	if _, err := os.Stat(indexDir(path, segmentIndexName(1))); err != nil {
+		t.Fatalf("segment index not rotated: %v", err)
+	}
+	// Corrupt the compressed segment: a query that the index rules out
+	// must not need to open it.
+	segs, _ := listSegments(path)
+	if err := os.WriteFile(segs[0].path, []byte("not gzip"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	got, err := NewReader(path).Read(Filter{TaskID: "t-new"})
+	if err != nil {
+		t.Fatalf("Read: %v", err)
+	}
+	if len(got) != 1 {
+		t.Errorf("got %d events, want 1", len(got))
+	}
+}
+
+func TestTailAcrossSegments(t *testing.T) {
+	for _, compress := range []bool{false, true} {
+		path := tmpPath(t)
+		w := NewWriter(path, WithRotation(RotationPolicy{MaxBytes: 300, Compress: compress}))
+		for i := range 20 {
+			mustWrite(t, w, testEvent(TaskCreated, "", fmt.Sprintf("t%02d", i)))
+		}
+		if segs, _ := listSegments(path); len(segs) < 2 {
+			t.Fatalf("compress=%v: expected several segments, got %d", compress, len(segs))
+		}
+
+		got, err := NewReader(path).Tail(7)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(got) != 7 {
+			t.Fatalf("compress=%v: got %d events, want 7", compress, len(got))
+		}
+		for i, ev := range got {
+			if want := fmt.Sprintf("t%02d", 13+i); ev.TaskID != want {
+				t.Errorf("compress=%v: got[%d] = %s, want %s", compress, i, ev.TaskID, want)
+			}
+		}
+	}
+}
+
+func TestTailLargeFileReadsBackward(t *testing.T) {
+	path := tmpPath(t)
+	w := NewWriter(path)
+	batch := make([]Event, 0, 5000)
+	for i := range 5000 {
+		ev := testEvent(TaskCreated, "", "t1")
+		ev.Data = map[string]any{"n": float64(i), "pad": strings.Repeat("x", 64)}
+		batch = append(batch, ev)
+	}
+	mustWrite(t, w, batch...)
+
+	got, err := NewReader(path).Tail(3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 3 || got[0].Data["n"] != float64(4997) || got[2].Data["n"] != float64(4999) {
+		t.Errorf("Tail(3) = %v", got)
+	}
+}
+
+func TestTailSkipsCorruptLastLine(t *testing.T) {
+	path := tmpPath(t)
+	w := NewWriter(path)
+	mustWrite(t, w, testEvent(TaskCreated, "", "t1"), testEvent(TaskCreated, "", "t2"))
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _ = f.WriteString("{partial")
+	_ = f.Close()
+
+	got, err := NewReader(path).Tail(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 || got[0].TaskID != "t2" {
+		t.Errorf("Tail(1) = %v, want t2", got)
+	}
+}
+
+// receive reads n events from ch or fails after a timeout.
+func receive(t *testing.T, ch <-chan Event, n int) []Event {
+	t.Helper()
+	var got []Event
+	timeout := time.After(5 * time.Second)
+	for len(got) < n {
+		select {
+		case ev, ok := <-ch:
+			if !ok {
+				t.Fatalf("channel closed after %d of %d events", len(got), n)
+			}
+			got = append(got, ev)
+		case <-timeout:
+			t.Fatalf("timed out after %d of %d events", len(got), n)
+		}
+	}
+	return got
+}
+
+func TestWatchDeliversNewEvents(t *testing.T) {
+	path := tmpPath(t)
+	w := NewWriter(path)
+	mustWrite(t, w, testEvent(TaskCreated, "", "t-before"))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	ch, err := NewReader(path).Watch(ctx, Filter{TaskID: "t1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mustWrite(t, w,
+		testEvent(TaskCreated, "", "t1"),
+		testEvent(TaskCreated, "", "t2"),
+		testEvent(TaskDone, "", "t1"),
+	)
+	got := receive(t, ch, 2)
+	if got[0].Type != TaskCreated || got[1].Type != TaskDone {
+		t.Errorf("got %v, want created then done for t1", got)
+	}
+
+	cancel()
+	for range ch {
+	}
+}
+
+func TestWatchResumesFromCursorFile(t *testing.T) {
+	path := tmpPath(t)
+	cursorFile := filepath.Join(t.TempDir(), "cursor.json")
+	w := NewWriter(path)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	ch, err := NewReader(path).Watch(ctx, Filter{}, WithCursorFile(cursorFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	mustWrite(t, w, testEvent(TaskCreated, "", "t1"), testEvent(TaskCreated, "", "t2"))
+	receive(t, ch, 2)
+	cancel()
+	for range ch {
+	}
+
+	// Events appended while no one is watching are delivered on resume.
+	mustWrite(t, w, testEvent(TaskCreated, "", "t3"), testEvent(TaskCreated, "", "t4"))
+
+	ctx2, cancel2 := context.WithCancel(context.Background())
+	defer cancel2()
+	ch2, err := NewReader(path).Watch(ctx2, Filter{}, WithCursorFile(cursorFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := receive(t, ch2, 2)
+	if got[0].TaskID != "t3" || got[1].TaskID != "t4" {
+		t.Errorf("resumed with %s, %s; want t3, t4", got[0].TaskID, got[1].TaskID)
+	}
+}
+
+func TestWatchFollowsRotation(t *testing.T) {
+	path := tmpPath(t)
+	w := NewWriter(path, WithRotation(RotationPolicy{MaxBytes: 300, Compress: true}))
+	mustWrite(t, w, testEvent(TaskCreated, "", "t00"))
+
+	start, err := NewReader(path).End()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 1; i <= 12; i++ {
+		mustWrite(t, w, testEvent(TaskCreated, "", fmt.Sprintf("t%02d", i)))
+	}
+	if segs, _ := listSegments(path); len(segs) < 2 {
+		t.Fatalf("expected rotation, got %d segments", len(segs))
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	ch, err := NewReader(path).Watch(ctx, Filter{}, FromCursor(start))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := receive(t, ch, 12)
+	for i, ev := range got {
+		if want := fmt.Sprintf("t%02d", i+1); ev.TaskID != want {
+			t.Errorf("got[%d] = %s, want %s", i, ev.TaskID, want)
+		}
+	}
+}
+
+func TestWatchFromZeroCursorReplaysLog(t *testing.T) {
+	path := tmpPath(t)
+	w := NewWriter(path)
+	mustWrite(t, w, testEvent(TaskCreated, "", "t1"), testEvent(TaskCreated, "", "t2"))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	ch, err := NewReader(path).Watch(ctx, Filter{}, FromCursor(Cursor{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := receive(t, ch, 2)
+	if got[0].TaskID != "t1" || got[1].TaskID != "t2" {
+		t.Errorf("got %v", got)
+	}
+}
+
+func TestCursorRoundtrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sub", "cursor.json")
+	if _, ok, err := LoadCursor(path); ok || err != nil {
+		t.Fatalf("LoadCursor(missing) = ok %v, err %v", ok, err)
+	}
+	want := Cursor{Seq: 3, Offset: 1234}
+	if err := SaveCursor(path, want); err != nil {
+		t.Fatal(err)
+	}
+	got, ok, err := LoadCursor(path)
+	if err != nil || !ok || got != want {
+		t.Errorf("LoadCursor = %+v, %v, %v; want %+v", got, ok, err, want)
+	}
+}
+
+func TestReadFilterByData(t *testing.T) {
+	path := tmpPath(t)
+	w := NewWriter(path)
+	a := testEvent(MergeFailed, "", "t1")
+	a.Data = map[string]any{"reason": "conflict", "attempts": float64(3)}
+	b := testEvent(MergeFailed, "", "t2")
+	b.Data = map[string]any{"reason": "tests"}
+	mustWrite(t, w, a, b, testEvent(TaskCreated, "", "t3"))
+
+	r := NewReader(path)
+	got, err := r.Read(Filter{Data: map[string]string{"reason": "conflict", "attempts": "3"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 || got[0].TaskID != "t1" {
+		t.Errorf("got %v, want only t1", got)
+	}
+	got, _ = r.Read(Filter{Data: map[string]string{"missing": ""}})
+	if len(got) != 0 {
+		t.Errorf("missing key matched %d events", len(got))
+	}
+}
+
+func TestFollowDeliversCursors(t *testing.T) {
+	path := tmpPath(t)
+	w := NewWriter(path)
+	mustWrite(t, w, testEvent(TaskCreated, "", "t1"), testEvent(TaskCreated, "", "t2"))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	ch, err := NewReader(path).Follow(ctx, Filter{}, Cursor{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var first Delivery
+	select {
+	case first = <-ch:
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out")
+	}
+	if first.Event.TaskID != "t1" {
+		t.Fatalf("first = %s, want t1", first.Event.TaskID)
+	}
+	cancel()
+	for range ch {
+	}
+
+	// Resuming from the first delivery's cursor yields the second event.
+	ctx2, cancel2 := context.WithCancel(context.Background())
+	defer cancel2()
+	ch2, err := NewReader(path).Follow(ctx2, Filter{}, first.Cursor)
+	if err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case d := <-ch2:
+		if d.Event.TaskID != "t2" {
+			t.Errorf("resumed at %s, want t2", d.Event.TaskID)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out")
+	}
+}
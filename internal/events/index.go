@@ -0,0 +1,470 @@
+package events
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// The sidecar index lives next to the log in {stem}.idx/. Each log file
+// has its own subdirectory: "active" for the file being appended to, and
+// the zero-padded segment number once it is rotated. Inside, posting
+// files list the byte offsets of matching events, one per line:
+//
+//	events.idx/active/task/t-1a2b3c
+//	events.idx/active/agent/w-9f8e7d
+//	events.idx/active/type/merge_success
+//	events.idx/active/hour/2026021912
+//	events.idx/active/watermark
+//
+// The watermark records how many bytes of the log file are indexed.
+// Readers trust postings below the watermark and scan the remainder, so a
+// crash between appending an event and indexing it never hides events.
+
+const activeIndex = "active"
+
+// Index key kinds.
+const (
+	keyTask  = "task"
+	keyAgent = "agent"
+	keyType  = "type"
+	keyHour  = "hour"
+)
+
+// hourBucket is the time-bucket layout used for hour postings (UTC).
+const hourBucket = "2006010215"
+
+type indexKey struct {
+	kind  string
+	value string
+}
+
+// indexRoot returns the sidecar index directory for the log at path.
+func indexRoot(path string) string {
+	dir, stem, _ := logStem(path)
+	return filepath.Join(dir, stem+".idx")
+}
+
+// indexDir returns the index directory for one log file ("active" or a
+// segment number).
+func indexDir(path, name string) string {
+	return filepath.Join(indexRoot(path), name)
+}
+
+// segmentIndexName returns the index directory name of segment seq.
+func segmentIndexName(seq int) string {
+	return fmt.Sprintf("%06d", seq)
+}
+
+// keysFor returns the index keys an event is filed under.
+func keysFor(ev Event) []indexKey {
+	keys := []indexKey{
+		{keyType, string(ev.Type)},
+		{keyHour, ev.Timestamp.UTC().Format(hourBucket)},
+	}
+	if ev.TaskID != "" {
+		keys = append(keys, indexKey{keyTask, ev.TaskID})
+	}
+	if ev.AgentID != "" {
+		keys = append(keys, indexKey{keyAgent, ev.AgentID})
+	}
+	return keys
+}
+
+func postingPath(dir string, k indexKey) string {
+	return filepath.Join(dir, k.kind, url.PathEscape(k.value))
+}
+
+// readWatermark returns the number of indexed bytes recorded in dir, and
+// false if the directory has no watermark (the file was never indexed).
+func readWatermark(dir string) (int64, bool) {
+	data, err := os.ReadFile(filepath.Join(dir, "watermark"))
+	if err != nil {
+		return 0, false
+	}
+	n, err := strconv.ParseInt(strings.TrimSpace(string(data)), 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	return n, true
+}
+
+func writeWatermark(dir string, n int64) error {
+	return writeFileAtomic(filepath.Join(dir, "watermark"), []byte(strconv.FormatInt(n, 10)+"\n"))
+}
+
+// indexedEvent pairs an event with its byte offset in the log file.
+type indexedEvent struct {
+	offset int64
+	ev     Event
+}
+
+// addPostings appends the offsets of evs to their posting files in dir.
+func addPostings(dir string, evs []indexedEvent) error {
+	postings := map[indexKey][]int64{}
+	var order []indexKey
+	for _, ie := range evs {
+		for _, k := range keysFor(ie.ev) {
+			if _, ok := postings[k]; !ok {
+				order = append(order, k)
+			}
+			postings[k] = append(postings[k], ie.offset)
+		}
+	}
+	for _, k := range order {
+		p := postingPath(dir, k)
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			return fmt.Errorf("events: create index dir: %w", err)
+		}
+		var b strings.Builder
+		for _, off := range postings[k] {
+			b.WriteString(strconv.FormatInt(off, 10))
+			b.WriteByte('\n')
+		}
+		f, err := os.OpenFile(p, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o644)
+		if err != nil {
+			return fmt.Errorf("events: open posting file: %w", err)
+		}
+		_, werr := f.WriteString(b.String())
+		cerr := f.Close()
+		if werr != nil {
+			return fmt.Errorf("events: write posting file: %w", werr)
+		}
+		if cerr != nil {
+			return fmt.Errorf("events: close posting file: %w", cerr)
+		}
+	}
+	return nil
+}
+
+// readPostings returns the sorted, de-duplicated offsets for key in dir.
+// The bool is false if no posting file exists.
+func readPostings(dir string, k indexKey) ([]int64, bool, error) {
+	data, err := os.ReadFile(postingPath(dir, k))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, false, nil
+		}
+		return nil, false, fmt.Errorf("events: read postings: %w", err)
+	}
+	var offs []int64
+	for _, line := range strings.Split(string(data), "\n") {
+		if line == "" {
+			continue
+		}
+		n, err := strconv.ParseInt(line, 10, 64)
+		if err != nil {
+			continue
+		}
+		offs = append(offs, n)
+	}
+	return sortUnique(offs), true, nil
+}
+
+// readHourPostings unions hour postings in dir within [after, before].
+// Zero bounds are open.
+func readHourPostings(dir string, after, before time.Time) ([]int64, error) {
+	entries, err := os.ReadDir(filepath.Join(dir, keyHour))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("events: read hour index: %w", err)
+	}
+	var lo, hi string
+	if !after.IsZero() {
+		lo = after.UTC().Format(hourBucket)
+	}
+	if !before.IsZero() {
+		hi = before.UTC().Format(hourBucket)
+	}
+	var offs []int64
+	for _, e := range entries {
+		name := e.Name()
+		if (lo != "" && name < lo) || (hi != "" && name > hi) {
+			continue
+		}
+		o, _, err := readPostings(dir, indexKey{keyHour, name})
+		if err != nil {
+			return nil, err
+		}
+		offs = append(offs, o...)
+	}
+	return sortUnique(offs), nil
+}
+
+func sortUnique(offs []int64) []int64 {
+	sort.Slice(offs, func(i, j int) bool { return offs[i] < offs[j] })
+	out := offs[:0]
+	for i, o := range offs {
+		if i == 0 || o != offs[i-1] {
+			out = append(out, o)
+		}
+	}
+	return out
+}
+
+// queryKey picks the most selective indexed field of a filter. The bool
+// is false if the filter has no indexable field.
+func queryKey(f Filter) (indexKey, bool) {
+	switch {
+	case f.TaskID != "":
+		return indexKey{keyTask, f.TaskID}, true
+	case f.AgentID != "":
+		return indexKey{keyAgent, f.AgentID}, true
+	case f.Type != "":
+		return indexKey{keyType, string(f.Type)}, true
+	}
+	return indexKey{}, false
+}
+
+// indexAppended files events just written at the given offsets into the
+// active index. If the watermark lags the start offset (another writer
+// crashed before indexing, or the index was deleted) the gap is indexed
+// first; if it is ahead (the log was replaced) the index is rebuilt.
+func indexAppended(path string, start, end int64, evs []indexedEvent) error {
+	dir := indexDir(path, activeIndex)
+	wm, _ := readWatermark(dir)
+	if wm > start {
+		if err := os.RemoveAll(dir); err != nil {
+			return fmt.Errorf("events: reset index: %w", err)
+		}
+		wm = 0
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("events: create index dir: %w", err)
+	}
+	if wm < start {
+		var gap []indexedEvent
+		if err := scanRange(path, wm, start, func(off int64, ev Event) {
+			gap = append(gap, indexedEvent{off, ev})
+		}); err != nil {
+			return err
+		}
+		evs = append(gap, evs...)
+	}
+	if err := addPostings(dir, evs); err != nil {
+		return err
+	}
+	return writeWatermark(dir, end)
+}
+
+// rotateIndex moves the active index to the directory of segment seq.
+func rotateIndex(path string, seq int) error {
+	src := indexDir(path, activeIndex)
+	if _, err := os.Stat(src); os.IsNotExist(err) {
+		return nil
+	}
+	dst := indexDir(path, segmentIndexName(seq))
+	if err := os.RemoveAll(dst); err != nil {
+		return fmt.Errorf("events: rotate index: %w", err)
+	}
+	if err := os.Rename(src, dst); err != nil {
+		return fmt.Errorf("events: rotate index: %w", err)
+	}
+	return nil
+}
+
+// scanRange calls fn with the offset and event of every well-formed line
+// in the plain file at path that starts in [from, to). A negative to reads
+// to EOF.
+func scanRange(path string, from, to int64, fn func(int64, Event)) error {
+	f, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("events: open: %w", err)
+	}
+	defer func() { _ = f.Close() }()
+	if _, err := f.Seek(from, io.SeekStart); err != nil {
+		return fmt.Errorf("events: seek: %w", err)
+	}
+	br := bufio.NewReaderSize(f, 64*1024)
+	off := from
+	for to < 0 || off < to {
+		line, err := br.ReadBytes('\n')
+		if len(line) > 0 {
+			lineOff := off
+			off += int64(len(line))
+			if trimmed := bytes.TrimSpace(line); len(trimmed) > 0 {
+				var ev Event
+				if json.Unmarshal(trimmed, &ev) == nil {
+					fn(lineOff, ev)
+				}
+			}
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("events: read: %w", err)
+		}
+	}
+	return nil
+}
+
+// readAt reads the events starting at each offset in the plain file.
+func readAt(path string, offs []int64, fn func(Event)) error {
+	if len(offs) == 0 {
+		return nil
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("events: open: %w", err)
+	}
+	defer func() { _ = f.Close() }()
+	br := bufio.NewReaderSize(f, 4096)
+	for _, off := range offs {
+		if _, err := f.Seek(off, io.SeekStart); err != nil {
+			return fmt.Errorf("events: seek: %w", err)
+		}
+		br.Reset(f)
+		line, err := br.ReadBytes('\n')
+		if err != nil && err != io.EOF {
+			return fmt.Errorf("events: read: %w", err)
+		}
+		var ev Event
+		if json.Unmarshal(bytes.TrimSpace(line), &ev) == nil {
+			fn(ev)
+		}
+	}
+	return nil
+}
+
+// queryFile calls fn for events in one log file that match filter, using
+// its index directory when possible. Plain files are read by offset;
+// gzipped segments are only decompressed if the index says they can
+// contain a match.
+func queryFile(path, idxDir string, filter Filter, fn func(Event)) error {
+	match := func(ev Event) {
+		if filter.matches(&ev) {
+			fn(ev)
+		}
+	}
+	wm, indexed := readWatermark(idxDir)
+	key, byKey := queryKey(filter)
+	byTime := !filter.After.IsZero() || !filter.Before.IsZero()
+	if !indexed || (!byKey && !byTime) {
+		return scanFile(path, match)
+	}
+
+	var offs []int64
+	if byKey {
+		o, ok, err := readPostings(idxDir, key)
+		if err != nil {
+			return err
+		}
+		if ok {
+			offs = o
+		}
+	} else {
+		o, err := readHourPostings(idxDir, filter.After, filter.Before)
+		if err != nil {
+			return err
+		}
+		offs = o
+	}
+
+	if strings.HasSuffix(path, ".gz") {
+		if len(offs) == 0 {
+			return nil
+		}
+		return scanFile(path, match)
+	}
+
+	// Only trust postings below the watermark; the rest is scanned.
+	trusted := offs[:0]
+	for _, o := range offs {
+		if o < wm {
+			trusted = append(trusted, o)
+		}
+	}
+	if err := readAt(path, trusted, match); err != nil {
+		return err
+	}
+	return scanRange(path, wm, -1, func(_ int64, ev Event) { match(ev) })
+}
+
+// tailFile returns up to the last n well-formed events of one log file.
+// Plain files are read in growing chunks from EOF; gzipped segments cannot
+// be seeked and are decompressed in full.
+func tailFile(path string, n int) ([]Event, error) {
+	if strings.HasSuffix(path, ".gz") {
+		var ring []Event
+		err := scanFile(path, func(ev Event) {
+			ring = append(ring, ev)
+			if len(ring) > n {
+				ring = ring[1:]
+			}
+		})
+		return ring, err
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("events: open: %w", err)
+	}
+	defer func() { _ = f.Close() }()
+	st, err := f.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("events: stat: %w", err)
+	}
+
+	pos := st.Size()
+	chunk := int64(64 * 1024)
+	var buf []byte
+	for {
+		evs := parseLines(buf, pos == 0)
+		if len(evs) >= n || pos == 0 {
+			if len(evs) > n {
+				evs = evs[len(evs)-n:]
+			}
+			return evs, nil
+		}
+		size := min(chunk, pos)
+		pos -= size
+		b := make([]byte, size, int(size)+len(buf))
+		if _, err := f.ReadAt(b, pos); err != nil && err != io.EOF {
+			return nil, fmt.Errorf("events: read: %w", err)
+		}
+		buf = append(b, buf...)
+		chunk *= 2
+	}
+}
+
+// parseLines decodes the JSONL lines in buf. Unless complete is set, the
+// first line may be cut off by the chunk boundary and is dropped.
+func parseLines(buf []byte, complete bool) []Event {
+	lines := bytes.Split(buf, []byte{'\n'})
+	if !complete && len(lines) > 0 {
+		lines = lines[1:]
+	}
+	var evs []Event
+	for _, line := range lines {
+		line = bytes.TrimSpace(line)
+		if len(line) == 0 {
+			continue
+		}
+		var ev Event
+		if json.Unmarshal(line, &ev) == nil {
+			evs = append(evs, ev)
+		}
+	}
+	return evs
+}
@@ -0,0 +1,50 @@
+package events
+
+import (
+	"os"
+	"syscall"
+)
+
+// inotifyNotifier wakes Watch when files in the log directory change.
+type inotifyNotifier struct {
+	f  *os.File
+	ch chan struct{}
+}
+
+// newNotifier watches dir with inotify. The directory rather than the file
+// is watched so rotation (rename + create) is seen too.
+func newNotifier(dir string) (notifier, error) {
+	fd, err := syscall.InotifyInit1(syscall.IN_CLOEXEC | syscall.IN_NONBLOCK)
+	if err != nil {
+		return nil, os.NewSyscallError("inotify_init1", err)
+	}
+	mask := uint32(syscall.IN_MODIFY | syscall.IN_CREATE | syscall.IN_MOVED_TO | syscall.IN_CLOSE_WRITE)
+	if _, err := syscall.InotifyAddWatch(fd, dir, mask); err != nil {
+		_ = syscall.Close(fd)
+		return nil, os.NewSyscallError("inotify_add_watch", err)
+	}
+	// A non-blocking fd is registered with the runtime poller, so Close
+	// unblocks the pending Read below.
+	n := &inotifyNotifier{f: os.NewFile(uintptr(fd), "inotify"), ch: make(chan struct{}, 1)}
+	go n.loop()
+	return n, nil
+}
+
+func (n *inotifyNotifier) loop() {
+	buf := make([]byte, 64*(syscall.SizeofInotifyEvent+syscall.NAME_MAX+1))
+	for {
+		if _, err := n.f.Read(buf); err != nil {
+			return
+		}
+		// Coalesce: one pending wakeup is enough, Watch rereads from its
+		// cursor.
+		select {
+		case n.ch <- struct{}{}:
+		default:
+		}
+	}
+}
+
+func (n *inotifyNotifier) C() <-chan struct{} { return n.ch }
+
+func (n *inotifyNotifier) Close() error { return n.f.Close() }
@@ -0,0 +1,10 @@
+//go:build !linux
+
+package events
+
+import "errors"
+
+// newNotifier is unavailable without inotify; Watch falls back to polling.
+func newNotifier(dir string) (notifier, error) {
+	return nil, errors.New("events: change notification not supported on this platform")
+}
@@ -1,10 +1,7 @@
 package events
 
 import (
-	"bufio"
-	"encoding/json"
 	"fmt"
-	"os"
 	"time"
 )
 
@@ -15,6 +12,9 @@ type Filter struct {
 	TaskID  string    // If non-empty, match only this task.
 	After   time.Time // If non-zero, match events after this time.
 	Before  time.Time // If non-zero, match events before this time.
+	// Data, if non-empty, matches events whose Data has every key with a
+	// value that formats (fmt %v) to the given string.
+	Data map[string]string
 }
 
 func (f Filter) matches(e *Event) bool {
@@ -33,6 +33,12 @@ func (f Filter) matches(e *Event) bool {
 	if !f.Before.IsZero() && !e.Timestamp.Before(f.Before) {
 		return false
 	}
+	for k, want := range f.Data {
+		v, ok := e.Data[k]
+		if !ok || fmt.Sprint(v) != want {
+			return false
+		}
+	}
 	return true
 }
 
@@ -50,39 +56,120 @@ func NewReader(path string) *Reader {
 	return &Reader{path: path}
 }
 
-// Read returns all events matching the given filter.
+// Read returns all events matching the given filter, across rotated
+// segments and the active file in chronological order.
 // A zero-value Filter matches all events.
 func (r *Reader) Read(filter Filter) ([]Event, error) {
-	f, err := os.Open(r.path)
+	var result []Event
+	err := r.each(filter, func(ev Event) {
+		result = append(result, ev)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// each calls fn for every event matching filter, oldest first. Segments
+// whose manifest time range cannot match the filter are skipped unread,
+// and the sidecar index narrows the reads within each file.
+func (r *Reader) each(filter Filter, fn func(Event)) error {
+	files, err := r.files(filter)
 	if err != nil {
-		if os.IsNotExist(err) {
-			return nil, nil
+		return err
+	}
+	for _, lf := range files {
+		if err := queryFile(lf.path, lf.index, filter, fn); err != nil {
+			return err
 		}
-		return nil, fmt.Errorf("events: open: %w", err)
 	}
-	defer func() { _ = f.Close() }()
+	return nil
+}
 
-	var result []Event
-	scanner := bufio.NewScanner(f)
-	scanner.Buffer(make([]byte, 0, 1024*1024), 1024*1024)
+// logFile is one file of the log together with its index directory.
+type logFile struct {
+	path  string
+	index string
+}
 
-	for scanner.Scan() {
-		line := scanner.Bytes()
-		if len(line) == 0 {
-			continue
+// files returns the log files that may hold events matching filter:
+// rotated segments in order, then the active file.
+func (r *Reader) files(filter Filter) ([]logFile, error) {
+	segs, err := listSegments(r.path)
+	if err != nil {
+		return nil, err
+	}
+	m, err := readManifest(r.path)
+	if err != nil {
+		return nil, err
+	}
+	known := m.bySeq()
+
+	files := make([]logFile, 0, len(segs)+1)
+	for _, s := range segs {
+		if info, ok := known[s.seq]; ok && info.Count > 0 {
+			if !filter.After.IsZero() && !info.Last.After(filter.After) {
+				continue
+			}
+			if !filter.Before.IsZero() && !info.First.Before(filter.Before) {
+				continue
+			}
 		}
-		var ev Event
-		if err := json.Unmarshal(line, &ev); err != nil {
-			continue // skip corrupt lines
+		files = append(files, logFile{path: s.path, index: indexDir(r.path, segmentIndexName(s.seq))})
+	}
+	return append(files, logFile{path: r.path, index: indexDir(r.path, activeIndex)}), nil
+}
+
+// SumData totals a numeric Data field across the whole log. For keys in
+// AggregateKeys, rotated segments contribute their persisted sums from the
+// manifest, so only the active file (and any segment missing from the
+// manifest) is scanned.
+func (r *Reader) SumData(key string) (float64, error) {
+	aggregated := false
+	for _, k := range AggregateKeys {
+		if k == key {
+			aggregated = true
+		}
+	}
+
+	segs, err := listSegments(r.path)
+	if err != nil {
+		return 0, err
+	}
+	m, err := readManifest(r.path)
+	if err != nil {
+		return 0, err
+	}
+	known := m.bySeq()
+
+	var total float64
+	add := func(ev Event) {
+		if v, ok := ev.Data[key].(float64); ok {
+			total += v
+		}
+	}
+	for _, s := range segs {
+		if info, ok := known[s.seq]; ok && aggregated {
+			total += info.Sums[key]
+			continue
 		}
-		if filter.matches(&ev) {
-			result = append(result, ev)
+		if err := scanFile(s.path, add); err != nil {
+			return 0, err
 		}
 	}
-	if err := scanner.Err(); err != nil {
-		return nil, fmt.Errorf("events: scan: %w", err)
+	if err := scanFile(r.path, add); err != nil {
+		return 0, err
 	}
-	return result, nil
+	return total, nil
+}
+
+// Segments returns the manifest entries of rotated segments, oldest first.
+func (r *Reader) Segments() ([]SegmentInfo, error) {
+	m, err := readManifest(r.path)
+	if err != nil {
+		return nil, err
+	}
+	return m.Segments, nil
 }
 
 // ReadAll returns every event in the log.
@@ -90,21 +177,27 @@ func (r *Reader) ReadAll() ([]Event, error) {
 	return r.Read(Filter{})
 }
 
-// Tail returns the last n events from the log.
+// Tail returns the last n events from the log, spanning segments. The
+// active file and plain segments are read backward from the end, so the
+// cost depends on n rather than the size of the log.
 func (r *Reader) Tail(n int) ([]Event, error) {
 	if n <= 0 {
 		return nil, nil
 	}
 
-	all, err := r.ReadAll()
+	files, err := r.files(Filter{})
 	if err != nil {
 		return nil, err
 	}
-
-	if len(all) <= n {
-		return all, nil
+	var result []Event
+	for i := len(files) - 1; i >= 0 && len(result) < n; i-- {
+		evs, err := tailFile(files[i].path, n-len(result))
+		if err != nil {
+			return nil, err
+		}
+		result = append(evs, result...)
 	}
-	return all[len(all)-n:], nil
+	return result, nil
 }
 
 // Path returns the file path this reader reads from.
@@ -0,0 +1,320 @@
+package events
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// AggregateKeys lists the numeric Data fields whose per-segment sums are
+// persisted in the manifest when a segment is rotated out, so totals such
+// as budget spent never need a rescan of old segments.
+var AggregateKeys = []string{"token_cost"}
+
+// RotationPolicy controls when the active log file is rotated into a
+// numbered segment. A zero policy never rotates.
+type RotationPolicy struct {
+	MaxBytes int64         // rotate once the active file reaches this size
+	MaxAge   time.Duration // rotate once the oldest active event is this old
+	Compress bool          // gzip segments after rotation
+}
+
+func (p RotationPolicy) enabled() bool {
+	return p.MaxBytes > 0 || p.MaxAge > 0
+}
+
+// SegmentInfo describes a rotated segment in the manifest.
+type SegmentInfo struct {
+	Name  string             `json:"name"`
+	Seq   int                `json:"seq"`
+	First time.Time          `json:"first,omitzero"`
+	Last  time.Time          `json:"last,omitzero"`
+	Count int                `json:"count"`
+	Sums  map[string]float64 `json:"sums,omitempty"`
+}
+
+// manifest is persisted next to the log as {stem}.manifest.json.
+type manifest struct {
+	Segments []SegmentInfo `json:"segments"`
+}
+
+// segmentFile is a rotated segment found on disk.
+type segmentFile struct {
+	path string
+	seq  int
+}
+
+// logStem splits a log path like ".alt/events.jsonl" into its directory,
+// stem ("events") and extension (".jsonl").
+func logStem(path string) (dir, stem, ext string) {
+	dir = filepath.Dir(path)
+	base := filepath.Base(path)
+	ext = filepath.Ext(base)
+	stem = strings.TrimSuffix(base, ext)
+	return dir, stem, ext
+}
+
+// segmentPath returns the uncompressed path of segment seq.
+func segmentPath(path string, seq int) string {
+	dir, stem, ext := logStem(path)
+	return filepath.Join(dir, fmt.Sprintf("%s.%06d%s", stem, seq, ext))
+}
+
+// manifestPath returns the manifest path for the log at path.
+func manifestPath(path string) string {
+	dir, stem, _ := logStem(path)
+	return filepath.Join(dir, stem+".manifest.json")
+}
+
+// listSegments returns the rotated segments of the log at path, oldest
+// first. Both plain and gzipped segments are returned.
+func listSegments(path string) ([]segmentFile, error) {
+	dir, stem, ext := logStem(path)
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("events: read log dir: %w", err)
+	}
+	var segs []segmentFile
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || !strings.HasPrefix(name, stem+".") {
+			continue
+		}
+		rest := strings.TrimPrefix(name, stem+".")
+		rest = strings.TrimSuffix(rest, ".gz")
+		if !strings.HasSuffix(rest, ext) {
+			continue
+		}
+		seq, err := strconv.Atoi(strings.TrimSuffix(rest, ext))
+		if err != nil {
+			continue
+		}
+		segs = append(segs, segmentFile{path: filepath.Join(dir, name), seq: seq})
+	}
+	sort.Slice(segs, func(i, j int) bool { return segs[i].seq < segs[j].seq })
+	return segs, nil
+}
+
+// nextSeq returns the sequence number the active file will get when it is
+// next rotated: one past the highest segment on disk or in the manifest.
+func nextSeq(path string, m manifest) (int, error) {
+	segs, err := listSegments(path)
+	if err != nil {
+		return 0, err
+	}
+	seq := 1
+	if n := len(segs); n > 0 {
+		seq = segs[n-1].seq + 1
+	}
+	for _, s := range m.Segments {
+		if s.Seq >= seq {
+			seq = s.Seq + 1
+		}
+	}
+	return seq, nil
+}
+
+// readManifest loads the manifest for the log at path. A missing manifest
+// yields an empty one.
+func readManifest(path string) (manifest, error) {
+	var m manifest
+	data, err := os.ReadFile(manifestPath(path))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return m, nil
+		}
+		return m, fmt.Errorf("events: read manifest: %w", err)
+	}
+	if err := json.Unmarshal(data, &m); err != nil {
+		return m, fmt.Errorf("events: parse manifest: %w", err)
+	}
+	return m, nil
+}
+
+// writeManifest atomically replaces the manifest for the log at path.
+func writeManifest(path string, m manifest) error {
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return fmt.Errorf("events: marshal manifest: %w", err)
+	}
+	data = append(data, '\n')
+	return writeFileAtomic(manifestPath(path), data)
+}
+
+// bySeq indexes manifest entries by segment sequence number.
+func (m manifest) bySeq() map[int]SegmentInfo {
+	out := make(map[int]SegmentInfo, len(m.Segments))
+	for _, s := range m.Segments {
+		out[s.Seq] = s
+	}
+	return out
+}
+
+// openLog opens a plain or gzipped log file for reading.
+func openLog(path string) (io.ReadCloser, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	if !strings.HasSuffix(path, ".gz") {
+		return f, nil
+	}
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		_ = f.Close()
+		return nil, fmt.Errorf("events: gzip %s: %w", filepath.Base(path), err)
+	}
+	return &gzipFile{Reader: gz, f: f}, nil
+}
+
+type gzipFile struct {
+	*gzip.Reader
+	f *os.File
+}
+
+func (g *gzipFile) Close() error {
+	_ = g.Reader.Close()
+	return g.f.Close()
+}
+
+// scanFile calls fn for every well-formed event in the file at path.
+// Corrupt lines are skipped. A missing file is not an error.
+func scanFile(path string, fn func(Event)) error {
+	rc, err := openLog(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("events: open: %w", err)
+	}
+	defer func() { _ = rc.Close() }()
+
+	scanner := bufio.NewScanner(rc)
+	scanner.Buffer(make([]byte, 0, 1024*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+		var ev Event
+		if err := json.Unmarshal(line, &ev); err != nil {
+			continue // skip corrupt lines
+		}
+		fn(ev)
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("events: scan: %w", err)
+	}
+	return nil
+}
+
+// summarize computes the manifest entry for the file at path.
+func summarize(path string) (SegmentInfo, error) {
+	info := SegmentInfo{Sums: map[string]float64{}}
+	err := scanFile(path, func(ev Event) {
+		if info.Count == 0 || ev.Timestamp.Before(info.First) {
+			info.First = ev.Timestamp
+		}
+		if ev.Timestamp.After(info.Last) {
+			info.Last = ev.Timestamp
+		}
+		info.Count++
+		for _, key := range AggregateKeys {
+			if v, ok := ev.Data[key].(float64); ok {
+				info.Sums[key] += v
+			}
+		}
+	})
+	return info, err
+}
+
+// firstTimestamp returns the timestamp of the first event in the file.
+func firstTimestamp(path string) (time.Time, bool) {
+	f, err := os.Open(path)
+	if err != nil {
+		return time.Time{}, false
+	}
+	defer func() { _ = f.Close() }()
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 1024*1024), 1024*1024)
+	for scanner.Scan() {
+		var ev Event
+		if err := json.Unmarshal(scanner.Bytes(), &ev); err == nil {
+			return ev.Timestamp, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// gzipFileAtomic compresses src to src+".gz" and removes src.
+func gzipFileAtomic(src string) (string, error) {
+	in, err := os.Open(src)
+	if err != nil {
+		return "", fmt.Errorf("events: open segment: %w", err)
+	}
+	defer func() { _ = in.Close() }()
+
+	dst := src + ".gz"
+	tmp, err := os.CreateTemp(filepath.Dir(src), ".tmp-events-*")
+	if err != nil {
+		return "", fmt.Errorf("events: create temp file: %w", err)
+	}
+	tmpName := tmp.Name()
+	gz := gzip.NewWriter(tmp)
+	if _, err := io.Copy(gz, in); err != nil {
+		_ = tmp.Close()
+		_ = os.Remove(tmpName)
+		return "", fmt.Errorf("events: compress segment: %w", err)
+	}
+	if err := gz.Close(); err != nil {
+		_ = tmp.Close()
+		_ = os.Remove(tmpName)
+		return "", fmt.Errorf("events: compress segment: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		_ = os.Remove(tmpName)
+		return "", fmt.Errorf("events: close temp file: %w", err)
+	}
+	if err := os.Rename(tmpName, dst); err != nil {
+		_ = os.Remove(tmpName)
+		return "", fmt.Errorf("events: rename compressed segment: %w", err)
+	}
+	if err := os.Remove(src); err != nil {
+		return "", fmt.Errorf("events: remove uncompressed segment: %w", err)
+	}
+	return dst, nil
+}
+
+// writeFileAtomic writes data to path via temp file + rename.
+func writeFileAtomic(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".tmp-events-*")
+	if err != nil {
+		return fmt.Errorf("events: create temp file: %w", err)
+	}
+	tmpName := tmp.Name()
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		_ = os.Remove(tmpName)
+		return fmt.Errorf("events: write temp file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		_ = os.Remove(tmpName)
+		return fmt.Errorf("events: close temp file: %w", err)
+	}
+	if err := os.Rename(tmpName, path); err != nil {
+		_ = os.Remove(tmpName)
+		return fmt.Errorf("events: rename temp file: %w", err)
+	}
+	return nil
+}
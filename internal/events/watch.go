@@ -0,0 +1,413 @@
+package events
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Cursor is a position in the event log that survives rotation. Seq is the
+// segment number the file has (or will get once the active file is
+// rotated) and Offset is the uncompressed byte offset within it. The zero
+// Cursor is the start of the log.
+type Cursor struct {
+	Seq    int   `json:"seq"`
+	Offset int64 `json:"offset"`
+}
+
+// LoadCursor reads a cursor persisted with SaveCursor. The bool is false if
+// the file does not exist.
+func LoadCursor(path string) (Cursor, bool, error) {
+	var c Cursor
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return c, false, nil
+		}
+		return c, false, fmt.Errorf("events: read cursor: %w", err)
+	}
+	if err := json.Unmarshal(data, &c); err != nil {
+		return c, false, fmt.Errorf("events: parse cursor: %w", err)
+	}
+	return c, true, nil
+}
+
+// SaveCursor atomically persists a cursor to path.
+func SaveCursor(path string, c Cursor) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("events: create cursor dir: %w", err)
+	}
+	data, err := json.Marshal(c)
+	if err != nil {
+		return fmt.Errorf("events: marshal cursor: %w", err)
+	}
+	return writeFileAtomic(path, append(data, '\n'))
+}
+
+// DefaultPollInterval is how often Watch rescans the log when no change
+// notification arrives. It bounds latency on platforms without inotify.
+const DefaultPollInterval = 2 * time.Second
+
+// WatchOption configures Watch.
+type WatchOption func(*watchConfig)
+
+type watchConfig struct {
+	from       *Cursor
+	cursorFile string
+	poll       time.Duration
+}
+
+// FromCursor starts the watch at c instead of the end of the log.
+func FromCursor(c Cursor) WatchOption {
+	return func(wc *watchConfig) {
+		wc.from = &c
+	}
+}
+
+// WithCursorFile persists the cursor to path as events are delivered, and
+// resumes from it if it already exists. It takes precedence over FromCursor
+// once the file has been written.
+func WithCursorFile(path string) WatchOption {
+	return func(wc *watchConfig) {
+		wc.cursorFile = path
+	}
+}
+
+// WithPollInterval overrides DefaultPollInterval.
+func WithPollInterval(d time.Duration) WatchOption {
+	return func(wc *watchConfig) {
+		wc.poll = d
+	}
+}
+
+// notifier signals that files in the log directory may have changed.
+type notifier interface {
+	C() <-chan struct{}
+	Close() error
+}
+
+// End returns the cursor just past the last complete event in the log.
+func (r *Reader) End() (Cursor, error) {
+	return r.readSince(Cursor{Seq: -1}, nil)
+}
+
+// Watch streams events matching filter as they are appended. By default it
+// starts at the end of the log; FromCursor and WithCursorFile resume from
+// an earlier position, so a consumer that restarts sees every event once.
+// With WithCursorFile the cursor is saved as soon as an event is received;
+// consumers that must not lose an event they were processing should use
+// Follow and save the cursor themselves. The channel is closed when ctx is
+// done.
+func (r *Reader) Watch(ctx context.Context, filter Filter, opts ...WatchOption) (<-chan Event, error) {
+	wc := watchConfig{poll: DefaultPollInterval}
+	for _, opt := range opts {
+		opt(&wc)
+	}
+
+	var cur Cursor
+	switch {
+	case wc.cursorFile != "":
+		c, ok, err := LoadCursor(wc.cursorFile)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			cur = c
+			break
+		}
+		fallthrough
+	default:
+		if wc.from != nil {
+			cur = *wc.from
+			break
+		}
+		end, err := r.End()
+		if err != nil {
+			return nil, err
+		}
+		cur = end
+	}
+
+	ch := make(chan Event)
+	saved := cur
+	save := func(c Cursor) {
+		if wc.cursorFile != "" && c != saved {
+			if SaveCursor(wc.cursorFile, c) == nil {
+				saved = c
+			}
+		}
+	}
+	err := r.follow(ctx, filter, cur, wc.poll, func(ev Event, after Cursor) bool {
+		select {
+		case ch <- ev:
+			save(after)
+			return true
+		case <-ctx.Done():
+			return false
+		}
+	}, save, func() { close(ch) })
+	if err != nil {
+		return nil, err
+	}
+	return ch, nil
+}
+
+// Delivery is an event streamed by Follow together with the cursor just
+// past it. Saving Cursor after handling Event acknowledges it.
+type Delivery struct {
+	Event  Event
+	Cursor Cursor
+}
+
+// Follow streams events matching filter from cursor from onward, each with
+// the cursor that resumes after it. Unlike Watch it persists nothing: the
+// consumer saves the cursor once an event is handled, giving at-least-once
+// delivery across restarts. The channel is closed when ctx is done.
+func (r *Reader) Follow(ctx context.Context, filter Filter, from Cursor, opts ...WatchOption) (<-chan Delivery, error) {
+	wc := watchConfig{poll: DefaultPollInterval}
+	for _, opt := range opts {
+		opt(&wc)
+	}
+	ch := make(chan Delivery)
+	err := r.follow(ctx, filter, from, wc.poll, func(ev Event, after Cursor) bool {
+		select {
+		case ch <- Delivery{Event: ev, Cursor: after}:
+			return true
+		case <-ctx.Done():
+			return false
+		}
+	}, nil, func() { close(ch) })
+	if err != nil {
+		return nil, err
+	}
+	return ch, nil
+}
+
+// follow starts a goroutine that passes every event matching filter after
+// cur to deliver, waking on change notifications or every poll interval.
+// idle, if set, is called with the cursor after each pass over the log;
+// done is called when the goroutine exits.
+func (r *Reader) follow(ctx context.Context, filter Filter, cur Cursor, poll time.Duration,
+	deliver func(Event, Cursor) bool, idle func(Cursor), done func()) error {
+	if err := os.MkdirAll(filepath.Dir(r.path), 0o755); err != nil {
+		return fmt.Errorf("events: create dir: %w", err)
+	}
+	notify, err := newNotifier(filepath.Dir(r.path))
+	if err != nil {
+		notify = nil // fall back to polling
+	}
+
+	go func() {
+		defer done()
+		if notify != nil {
+			defer func() { _ = notify.Close() }()
+		}
+		ticker := time.NewTicker(poll)
+		defer ticker.Stop()
+
+		for {
+			next, err := r.readSince(cur, func(ev Event, after Cursor) bool {
+				if filter.matches(&ev) && !deliver(ev, after) {
+					return false
+				}
+				cur = after
+				return true
+			})
+			if ctx.Err() != nil {
+				return
+			}
+			if err == nil {
+				cur = next
+				if idle != nil {
+					idle(cur)
+				}
+			}
+
+			var changed <-chan struct{}
+			if notify != nil {
+				changed = notify.C()
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-changed:
+			case <-ticker.C:
+			}
+		}
+	}()
+	return nil
+}
+
+// readSince calls fn for every complete event after c, across any segments
+// rotated since c was taken, together with the cursor just past it. If fn
+// returns false reading stops. It returns the cursor after the last event
+// read. A nil fn skips straight to the end.
+func (r *Reader) readSince(c Cursor, fn func(Event, Cursor) bool) (Cursor, error) {
+	f, seq, err := r.openActive()
+	if err != nil {
+		return c, err
+	}
+	if f != nil {
+		defer func() { _ = f.Close() }()
+	}
+
+	// A cursor from a newer generation means the log was reset.
+	if c.Seq > seq || c.Seq < 0 || fn == nil {
+		c = Cursor{Seq: seq}
+		if fn == nil {
+			c.Offset = -1
+		}
+	}
+
+	if c.Seq < seq {
+		segs, err := listSegments(r.path)
+		if err != nil {
+			return c, err
+		}
+		for _, s := range segs {
+			if s.seq < c.Seq || s.seq >= seq {
+				continue
+			}
+			start := int64(0)
+			if s.seq == c.Seq {
+				start = c.Offset
+			}
+			rc, err := openLog(s.path)
+			if err != nil {
+				if os.IsNotExist(err) {
+					continue
+				}
+				return c, fmt.Errorf("events: open: %w", err)
+			}
+			end, more, err := readLinesFrom(rc, start, s.seq, fn)
+			_ = rc.Close()
+			if err != nil {
+				return c, err
+			}
+			c = Cursor{Seq: s.seq, Offset: end}
+			if !more {
+				return c, nil
+			}
+		}
+		c = Cursor{Seq: seq}
+	}
+
+	if f == nil {
+		return Cursor{Seq: seq}, nil
+	}
+	if c.Offset < 0 {
+		// End of log requested: skip to the last complete line.
+		st, err := f.Stat()
+		if err != nil {
+			return c, fmt.Errorf("events: stat: %w", err)
+		}
+		c.Offset = lastLineEnd(f, st.Size())
+		return c, nil
+	}
+	if st, err := f.Stat(); err == nil && c.Offset > st.Size() {
+		c.Offset = 0 // truncated underneath us
+	}
+	end, _, err := readLinesFrom(f, c.Offset, seq, fn)
+	if err != nil {
+		return c, err
+	}
+	return Cursor{Seq: seq, Offset: end}, nil
+}
+
+// openActive opens the active log file and returns it with the segment
+// number it will get on rotation. The pair is consistent: if the file is
+// rotated afterwards, the handle's contents become that segment. A
+// missing file yields a nil handle.
+func (r *Reader) openActive() (*os.File, int, error) {
+	for {
+		f, err := os.Open(r.path)
+		if err != nil && !os.IsNotExist(err) {
+			return nil, 0, fmt.Errorf("events: open: %w", err)
+		}
+		m, err := readManifest(r.path)
+		if err != nil {
+			if f != nil {
+				_ = f.Close()
+			}
+			return nil, 0, err
+		}
+		seq, err := nextSeq(r.path, m)
+		if err != nil {
+			if f != nil {
+				_ = f.Close()
+			}
+			return nil, 0, err
+		}
+		if f == nil {
+			return nil, seq, nil
+		}
+		held, err1 := f.Stat()
+		cur, err2 := os.Stat(r.path)
+		if err1 == nil && err2 == nil && os.SameFile(held, cur) {
+			return f, seq, nil
+		}
+		// Rotated between open and seq lookup; try again.
+		_ = f.Close()
+	}
+}
+
+// readLinesFrom reads complete lines from rd starting at offset start and
+// calls fn with each event and the cursor after it. A trailing line with
+// no newline is left for the next read. It returns the offset after the
+// last consumed line and false if fn asked to stop.
+func readLinesFrom(rd io.Reader, start int64, seq int, fn func(Event, Cursor) bool) (int64, bool, error) {
+	if s, ok := rd.(io.Seeker); ok {
+		if _, err := s.Seek(start, io.SeekStart); err != nil {
+			return start, true, fmt.Errorf("events: seek: %w", err)
+		}
+	} else if _, err := io.CopyN(io.Discard, rd, start); err != nil && err != io.EOF {
+		return start, true, fmt.Errorf("events: skip: %w", err)
+	}
+
+	br := bufio.NewReaderSize(rd, 64*1024)
+	off := start
+	for {
+		line, err := br.ReadBytes('\n')
+		if len(line) > 0 && line[len(line)-1] == '\n' {
+			off += int64(len(line))
+			if trimmed := bytes.TrimSpace(line); len(trimmed) > 0 {
+				var ev Event
+				if json.Unmarshal(trimmed, &ev) == nil {
+					if !fn(ev, Cursor{Seq: seq, Offset: off}) {
+						return off, false, nil
+					}
+				}
+			}
+		}
+		if errors.Is(err, io.EOF) {
+			return off, true, nil
+		}
+		if err != nil {
+			return off, true, fmt.Errorf("events: read: %w", err)
+		}
+	}
+}
+
+// lastLineEnd returns the offset just past the last newline in the first
+// size bytes of f, or 0 if there is none.
+func lastLineEnd(f *os.File, size int64) int64 {
+	buf := make([]byte, 4096)
+	for pos := size; pos > 0; {
+		n := min(int64(len(buf)), pos)
+		pos -= n
+		if _, err := f.ReadAt(buf[:n], pos); err != nil && err != io.EOF {
+			return 0
+		}
+		if i := bytes.LastIndexByte(buf[:n], '\n'); i >= 0 {
+			return pos + int64(i) + 1
+		}
+	}
+	return 0
+}
@@ -1,63 +1,207 @@
 package events
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
 	"syscall"
+	"time"
 )
 
 const defaultPath = ".alt/events.jsonl"
 
 // Writer appends events to a JSONL file with flock-based concurrency safety.
+// With a RotationPolicy, the active file is rotated into numbered segments
+// ({stem}.000001.jsonl, optionally gzipped) once it grows too large or old.
 type Writer struct {
-	path string
+	path     string
+	rotation RotationPolicy
+}
+
+// WriterOption configures a Writer.
+type WriterOption func(*Writer)
+
+// WithRotation enables size- and/or age-based rotation of the log.
+func WithRotation(p RotationPolicy) WriterOption {
+	return func(w *Writer) {
+		w.rotation = p
+	}
 }
 
 // NewWriter creates a Writer that appends to the given file path.
 // If path is empty, it defaults to .alt/events.jsonl.
-func NewWriter(path string) *Writer {
+func NewWriter(path string, opts ...WriterOption) *Writer {
 	if path == "" {
 		path = defaultPath
 	}
-	return &Writer{path: path}
+	w := &Writer{path: path}
+	for _, opt := range opts {
+		opt(w)
+	}
+	return w
 }
 
 // Append writes one or more events to the log file atomically.
 // It acquires an exclusive flock, appends the events as JSONL lines,
-// then releases the lock.
+// files them in the sidecar index, rotates the file if the rotation
+// policy says it is due, then releases the lock.
 func (w *Writer) Append(events ...Event) error {
 	if len(events) == 0 {
 		return nil
 	}
 
-	if err := os.MkdirAll(filepath.Dir(w.path), 0o755); err != nil {
-		return fmt.Errorf("events: create dir: %w", err)
-	}
-
-	f, err := os.OpenFile(w.path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o644)
+	f, err := w.openLocked()
 	if err != nil {
-		return fmt.Errorf("events: open file: %w", err)
+		return err
 	}
-	defer func() { _ = f.Close() }()
+	defer func() {
+		_ = syscall.Flock(int(f.Fd()), syscall.LOCK_UN)
+		_ = f.Close()
+	}()
 
-	// Acquire exclusive lock for concurrent append safety.
-	if err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX); err != nil {
-		return fmt.Errorf("events: flock: %w", err)
+	st, err := f.Stat()
+	if err != nil {
+		return fmt.Errorf("events: stat: %w", err)
 	}
-	defer func() { _ = syscall.Flock(int(f.Fd()), syscall.LOCK_UN) }()
+	start := st.Size()
 
-	enc := json.NewEncoder(f)
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	indexed := make([]indexedEvent, 0, len(events))
 	for _, ev := range events {
+		indexed = append(indexed, indexedEvent{offset: start + int64(buf.Len()), ev: ev})
 		if err := enc.Encode(ev); err != nil {
 			return fmt.Errorf("events: encode: %w", err)
 		}
 	}
+	if _, err := f.Write(buf.Bytes()); err != nil {
+		return fmt.Errorf("events: write: %w", err)
+	}
+
+	// The index is an accelerator: readers scan past the watermark, so a
+	// failure here loses no events and must not fail the append.
+	_ = indexAppended(w.path, start, start+int64(buf.Len()), indexed)
 
+	if w.rotationDue(f) {
+		if err := w.rotateLocked(); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
+// Rotate moves the active log into a new segment regardless of the
+// rotation policy (compressing it if the policy says so). An empty or
+// missing log is left alone.
+func (w *Writer) Rotate() error {
+	f, err := w.openLocked()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = syscall.Flock(int(f.Fd()), syscall.LOCK_UN)
+		_ = f.Close()
+	}()
+	if st, err := f.Stat(); err != nil || st.Size() == 0 {
+		return nil
+	}
+	return w.rotateLocked()
+}
+
+// openLocked opens the active log for appending and takes an exclusive
+// flock on it. If another writer rotated the file while we waited for the
+// lock, the stale handle is dropped and the new active file is opened.
+func (w *Writer) openLocked() (*os.File, error) {
+	if err := os.MkdirAll(filepath.Dir(w.path), 0o755); err != nil {
+		return nil, fmt.Errorf("events: create dir: %w", err)
+	}
+	for {
+		f, err := os.OpenFile(w.path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o644)
+		if err != nil {
+			return nil, fmt.Errorf("events: open file: %w", err)
+		}
+
+		// Acquire exclusive lock for concurrent append safety.
+		if err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX); err != nil {
+			_ = f.Close()
+			return nil, fmt.Errorf("events: flock: %w", err)
+		}
+
+		held, err1 := f.Stat()
+		cur, err2 := os.Stat(w.path)
+		if err1 == nil && err2 == nil && os.SameFile(held, cur) {
+			return f, nil
+		}
+		// Rotated underneath us; retry on the fresh file.
+		_ = syscall.Flock(int(f.Fd()), syscall.LOCK_UN)
+		_ = f.Close()
+	}
+}
+
+// rotationDue reports whether the locked active file f should be rotated.
+func (w *Writer) rotationDue(f *os.File) bool {
+	if !w.rotation.enabled() {
+		return false
+	}
+	st, err := f.Stat()
+	if err != nil || st.Size() == 0 {
+		return false
+	}
+	if w.rotation.MaxBytes > 0 && st.Size() >= w.rotation.MaxBytes {
+		return true
+	}
+	if w.rotation.MaxAge > 0 {
+		if first, ok := firstTimestamp(w.path); ok && time.Since(first) >= w.rotation.MaxAge {
+			return true
+		}
+	}
+	return false
+}
+
+// rotateLocked renames the active file to the next segment, optionally
+// compresses it, and records its summary in the manifest. The caller must
+// hold the flock on the active file.
+func (w *Writer) rotateLocked() error {
+	// Bring the index up to date so the segment's postings are complete.
+	if st, err := os.Stat(w.path); err == nil {
+		_ = indexAppended(w.path, st.Size(), st.Size(), nil)
+	}
+
+	info, err := summarize(w.path)
+	if err != nil {
+		return err
+	}
+
+	m, err := readManifest(w.path)
+	if err != nil {
+		return err
+	}
+	seq, err := nextSeq(w.path, m)
+	if err != nil {
+		return err
+	}
+
+	dst := segmentPath(w.path, seq)
+	if err := os.Rename(w.path, dst); err != nil {
+		return fmt.Errorf("events: rotate: %w", err)
+	}
+	if err := rotateIndex(w.path, seq); err != nil {
+		return err
+	}
+	if w.rotation.Compress {
+		if dst, err = gzipFileAtomic(dst); err != nil {
+			return err
+		}
+	}
+
+	info.Name = filepath.Base(dst)
+	info.Seq = seq
+	m.Segments = append(m.Segments, info)
+	return writeManifest(w.path, m)
+}
+
 // Path returns the file path this writer appends to.
 func (w *Writer) Path() string {
 	return w.path
@@ -0,0 +1,478 @@
+// Package gc finds and removes what crashed agents and daemons leave
+// behind: worktrees with no live agent, branches whose tasks have merged
+// or failed, stale git worktree metadata, temp files from interrupted
+// atomic writes, old archived messages and orphaned tmux sessions.
+//
+// Collection is conservative. Unmerged work is only deleted once its task
+// has failed, worktrees with uncommitted changes are kept unless forced,
+// and tmux sessions are only killed when this project's agent records
+// name them, since other projects share the tmux server.
+package gc
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/anthropics/altera/internal/agent"
+	"github.com/anthropics/altera/internal/config"
+	"github.com/anthropics/altera/internal/git"
+	"github.com/anthropics/altera/internal/message"
+	"github.com/anthropics/altera/internal/task"
+	"github.com/anthropics/altera/internal/tmux"
+)
+
+// Kinds of garbage, in the order they are collected. Sessions and
+// worktrees go before branches, which git will not delete while checked
+// out.
+const (
+	KindSession  = "tmux-session"
+	KindWorktree = "worktree"
+	KindMetadata = "worktree-metadata"
+	KindBranch   = "branch"
+	KindTempFile = "temp-file"
+	KindMessage  = "message"
+)
+
+// TempFileAge is how old a .tmp-* file must be before it is removed, so a
+// write in progress is never touched.
+const TempFileAge = time.Hour
+
+// Branch prefixes agents work on. The rest of a worker branch name is the
+// agent ID; for the others it is the task ID.
+const (
+	workerBranchPrefix   = "worker/"
+	taskBranchPrefix     = "alt/t-"
+	resolverBranchPrefix = "alt/resolve-"
+)
+
+// Options controls a collection.
+type Options struct {
+	DryRun bool // report what would be removed without removing it
+	Force  bool // remove worktrees even if they have uncommitted changes
+
+	// MessageAge is how long archived messages, receipts and dead
+	// letters are kept; 0 keeps them forever.
+	MessageAge time.Duration
+}
+
+// Item is something removed, or that would be removed in a dry run. Kept
+// items were found but left in place; Reason says why.
+type Item struct {
+	Kind   string
+	Name   string // path, branch or session name
+	Reason string
+	Kept   bool
+}
+
+// Collector finds garbage in one project.
+type Collector struct {
+	root          string // project root (parent of .alt/)
+	altDir        string
+	repo          string
+	defaultBranch string
+
+	agents   *agent.Store
+	tasks    *task.Store
+	messages *message.Store
+}
+
+// New returns a Collector for the project at root.
+func New(root string, cfg config.Config) (*Collector, error) {
+	altDir := filepath.Join(root, config.DirName)
+	agents, err := agent.NewStore(filepath.Join(altDir, "agents"))
+	if err != nil {
+		return nil, fmt.Errorf("opening agent store: %w", err)
+	}
+	tasks, err := task.NewStore(root)
+	if err != nil {
+		return nil, fmt.Errorf("opening task store: %w", err)
+	}
+	messages, err := message.NewStore(filepath.Join(altDir, "messages"))
+	if err != nil {
+		return nil, fmt.Errorf("opening message store: %w", err)
+	}
+
+	repo := cfg.RepoPath
+	if repo == "" {
+		repo = root
+	} else if !filepath.IsAbs(repo) {
+		repo = filepath.Join(root, repo)
+	}
+	return &Collector{
+		root:          root,
+		altDir:        altDir,
+		repo:          repo,
+		defaultBranch: cfg.DefaultBranch,
+		agents:        agents,
+		tasks:         tasks,
+		messages:      messages,
+	}, nil
+}
+
+// state is what the collector knows about the project's agents and tasks.
+type state struct {
+	agents   map[string]*agent.Agent // worker and resolver records by ID
+	live     map[string]bool         // agent IDs still running
+	tasks    map[string]*task.Task
+	branches map[string]*task.Task // task by task.Branch
+	queued   map[string]bool       // branches waiting in the merge queue
+	kept     map[string]bool       // branches checked out in kept worktrees
+}
+
+// Run finds garbage and, unless opts.DryRun, removes it. It carries on
+// past individual failures and returns them joined, along with every item
+// it removed or kept.
+func (c *Collector) Run(opts Options) ([]Item, error) {
+	st, err := c.load()
+	if err != nil {
+		return nil, err
+	}
+
+	var items []Item
+	var errs []error
+	for _, step := range []func(*state, Options) ([]Item, error){
+		c.sessions,
+		c.worktrees,
+		c.metadata,
+		c.branches,
+		c.tempFiles,
+		c.oldMessages,
+	} {
+		found, err := step(st, opts)
+		items = append(items, found...)
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return items, errors.Join(errs...)
+}
+
+func (c *Collector) load() (*state, error) {
+	st := &state{
+		agents:   map[string]*agent.Agent{},
+		live:     map[string]bool{},
+		tasks:    map[string]*task.Task{},
+		branches: map[string]*task.Task{},
+		queued:   map[string]bool{},
+		kept:     map[string]bool{},
+	}
+	for _, role := range []agent.Role{agent.RoleWorker, agent.RoleResolver} {
+		agents, err := c.agents.ListByRole(role)
+		if err != nil {
+			return nil, fmt.Errorf("listing agents: %w", err)
+		}
+		for _, a := range agents {
+			st.agents[a.ID] = a
+			st.live[a.ID] = isLive(a)
+		}
+	}
+
+	tasks, err := c.tasks.List(task.Filter{})
+	if err != nil {
+		return nil, fmt.Errorf("listing tasks: %w", err)
+	}
+	for _, t := range tasks {
+		st.tasks[t.ID] = t
+		if t.Branch != "" {
+			st.branches[t.Branch] = t
+		}
+	}
+
+	entries, _ := os.ReadDir(filepath.Join(c.altDir, "merge-queue"))
+	for _, e := range entries {
+		if e.IsDir() || filepath.Ext(e.Name()) != ".json" || strings.HasPrefix(e.Name(), ".tmp-") {
+			continue
+		}
+		var item struct {
+			Branch string `json:"branch"`
+		}
+		if data, err := os.ReadFile(filepath.Join(c.altDir, "merge-queue", e.Name())); err == nil && json.Unmarshal(data, &item) == nil {
+			st.queued[item.Branch] = true
+		}
+	}
+	return st, nil
+}
+
+// isLive reports whether an agent is still running: not marked dead, and
+// with a live process or, if its PID was never read, a tmux session.
+func isLive(a *agent.Agent) bool {
+	if a.Status == agent.StatusDead {
+		return false
+	}
+	if a.PID > 0 {
+		return agent.CheckPID(a)
+	}
+	return a.TmuxSession != "" && tmux.SessionExists(a.TmuxSession)
+}
+
+// sessions finds tmux sessions that belong to agents of this project that
+// are no longer running.
+func (c *Collector) sessions(st *state, opts Options) ([]Item, error) {
+	names, err := tmux.ListSessions()
+	if errors.Is(err, exec.ErrNotFound) {
+		return nil, nil // no tmux, so no sessions
+	}
+	if err != nil {
+		return nil, err
+	}
+	exists := map[string]bool{}
+	for _, n := range names {
+		exists[n] = true
+	}
+
+	var items []Item
+	var errs []error
+	for _, id := range sortedKeys(st.agents) {
+		a := st.agents[id]
+		if a.TmuxSession == "" || !exists[a.TmuxSession] || st.live[id] {
+			continue
+		}
+		if !opts.DryRun {
+			if err := tmux.KillSession(a.TmuxSession); err != nil {
+				errs = append(errs, err)
+				continue
+			}
+		}
+		items = append(items, Item{Kind: KindSession, Name: a.TmuxSession, Reason: fmt.Sprintf("%s %s is not running", a.Role, id)})
+	}
+	return items, errors.Join(errs...)
+}
+
+// worktrees finds agent worktrees, under .alt/worktrees or worktrees/,
+// whose agent has no record or is no longer running. Those with
+// uncommitted changes are kept, along with their branches, unless
+// opts.Force.
+func (c *Collector) worktrees(st *state, opts Options) ([]Item, error) {
+	var items []Item
+	var errs []error
+	for _, dir := range []string{filepath.Join(c.altDir, "worktrees"), filepath.Join(c.root, "worktrees")} {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+		for _, e := range entries {
+			if !e.IsDir() {
+				continue
+			}
+			id := e.Name()
+			var reason string
+			switch a, ok := st.agents[id]; {
+			case !ok:
+				reason = "no agent record"
+			case !st.live[id]:
+				reason = fmt.Sprintf("%s %s is not running", a.Role, id)
+			default:
+				continue
+			}
+			path := filepath.Join(dir, id)
+			if dirty := uncommitted(path); dirty != "" && !opts.Force {
+				if branch, err := git.CurrentBranch(path); err == nil {
+					st.kept[branch] = true
+				}
+				items = append(items, Item{Kind: KindWorktree, Name: path, Reason: fmt.Sprintf("%s (%s)", dirty, reason), Kept: true})
+				continue
+			}
+			if !opts.DryRun {
+				if err := c.removeWorktree(path); err != nil {
+					errs = append(errs, err)
+					continue
+				}
+			}
+			items = append(items, Item{Kind: KindWorktree, Name: path, Reason: reason})
+		}
+	}
+	return items, errors.Join(errs...)
+}
+
+// uncommitted returns why the worktree at path may hold work not yet
+// committed, or "" if it holds none. Untracked files are ignored, as by
+// the daemon: every agent worktree has a task.json that is never
+// committed. A directory without a .git file is not a checkout.
+func uncommitted(path string) string {
+	if _, err := os.Lstat(filepath.Join(path, ".git")); os.IsNotExist(err) {
+		return ""
+	}
+	dirty, err := git.HasUncommittedChanges(path)
+	switch {
+	case err != nil:
+		return "cannot check for uncommitted changes"
+	case dirty:
+		return "uncommitted changes"
+	}
+	return ""
+}
+
+// removeWorktree removes a checkout through git, which also drops its
+// metadata, and anything else as a plain directory.
+func (c *Collector) removeWorktree(path string) error {
+	if _, err := os.Lstat(filepath.Join(path, ".git")); err == nil {
+		return git.DeleteWorktree(c.repo, path)
+	}
+	if err := os.RemoveAll(path); err != nil {
+		return fmt.Errorf("removing worktree %s: %w", path, err)
+	}
+	return nil
+}
+
+// metadata finds git worktree records whose directories are gone.
+func (c *Collector) metadata(_ *state, opts Options) ([]Item, error) {
+	pruned, err := git.PruneWorktrees(c.repo, opts.DryRun)
+	if err != nil {
+		return nil, err
+	}
+	items := make([]Item, 0, len(pruned))
+	for _, line := range pruned {
+		name, reason, _ := strings.Cut(strings.TrimPrefix(line, "Removing "), ": ")
+		items = append(items, Item{Kind: KindMetadata, Name: name, Reason: reason})
+	}
+	return items, nil
+}
+
+// branches finds agent branches that are not in use and whose work has
+// merged into the default branch or whose task has failed.
+func (c *Collector) branches(st *state, opts Options) ([]Item, error) {
+	base := c.defaultBranch
+	if base == "" || !git.RefExists(c.repo, "refs/heads/"+base) {
+		base = "HEAD"
+	}
+
+	var items []Item
+	var errs []error
+	for _, prefix := range []string{workerBranchPrefix, taskBranchPrefix, resolverBranchPrefix} {
+		names, err := git.ListBranches(c.repo, prefix)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		for _, name := range names {
+			reason := c.branchGarbage(st, prefix, name, base)
+			if reason == "" {
+				continue
+			}
+			if !opts.DryRun {
+				if err := git.DeleteBranch(c.repo, name); err != nil {
+					errs = append(errs, err)
+					continue
+				}
+			}
+			items = append(items, Item{Kind: KindBranch, Name: name, Reason: reason})
+		}
+	}
+	return items, errors.Join(errs...)
+}
+
+// branchGarbage returns why branch name can go, or "" if it must stay.
+func (c *Collector) branchGarbage(st *state, prefix, name, base string) string {
+	if st.queued[name] || st.kept[name] {
+		return ""
+	}
+	suffix := strings.TrimPrefix(name, prefix)
+
+	// Keep branches a running agent works on: a daemon worker's branch is
+	// named after it; task-named branches belong to the worker or the
+	// resolver working on that task.
+	t := st.branches[name]
+	if prefix == workerBranchPrefix {
+		if st.live[suffix] {
+			return ""
+		}
+	} else {
+		if t == nil {
+			t = st.tasks[suffix]
+		}
+		role := agent.RoleWorker
+		if prefix == resolverBranchPrefix {
+			role = agent.RoleResolver
+		}
+		for id, a := range st.agents {
+			if a.Role == role && a.CurrentTask == suffix && st.live[id] {
+				return ""
+			}
+		}
+	}
+
+	switch {
+	case t != nil && t.Status == task.StatusFailed:
+		return fmt.Sprintf("task %s failed", t.ID)
+	case t != nil && t.Status != task.StatusDone && t.Branch == name:
+		return "" // the task still expects to be merged from here
+	case git.IsAncestor(c.repo, name, base):
+		return "merged into " + base
+	}
+	return ""
+}
+
+// tempFiles finds .tmp-* files under .alt/ left by interrupted atomic
+// writes. Worktrees are skipped; they belong to the agents' repositories.
+func (c *Collector) tempFiles(_ *state, opts Options) ([]Item, error) {
+	cutoff := time.Now().Add(-TempFileAge)
+	worktrees := filepath.Join(c.altDir, "worktrees")
+
+	var items []Item
+	var errs []error
+	err := filepath.WalkDir(c.altDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil // unreadable entries are not ours to judge
+		}
+		if d.IsDir() {
+			if path == worktrees {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !strings.HasPrefix(d.Name(), ".tmp-") {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil || !info.ModTime().Before(cutoff) {
+			return nil
+		}
+		if !opts.DryRun {
+			if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+				errs = append(errs, err)
+				return nil
+			}
+		}
+		items = append(items, Item{Kind: KindTempFile, Name: path, Reason: "interrupted write"})
+		return nil
+	})
+	if err != nil {
+		errs = append(errs, err)
+	}
+	return items, errors.Join(errs...)
+}
+
+// oldMessages finds archived messages, receipts and dead letters older
+// than opts.MessageAge.
+func (c *Collector) oldMessages(_ *state, opts Options) ([]Item, error) {
+	if opts.MessageAge <= 0 {
+		return nil, nil
+	}
+	age := opts.MessageAge.String()
+	if day := 24 * time.Hour; opts.MessageAge%day == 0 {
+		age = fmt.Sprintf("%d days", opts.MessageAge/day)
+	}
+	pruned, err := c.messages.Prune(time.Now().Add(-opts.MessageAge), opts.DryRun)
+	items := make([]Item, 0, len(pruned))
+	for _, path := range pruned {
+		items = append(items, Item{Kind: KindMessage, Name: path, Reason: "older than " + age})
+	}
+	return items, err
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,302 @@
+package gc
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/anthropics/altera/internal/agent"
+	"github.com/anthropics/altera/internal/config"
+	"github.com/anthropics/altera/internal/git"
+	"github.com/anthropics/altera/internal/message"
+	"github.com/anthropics/altera/internal/task"
+	"github.com/anthropics/altera/internal/tmux"
+)
+
+func gitCmd(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %v: %v\n%s", args, err, out)
+	}
+	return string(out)
+}
+
+// setupProject returns a project root with one commit and an .alt/ dir.
+func setupProject(t *testing.T) string {
+	t.Helper()
+	tmux.UseTestSocket(t)
+	root := t.TempDir()
+	gitCmd(t, root, "init", "-q")
+	gitCmd(t, root, "config", "user.name", "test")
+	gitCmd(t, root, "config", "user.email", "test@test.local")
+	gitCmd(t, root, "commit", "-q", "--allow-empty", "-m", "initial commit")
+	if _, err := config.EnsureDir(root); err != nil {
+		t.Fatalf("EnsureDir: %v", err)
+	}
+	return root
+}
+
+// addBranch creates branch from HEAD, with a commit of its own unless
+// merged.
+func addBranch(t *testing.T, root, branch string, merged bool) {
+	t.Helper()
+	if err := git.CreateBranch(root, branch, "HEAD"); err != nil {
+		t.Fatal(err)
+	}
+	if !merged {
+		rev := strings.TrimSpace(gitCmd(t, root, "commit-tree", "-p", "HEAD", "-m", "work on "+branch, "HEAD^{tree}"))
+		gitCmd(t, root, "update-ref", "refs/heads/"+branch, rev)
+	}
+}
+
+// addWorktree creates branch from HEAD with a worktree at path, and
+// commits to it unless merged.
+func addWorktree(t *testing.T, root, branch, path string, merged bool) {
+	t.Helper()
+	if err := git.CreateBranch(root, branch, "HEAD"); err != nil {
+		t.Fatal(err)
+	}
+	if err := git.CreateWorktree(root, branch, path); err != nil {
+		t.Fatal(err)
+	}
+	if !merged {
+		gitCmd(t, path, "commit", "-q", "--allow-empty", "-m", "work on "+branch)
+	}
+}
+
+func TestRun(t *testing.T) {
+	root := setupProject(t)
+	altDir := filepath.Join(root, config.DirName)
+	agents, _ := agent.NewStore(filepath.Join(altDir, "agents"))
+	tasks, _ := task.NewStore(root)
+	messages, _ := message.NewStore(filepath.Join(altDir, "messages"))
+	wtDir := filepath.Join(altDir, "worktrees")
+
+	newTask := func(status task.Status, branch string) *task.Task {
+		t.Helper()
+		tk := &task.Task{Title: branch, Status: status, Branch: branch}
+		if err := tasks.Create(tk); err != nil {
+			t.Fatal(err)
+		}
+		return tk
+	}
+	newAgent := func(id string, role agent.Role, pid int, taskID string) *agent.Agent {
+		t.Helper()
+		a := &agent.Agent{
+			ID: id, Role: role, Status: agent.StatusActive, PID: pid, CurrentTask: taskID,
+			Worktree: filepath.Join(wtDir, id), TmuxSession: tmux.SessionName(string(role), id),
+			Heartbeat: time.Now(), StartedAt: time.Now(),
+		}
+		if pid == 0 {
+			a.Status = agent.StatusDead
+		}
+		if err := agents.Create(a); err != nil {
+			t.Fatal(err)
+		}
+		return a
+	}
+
+	// A crashed worker whose task merged: its session, worktree and branch go.
+	done := newTask(task.StatusDone, "worker/w-done")
+	crashed := newAgent("w-done", agent.RoleWorker, 0, done.ID)
+	addWorktree(t, root, "worker/w-done", crashed.Worktree, true)
+	if err := tmux.CreateSession(crashed.TmuxSession); err != nil {
+		t.Fatalf("CreateSession: %v", err)
+	}
+
+	// A running worker keeps everything, merged or not.
+	running := newTask(task.StatusInProgress, "worker/w-live")
+	live := newAgent("w-live", agent.RoleWorker, os.Getpid(), running.ID)
+	addWorktree(t, root, "worker/w-live", live.Worktree, false)
+	if err := tmux.CreateSession(live.TmuxSession); err != nil {
+		t.Fatalf("CreateSession: %v", err)
+	}
+
+	// A crashed worker's uncommitted changes keep its worktree, and with it
+	// the branch of its failed task, until forced.
+	abandoned := newTask(task.StatusFailed, "worker/w-dirty")
+	dirty := newAgent("w-dirty", agent.RoleWorker, 0, abandoned.ID)
+	addWorktree(t, root, "worker/w-dirty", dirty.Worktree, false)
+	if err := os.WriteFile(filepath.Join(dirty.Worktree, "work.go"), []byte("package work\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	gitCmd(t, dirty.Worktree, "add", "work.go")
+
+	// Unmerged work on a task still in progress stays after its worker dies.
+	newTask(task.StatusInProgress, "worker/w-stalled")
+	addBranch(t, root, "worker/w-stalled", false)
+
+	// A failed task's resolver branch goes even though it never merged.
+	failed := newTask(task.StatusFailed, "worker/w-failed")
+	addBranch(t, root, "alt/resolve-"+failed.ID, false)
+
+	// A merged branch still in the merge queue stays.
+	addBranch(t, root, "worker/w-queued", true)
+	if err := os.WriteFile(filepath.Join(altDir, "merge-queue", "q.json"), []byte(`{"branch":"worker/w-queued"}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	// A worktree directory no agent record claims.
+	ghost := filepath.Join(wtDir, "w-ghost")
+	if err := os.MkdirAll(ghost, 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	// Metadata for a worktree deleted behind git's back.
+	external := filepath.Join(t.TempDir(), "external")
+	gitCmd(t, root, "worktree", "add", "-q", "--detach", external)
+	if err := os.RemoveAll(external); err != nil {
+		t.Fatal(err)
+	}
+
+	// An interrupted write, and one that may still be in progress.
+	oldTmp := filepath.Join(altDir, "tasks", ".tmp-old")
+	newTmp := filepath.Join(altDir, "tasks", ".tmp-new")
+	for _, p := range []string{oldTmp, newTmp} {
+		if err := os.WriteFile(p, nil, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	longAgo := time.Now().Add(-60 * 24 * time.Hour)
+	_ = os.Chtimes(oldTmp, longAgo, longAgo)
+
+	// An archived message from two months ago and one from today.
+	oldMsg, _ := messages.Create(message.TypeHelp, "w-done", "liaison", "", nil)
+	_ = messages.Ack(oldMsg.ID, "liaison")
+	newMsg, _ := messages.Create(message.TypeHelp, "w-done", "liaison", "", nil)
+	_ = messages.Ack(newMsg.ID, "liaison")
+	archive, _ := filepath.Glob(filepath.Join(altDir, "messages", "archive", "*"+oldMsg.ID+"*"))
+	if len(archive) != 1 {
+		t.Fatalf("archived files for %s = %v", oldMsg.ID, archive)
+	}
+	oldArchived := archive[0]
+	_ = os.Chtimes(oldArchived, longAgo, longAgo)
+
+	branch, _ := git.HeadBranch(root)
+	cfg := config.NewConfig()
+	cfg.DefaultBranch = branch
+	c, err := New(root, cfg)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	opts := Options{DryRun: true, MessageAge: 30 * 24 * time.Hour}
+
+	want := map[string]string{
+		KindSession + " " + crashed.TmuxSession:       "worker w-done is not running",
+		KindWorktree + " " + crashed.Worktree:         "worker w-done is not running",
+		KindWorktree + " " + ghost:                    "no agent record",
+		"kept " + KindWorktree + " " + dirty.Worktree: "uncommitted changes (worker w-dirty is not running)",
+		KindBranch + " worker/w-done":                 "merged into " + branch,
+		KindBranch + " alt/resolve-" + failed.ID:      "task " + failed.ID + " failed",
+		KindTempFile + " " + oldTmp:                   "interrupted write",
+		KindMessage + " " + oldArchived:               "older than 30 days",
+	}
+	metadataWant := 1
+	check := func(items []Item) {
+		t.Helper()
+		got := map[string]string{}
+		metadata := 0
+		for _, it := range items {
+			if it.Kind == KindMetadata {
+				metadata++ // named by git's internal worktree ID
+				continue
+			}
+			key := it.Kind + " " + it.Name
+			if it.Kept {
+				key = "kept " + key
+			}
+			got[key] = it.Reason
+		}
+		if metadata != metadataWant {
+			t.Errorf("%d worktree-metadata items, want %d", metadata, metadataWant)
+		}
+		for k, reason := range want {
+			if got[k] != reason {
+				t.Errorf("%s: reason %q, want %q", k, got[k], reason)
+			}
+		}
+		for k := range got {
+			if _, ok := want[k]; !ok {
+				t.Errorf("unexpected item %s (%s)", k, got[k])
+			}
+		}
+	}
+
+	items, err := c.Run(opts)
+	if err != nil {
+		t.Fatalf("dry run: %v", err)
+	}
+	check(items)
+	if _, err := os.Stat(ghost); err != nil || !tmux.SessionExists(crashed.TmuxSession) || !git.RefExists(root, "refs/heads/worker/w-done") {
+		t.Fatal("dry run removed something")
+	}
+
+	opts.DryRun = false
+	items, err = c.Run(opts)
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	check(items)
+
+	for _, p := range []string{crashed.Worktree, ghost, oldTmp, oldArchived} {
+		if _, err := os.Stat(p); !os.IsNotExist(err) {
+			t.Errorf("%s still exists", p)
+		}
+	}
+	for _, p := range []string{live.Worktree, dirty.Worktree, newTmp} {
+		if _, err := os.Stat(p); err != nil {
+			t.Errorf("%s removed: %v", p, err)
+		}
+	}
+	if tmux.SessionExists(crashed.TmuxSession) || !tmux.SessionExists(live.TmuxSession) {
+		t.Error("wrong tmux sessions killed")
+	}
+	for name, keep := range map[string]bool{
+		"worker/w-done":            false,
+		"alt/resolve-" + failed.ID: false,
+		"worker/w-live":            true,
+		"worker/w-dirty":           true,
+		"worker/w-stalled":         true,
+		"worker/w-queued":          true,
+	} {
+		if git.RefExists(root, "refs/heads/"+name) != keep {
+			t.Errorf("branch %s: kept = %v, want %v", name, !keep, keep)
+		}
+	}
+	if list := gitCmd(t, root, "worktree", "list"); strings.Contains(list, "external") {
+		t.Errorf("stale worktree metadata not pruned:\n%s", list)
+	}
+	if recent, _ := filepath.Glob(filepath.Join(altDir, "messages", "archive", "*"+newMsg.ID+"*")); len(recent) != 1 {
+		t.Errorf("recent archived message removed: %v", recent)
+	}
+
+	// Everything left is in use, or kept for its changes.
+	items, err = c.Run(opts)
+	if err != nil || len(items) != 1 || !items[0].Kept {
+		t.Errorf("second Run = %v, %v; want only the dirty worktree kept", items, err)
+	}
+
+	opts.Force = true
+	want = map[string]string{
+		KindWorktree + " " + dirty.Worktree: "worker w-dirty is not running",
+		KindBranch + " worker/w-dirty":      "task " + abandoned.ID + " failed",
+	}
+	metadataWant = 0
+	items, err = c.Run(opts)
+	if err != nil {
+		t.Fatalf("forced Run: %v", err)
+	}
+	check(items)
+	if _, err := os.Stat(dirty.Worktree); !os.IsNotExist(err) {
+		t.Errorf("%s still exists after forced Run", dirty.Worktree)
+	}
+	if git.RefExists(root, "refs/heads/worker/w-dirty") {
+		t.Error("branch worker/w-dirty kept after forced Run")
+	}
+}
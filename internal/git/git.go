@@ -55,6 +55,30 @@ func DeleteWorktree(repo, path string) error {
 	return nil
 }
 
+// PruneWorktrees removes administrative data for worktrees whose
+// directories no longer exist and returns a line per pruned worktree.
+// With dryRun it only reports what would be pruned.
+func PruneWorktrees(repo string, dryRun bool) ([]string, error) {
+	args := []string{"worktree", "prune", "--verbose"}
+	if dryRun {
+		args = append(args, "--dry-run")
+	}
+	// prune reports on stderr, so capture it alongside stdout.
+	cmd := exec.Command("git", args...)
+	cmd.Dir = repo
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return nil, fmt.Errorf("pruning worktrees: %s: %w", strings.TrimSpace(string(out)), err)
+	}
+	var pruned []string
+	for _, line := range strings.Split(strings.TrimSpace(string(out)), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			pruned = append(pruned, line)
+		}
+	}
+	return pruned, nil
+}
+
 // --- Branch Operations ---
 
 // CreateBranch creates a new branch pointing at base. If base is empty,
@@ -94,6 +118,49 @@ func CurrentBranch(path string) (string, error) {
 	return out, nil
 }
 
+// HeadBranch returns the branch HEAD points at. Unlike CurrentBranch it
+// works in a repository with no commits yet.
+func HeadBranch(path string) (string, error) {
+	out, err := run(path, "symbolic-ref", "--short", "HEAD")
+	if err != nil {
+		return "", fmt.Errorf("getting HEAD branch: %w", err)
+	}
+	return out, nil
+}
+
+// IsRepo reports whether path is inside a git repository.
+func IsRepo(path string) bool {
+	_, err := run(path, "rev-parse", "--git-dir")
+	return err == nil
+}
+
+// RefExists reports whether ref, such as "refs/heads/main", exists in repo.
+func RefExists(repo, ref string) bool {
+	_, err := run(repo, "rev-parse", "--verify", "--quiet", ref)
+	return err == nil
+}
+
+// HasRemote reports whether repo has a remote called name.
+func HasRemote(repo, name string) bool {
+	_, err := run(repo, "remote", "get-url", name)
+	return err == nil
+}
+
+// Fetch updates the remote-tracking ref for branch from remote.
+func Fetch(repo, remote, branch string) error {
+	_, err := run(repo, "fetch", "--quiet", remote, branch)
+	if err != nil {
+		return fmt.Errorf("fetching %s from %s: %w", branch, remote, err)
+	}
+	return nil
+}
+
+// IsAncestor reports whether ancestor is reachable from rev.
+func IsAncestor(repo, ancestor, rev string) bool {
+	_, err := run(repo, "merge-base", "--is-ancestor", ancestor, rev)
+	return err == nil
+}
+
 // --- Merge ---
 
 // MergeResult holds the outcome of a merge operation.
@@ -137,6 +204,23 @@ func AbortMerge(path string) error {
 	return nil
 }
 
+// Rebase rebases the branch checked out at path onto upstream. On a
+// conflict the rebase is aborted, leaving the branch as it was, and the
+// conflicting paths are returned with ErrConflict.
+func Rebase(path, upstream string) ([]string, error) {
+	_, err := run(path, "rebase", "--quiet", upstream)
+	if err == nil {
+		return nil, nil
+	}
+
+	statusOut, _ := run(path, "diff", "--name-only", "--diff-filter=U")
+	_, _ = run(path, "rebase", "--abort")
+	if statusOut == "" {
+		return nil, fmt.Errorf("rebasing onto %q: %w", upstream, err)
+	}
+	return strings.Split(statusOut, "\n"), ErrConflict
+}
+
 // --- Status ---
 
 // IsClean returns true if the working tree and index have no modifications,
@@ -1,6 +1,7 @@
 package git
 
 import (
+	"errors"
 	"os"
 	"path/filepath"
 	"strings"
@@ -151,6 +152,35 @@ func TestCurrentBranch(t *testing.T) {
 
 // --- Worktree Operations ---
 
+func TestHeadBranchAndRefExists(t *testing.T) {
+	empty := t.TempDir()
+	if IsRepo(empty) {
+		t.Error("IsRepo = true for a plain directory")
+	}
+	if err := Init(empty); err != nil {
+		t.Fatal(err)
+	}
+	// HeadBranch works before the first commit.
+	if _, err := HeadBranch(empty); err != nil {
+		t.Errorf("HeadBranch on unborn repo: %v", err)
+	}
+
+	dir := initRepo(t)
+	if !IsRepo(dir) {
+		t.Error("IsRepo = false for a repo")
+	}
+	head, err := HeadBranch(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !RefExists(dir, "refs/heads/"+head) {
+		t.Errorf("RefExists(refs/heads/%s) = false", head)
+	}
+	if RefExists(dir, "refs/heads/no-such-branch") {
+		t.Error("RefExists = true for a missing branch")
+	}
+}
+
 func TestCreateAndDeleteWorktree(t *testing.T) {
 	repo := initRepo(t)
 
@@ -433,6 +463,77 @@ func TestMerge_Conflict(t *testing.T) {
 	}
 }
 
+func TestRebase(t *testing.T) {
+	repo := initRepo(t)
+	mainBranch := defaultBranch(t, repo)
+
+	_ = CreateBranch(repo, "topic", "")
+	_ = Checkout(repo, "topic")
+	writeFile(t, repo, "topic.txt", "topic\n")
+	_ = Add(repo, nil)
+	_ = Commit(repo, "topic change")
+
+	_ = Checkout(repo, mainBranch)
+	writeFile(t, repo, "main.txt", "main\n")
+	_ = Add(repo, nil)
+	_ = Commit(repo, "main change")
+
+	if IsAncestor(repo, mainBranch, "topic") {
+		t.Fatal("main is already an ancestor of topic")
+	}
+	_ = Checkout(repo, "topic")
+	if conflicts, err := Rebase(repo, mainBranch); err != nil || conflicts != nil {
+		t.Fatalf("Rebase = %v, %v", conflicts, err)
+	}
+	if !IsAncestor(repo, mainBranch, "topic") {
+		t.Error("main is not an ancestor of topic after rebase")
+	}
+
+	// A conflicting rebase is aborted and leaves the branch untouched.
+	_ = Checkout(repo, mainBranch)
+	writeFile(t, repo, "topic.txt", "main's version\n")
+	_ = Add(repo, nil)
+	_ = Commit(repo, "conflicting change")
+	_ = Checkout(repo, "topic")
+	before, _ := Rev(repo, "HEAD")
+	conflicts, err := Rebase(repo, mainBranch)
+	if !errors.Is(err, ErrConflict) || len(conflicts) != 1 || conflicts[0] != "topic.txt" {
+		t.Fatalf("Rebase = %v, %v; want topic.txt conflict", conflicts, err)
+	}
+	if after, _ := Rev(repo, "HEAD"); after != before {
+		t.Errorf("HEAD moved from %s to %s after aborted rebase", before, after)
+	}
+	if clean, _ := IsClean(repo); !clean {
+		t.Error("working tree not clean after aborted rebase")
+	}
+}
+
+func TestFetchAndHasRemote(t *testing.T) {
+	upstream := initRepo(t)
+	branch := defaultBranch(t, upstream)
+	repo := initRepo(t)
+
+	if HasRemote(repo, "origin") {
+		t.Fatal("HasRemote(origin) before adding it")
+	}
+	if _, err := run(repo, "remote", "add", "origin", upstream); err != nil {
+		t.Fatal(err)
+	}
+	if !HasRemote(repo, "origin") {
+		t.Fatal("HasRemote(origin) = false")
+	}
+	if err := Fetch(repo, "origin", branch); err != nil {
+		t.Fatalf("Fetch: %v", err)
+	}
+	want, _ := Rev(upstream, "HEAD")
+	if got, _ := Rev(repo, "origin/"+branch); got != want {
+		t.Errorf("origin/%s = %s, want %s", branch, got, want)
+	}
+	if err := Fetch(repo, "origin", "no-such-branch"); err == nil {
+		t.Error("expected error fetching a missing branch")
+	}
+}
+
 // --- Rev ---
 
 func TestRev(t *testing.T) {
@@ -71,6 +71,16 @@ func (m *Manager) StartLiaison() error {
 
 	// Start terminal logging if debug mode is enabled.
 	altDir := filepath.Join(m.projectRoot, config.DirName)
+	cfg, err := config.Load(altDir)
+	if err != nil {
+		_ = tmux.KillSession(SessionName)
+		return fmt.Errorf("loading config: %w", err)
+	}
+	profile, err := agent.Profile(cfg, agent.RoleLiaison, "")
+	if err != nil {
+		_ = tmux.KillSession(SessionName)
+		return err
+	}
 	if config.DebugEnabled(altDir) {
 		logsDir := config.LogsDir(altDir)
 		_ = os.MkdirAll(logsDir, 0o755)
@@ -78,8 +88,12 @@ func (m *Manager) StartLiaison() error {
 		_ = tmux.StartLogging(SessionName, logPath)
 	}
 
-	// Start Claude Code in the session.
-	claudeCmd := fmt.Sprintf("cd %s && env -u CLAUDECODE claude --dangerously-skip-permissions", m.projectRoot)
+	// Start the liaison's agent profile (Claude Code by default) in the session.
+	claudeCmd, err := agent.LaunchCommand(profile, agent.LaunchData{AgentID: AgentID, Role: agent.RoleLiaison, Dir: m.projectRoot})
+	if err != nil {
+		_ = tmux.KillSession(SessionName)
+		return err
+	}
 	if err := tmux.SendKeys(SessionName, claudeCmd); err != nil {
 		_ = tmux.KillSession(SessionName)
 		return fmt.Errorf("starting claude code: %w", err)
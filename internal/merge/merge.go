@@ -151,9 +151,9 @@ func (p *Pipeline) AttemptMerge(taskID string, defaultBranch string, testCommand
 				},
 			})
 
-			_, _ = p.messages.Create(message.TypeMergeResult, "merge-pipeline", t.AssignedTo, taskID, map[string]any{
-				"outcome": string(OutcomeTestFailure),
-				"output":  testOutput,
+			_, _ = p.messages.Send("merge-pipeline", t.AssignedTo, taskID, message.MergeResultPayload{
+				Outcome: string(OutcomeTestFailure),
+				Output:  testOutput,
 			})
 
 			return &Result{
@@ -176,8 +176,9 @@ func (p *Pipeline) AttemptMerge(taskID string, defaultBranch string, testCommand
 		Data:      map[string]any{"branch": t.Branch},
 	})
 
-	_, _ = p.messages.Create(message.TypeMergeResult, "merge-pipeline", t.AssignedTo, taskID, map[string]any{
-		"outcome": string(OutcomeSuccess),
+	_, _ = p.messages.Send("merge-pipeline", t.AssignedTo, taskID, message.MergeResultPayload{
+		Success: true,
+		Outcome: string(OutcomeSuccess),
 	})
 
 	return &Result{
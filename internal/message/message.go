@@ -8,6 +8,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
 	"sort"
 	"strings"
 	"time"
@@ -24,6 +25,49 @@ const (
 	TypeUserMessage Type = "user_message"
 )
 
+// Address prefixes for group messages. A message's To field is either a
+// concrete agent ID, a role address ("role:worker"), a topic address
+// ("topic:main-changed") or the broadcast address "*".
+const (
+	AddrBroadcast   = "*"
+	AddrRolePrefix  = "role:"
+	AddrTopicPrefix = "topic:"
+)
+
+// ToRole returns the address of every agent with the given role.
+func ToRole(role string) string { return AddrRolePrefix + role }
+
+// ToTopic returns the address of every agent subscribed to topic.
+func ToTopic(topic string) string { return AddrTopicPrefix + topic }
+
+// IsGroupAddress reports whether to addresses more than one agent.
+func IsGroupAddress(to string) bool {
+	return to == AddrBroadcast || strings.HasPrefix(to, AddrRolePrefix) || strings.HasPrefix(to, AddrTopicPrefix)
+}
+
+// Priority orders pending messages; higher values are delivered first.
+type Priority int
+
+const (
+	PriorityLow    Priority = -10
+	PriorityNormal Priority = 0
+	PriorityHigh   Priority = 10
+)
+
+// ParsePriority converts "low", "normal" or "high" to a Priority.
+func ParsePriority(s string) (Priority, error) {
+	switch s {
+	case "low":
+		return PriorityLow, nil
+	case "", "normal":
+		return PriorityNormal, nil
+	case "high":
+		return PriorityHigh, nil
+	default:
+		return 0, fmt.Errorf("unknown priority %q (want low, normal or high)", s)
+	}
+}
+
 // Message is the data model for inter-agent communication.
 type Message struct {
 	ID        string         `json:"id"`
@@ -33,11 +77,59 @@ type Message struct {
 	TaskID    string         `json:"task_id,omitempty"`
 	Payload   map[string]any `json:"payload,omitempty"`
 	CreatedAt time.Time      `json:"created_at"`
+	Priority  Priority       `json:"priority,omitempty"`
+	ExpiresAt time.Time      `json:"expires_at,omitzero"`
+	Attempts  int            `json:"attempts,omitempty"`
+	LastError string         `json:"last_error,omitempty"`
+
+	// Recipients lists the agents a group message was addressed to when
+	// it was sent. Once each has acked it (or died) the message is
+	// archived.
+	Recipients []string `json:"recipients,omitempty"`
+
+	// DeadReason is set when the message is moved to the dead-letter
+	// directory and explains why it could not be delivered.
+	DeadReason string    `json:"dead_reason,omitempty"`
+	DeadAt     time.Time `json:"dead_at,omitzero"`
+}
+
+// Expired reports whether the message has a TTL that has elapsed at now.
+func (m *Message) Expired(now time.Time) bool {
+	return !m.ExpiresAt.IsZero() && !now.Before(m.ExpiresAt)
+}
+
+// CreateOption configures optional fields of a new message.
+type CreateOption func(*Message)
+
+// WithPriority sets the delivery priority of a message.
+func WithPriority(p Priority) CreateOption {
+	return func(m *Message) {
+		m.Priority = p
+	}
+}
+
+// WithTTL makes the message expire ttl after creation. Expired messages
+// are no longer returned by ListPending and are moved to the dead-letter
+// directory by ExpireStale. A zero ttl means no expiry.
+func WithTTL(ttl time.Duration) CreateOption {
+	return func(m *Message) {
+		if ttl > 0 {
+			m.ExpiresAt = m.CreatedAt.Add(ttl)
+		}
+	}
+}
+
+// IsGroup reports whether the message is addressed to a role, topic or
+// broadcast rather than a single agent.
+func (m *Message) IsGroup() bool {
+	return IsGroupAddress(m.To)
 }
 
 var (
-	ErrNotFound    = errors.New("message not found")
-	ErrInvalidType = errors.New("invalid message type")
+	ErrNotFound       = errors.New("message not found")
+	ErrInvalidType    = errors.New("invalid message type")
+	ErrInvalidAddress = errors.New("invalid message address")
+	ErrExpired        = errors.New("message expired")
 )
 
 // validTypes enumerates the accepted message types.
@@ -49,23 +141,57 @@ var validTypes = map[Type]bool{
 	TypeUserMessage: true,
 }
 
+// Recipient is an agent as seen by group addressing.
+type Recipient struct {
+	ID        string
+	Role      string
+	StartedAt time.Time
+	Dead      bool
+}
+
+// Directory resolves group addresses against the project's agents. The
+// agent store implements it.
+type Directory interface {
+	// Recipient returns the agent with the given ID, or false if there
+	// is no such agent.
+	Recipient(id string) (Recipient, bool)
+	// Recipients returns every agent that is not dead.
+	Recipients() ([]Recipient, error)
+}
+
 // Store manages message persistence in the filesystem.
+//
+// Direct messages are archived by their single recipient. Group messages
+// (role, topic, broadcast) record the agents they reached when sent; each
+// recipient acks with a receipt in the receipts subdirectory, and the
+// message is archived once all of them have.
 type Store struct {
-	dir string // e.g. ".alt/messages"
+	dir       string    // e.g. ".alt/messages"
+	directory Directory // nil = role addresses never match
 }
 
 // NewStore creates a Store rooted at the given directory.
-// The directory and its archive subdirectory are created if they do not exist.
+// The directory and its archive, dead-letter, receipts and subscriptions
+// subdirectories are created if they do not exist.
 func NewStore(dir string) (*Store, error) {
 	if err := os.MkdirAll(dir, 0o755); err != nil {
 		return nil, fmt.Errorf("create message store dir: %w", err)
 	}
-	if err := os.MkdirAll(filepath.Join(dir, "archive"), 0o755); err != nil {
-		return nil, fmt.Errorf("create message archive dir: %w", err)
+	for _, sub := range []string{"archive", deadLetterDir, "receipts", "subscriptions"} {
+		if err := os.MkdirAll(filepath.Join(dir, sub), 0o755); err != nil {
+			return nil, fmt.Errorf("create message %s dir: %w", sub, err)
+		}
 	}
 	return &Store{dir: dir}, nil
 }
 
+// SetDirectory installs the agent lookup used to resolve group addresses.
+// Without one, role-addressed messages are never delivered and group
+// messages are never archived.
+func (s *Store) SetDirectory(d Directory) {
+	s.directory = d
+}
+
 // generateID produces an ID in the form m-{random6chars}.
 func generateID() (string, error) {
 	b := make([]byte, 3) // 3 bytes = 6 hex chars
@@ -107,11 +233,22 @@ func writeAtomic(path string, data []byte) error {
 }
 
 // Create persists a new message. The ID and CreatedAt fields are set
-// automatically. Returns the created message.
-func (s *Store) Create(msgType Type, from, to, taskID string, payload map[string]any) (*Message, error) {
+// automatically; opts set priority and TTL. The payload must match the
+// schema for msgType (see ParsePayload); otherwise ErrInvalidPayload is
+// returned. Returns the created message.
+//
+// A group message is addressed to the agents it matches now. If it
+// matches none it goes straight to the archive.
+func (s *Store) Create(msgType Type, from, to, taskID string, payload map[string]any, opts ...CreateOption) (*Message, error) {
 	if !validTypes[msgType] {
 		return nil, ErrInvalidType
 	}
+	if err := validateAddress(to); err != nil {
+		return nil, err
+	}
+	if err := validatePayload(msgType, payload); err != nil {
+		return nil, err
+	}
 	id, err := generateID()
 	if err != nil {
 		return nil, err
@@ -125,20 +262,80 @@ func (s *Store) Create(msgType Type, from, to, taskID string, payload map[string
 		Payload:   payload,
 		CreatedAt: time.Now(),
 	}
-	data, err := json.MarshalIndent(m, "", "  ")
+	for _, opt := range opts {
+		opt(m)
+	}
+	dir, err := s.address(m)
 	if err != nil {
-		return nil, fmt.Errorf("marshal message: %w", err)
+		return nil, err
 	}
-	if err := writeAtomic(filepath.Join(s.dir, filename(m)), data); err != nil {
+	if err := s.write(dir, m); err != nil {
 		return nil, err
 	}
 	return m, nil
 }
 
+// address sets the recipients of a group message and returns the
+// directory m belongs in: the store, or the archive if nobody can receive
+// it.
+func (s *Store) address(m *Message) (string, error) {
+	m.Recipients = nil
+	if !m.IsGroup() || s.directory == nil {
+		return s.dir, nil
+	}
+	ids, err := s.resolve(m)
+	if err != nil {
+		return "", err
+	}
+	if len(ids) == 0 {
+		return filepath.Join(s.dir, "archive"), nil
+	}
+	m.Recipients = ids
+	return s.dir, nil
+}
+
+// resolve returns the IDs of the live agents a group message addresses,
+// other than its sender.
+func (s *Store) resolve(m *Message) ([]string, error) {
+	agents, err := s.directory.Recipients()
+	if err != nil {
+		return nil, fmt.Errorf("resolve recipients: %w", err)
+	}
+	var ids []string
+	for _, a := range agents {
+		if a.ID == m.From || a.Dead {
+			continue
+		}
+		topics, err := s.Subscriptions(a.ID)
+		if err != nil {
+			return nil, err
+		}
+		if matchesGroup(m.To, a.Role, topics) {
+			ids = append(ids, a.ID)
+		}
+	}
+	sort.Strings(ids)
+	return ids, nil
+}
+
+// write persists m under dir using its canonical filename.
+func (s *Store) write(dir string, m *Message) error {
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal message: %w", err)
+	}
+	return writeAtomic(filepath.Join(dir, filename(m)), data)
+}
+
 // findFile scans the store directory for a file whose name ends with -{id}.json.
 func (s *Store) findFile(id string) (string, error) {
+	return findIn(s.dir, id)
+}
+
+// findIn scans dir for a file whose name ends with -{id}.json.
+func findIn(dir, id string) (string, error) {
 	suffix := "-" + id + ".json"
-	entries, err := os.ReadDir(s.dir)
+	entries, err := os.ReadDir(dir)
 	if err != nil {
 		return "", fmt.Errorf("read message dir: %w", err)
 	}
@@ -147,18 +344,14 @@ func (s *Store) findFile(id string) (string, error) {
 			continue
 		}
 		if strings.HasSuffix(e.Name(), suffix) {
-			return filepath.Join(s.dir, e.Name()), nil
+			return filepath.Join(dir, e.Name()), nil
 		}
 	}
 	return "", ErrNotFound
 }
 
-// Get reads a message by ID. Returns ErrNotFound if absent.
-func (s *Store) Get(id string) (*Message, error) {
-	path, err := s.findFile(id)
-	if err != nil {
-		return nil, err
-	}
+// readFile reads and parses one message file.
+func readFile(path string) (*Message, error) {
 	data, err := os.ReadFile(path)
 	if err != nil {
 		return nil, fmt.Errorf("read message file: %w", err)
@@ -170,6 +363,42 @@ func (s *Store) Get(id string) (*Message, error) {
 	return &m, nil
 }
 
+// readDir returns every parseable message in dir in filename (timestamp)
+// order. Corrupt files are skipped.
+func readDir(dir string) ([]*Message, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("read message dir: %w", err)
+	}
+
+	// Sort entries by name to guarantee timestamp ordering.
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Name() < entries[j].Name()
+	})
+
+	var msgs []*Message
+	for _, e := range entries {
+		if e.IsDir() || filepath.Ext(e.Name()) != ".json" || strings.HasPrefix(e.Name(), ".tmp-") {
+			continue
+		}
+		m, err := readFile(filepath.Join(dir, e.Name()))
+		if err != nil {
+			continue
+		}
+		msgs = append(msgs, m)
+	}
+	return msgs, nil
+}
+
+// Get reads a message by ID. Returns ErrNotFound if absent.
+func (s *Store) Get(id string) (*Message, error) {
+	path, err := s.findFile(id)
+	if err != nil {
+		return nil, err
+	}
+	return readFile(path)
+}
+
 // Delete removes a message by ID. Returns ErrNotFound if absent.
 func (s *Store) Delete(id string) error {
 	path, err := s.findFile(id)
@@ -182,40 +411,259 @@ func (s *Store) Delete(id string) error {
 	return nil
 }
 
+// List returns every pending message regardless of recipient, ordered by
+// timestamp (oldest first).
+func (s *Store) List() ([]*Message, error) {
+	return readDir(s.dir)
+}
+
 // ListPending returns all messages addressed to the given recipient,
-// ordered by timestamp (oldest first). The ordering is naturally
-// provided by the filename prefix (Unix nanos).
+// ordered by priority (highest first) and then by timestamp (oldest
+// first). Timestamp ordering is naturally provided by the filename
+// prefix (Unix nanos). Expired messages are skipped.
+//
+// Besides direct messages, the result includes group messages the
+// recipient matches (its role, a subscribed topic, or broadcast) and has
+// not yet acknowledged. A recipient never receives its own group messages,
+// nor those sent before it started unless it was addressed.
 func (s *Store) ListPending(to string) ([]*Message, error) {
-	entries, err := os.ReadDir(s.dir)
+	all, err := readDir(s.dir)
 	if err != nil {
-		return nil, fmt.Errorf("read message dir: %w", err)
+		return nil, err
 	}
 
-	// Sort entries by name to guarantee timestamp ordering.
-	sort.Slice(entries, func(i, j int) bool {
-		return entries[i].Name() < entries[j].Name()
-	})
+	var self Recipient
+	if s.directory != nil {
+		self, _ = s.directory.Recipient(to)
+	}
+	topics, err := s.Subscriptions(to)
+	if err != nil {
+		return nil, err
+	}
 
+	now := time.Now()
 	var msgs []*Message
-	for _, e := range entries {
-		if e.IsDir() || filepath.Ext(e.Name()) != ".json" {
+	for _, m := range all {
+		if m.Expired(now) {
 			continue
 		}
-		data, err := os.ReadFile(filepath.Join(s.dir, e.Name()))
-		if err != nil {
+		if m.To == to {
+			msgs = append(msgs, m)
 			continue
 		}
-		var m Message
-		if err := json.Unmarshal(data, &m); err != nil {
+		if !m.IsGroup() || m.From == to || !matchesGroup(m.To, self.Role, topics) {
 			continue
 		}
-		if m.To == to {
-			msgs = append(msgs, &m)
+		if m.CreatedAt.Before(self.StartedAt) && !slices.Contains(m.Recipients, to) {
+			continue
 		}
+		if s.acked(m.ID, to) {
+			continue
+		}
+		msgs = append(msgs, m)
 	}
+
+	// Stable sort keeps timestamp order within a priority.
+	sort.SliceStable(msgs, func(i, j int) bool {
+		return msgs[i].Priority > msgs[j].Priority
+	})
 	return msgs, nil
 }
 
+// matchesGroup reports whether a recipient with the given role and topic
+// subscriptions is addressed by the group address to.
+func matchesGroup(to, role string, topics []string) bool {
+	switch {
+	case to == AddrBroadcast:
+		return true
+	case strings.HasPrefix(to, AddrRolePrefix):
+		return role != "" && strings.TrimPrefix(to, AddrRolePrefix) == role
+	case strings.HasPrefix(to, AddrTopicPrefix):
+		topic := strings.TrimPrefix(to, AddrTopicPrefix)
+		for _, t := range topics {
+			if t == topic {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// validateAddress rejects empty recipients and group addresses without a
+// role or topic name.
+func validateAddress(to string) error {
+	switch {
+	case to == "":
+		return fmt.Errorf("%w: empty recipient", ErrInvalidAddress)
+	case to == AddrRolePrefix || to == AddrTopicPrefix:
+		return fmt.Errorf("%w: %q has no name", ErrInvalidAddress, to)
+	}
+	return nil
+}
+
+// Ack records that recipient has consumed the message. Direct messages are
+// archived; group messages get a per-recipient receipt so other recipients
+// still see them, and are archived once every recipient has acked. Returns
+// ErrNotFound if the message does not exist.
+func (s *Store) Ack(id, recipient string) error {
+	m, err := s.Get(id)
+	if err != nil {
+		return err
+	}
+	if !m.IsGroup() {
+		return s.Archive(id)
+	}
+	path := s.receiptPath(id, recipient)
+	if err := writeAtomic(path, []byte(time.Now().UTC().Format(time.RFC3339Nano)+"\n")); err != nil {
+		return fmt.Errorf("write receipt: %w", err)
+	}
+	_, err = s.retire(m)
+	return err
+}
+
+// retire archives a group message once each of its recipients has acked
+// it or is gone, and reports whether it did. Messages sent without a
+// directory record no recipients and are never retired.
+func (s *Store) retire(m *Message) (bool, error) {
+	if len(m.Recipients) == 0 || s.directory == nil {
+		return false, nil
+	}
+	for _, id := range m.Recipients {
+		if s.acked(m.ID, id) {
+			continue
+		}
+		if a, ok := s.directory.Recipient(id); ok && !a.Dead {
+			return false, nil
+		}
+	}
+	if err := s.Archive(m.ID); err != nil && !errors.Is(err, ErrNotFound) {
+		return false, err
+	}
+	return true, nil
+}
+
+// RetireDelivered archives every pending group message whose remaining
+// recipients have all died without acking it, and returns them.
+func (s *Store) RetireDelivered() ([]*Message, error) {
+	all, err := readDir(s.dir)
+	if err != nil {
+		return nil, err
+	}
+	var retired []*Message
+	for _, m := range all {
+		if !m.IsGroup() {
+			continue
+		}
+		ok, err := s.retire(m)
+		if err != nil {
+			return retired, err
+		}
+		if ok {
+			retired = append(retired, m)
+		}
+	}
+	return retired, nil
+}
+
+// Receipts returns the IDs of recipients that have acknowledged the given
+// group message.
+func (s *Store) Receipts(id string) ([]string, error) {
+	entries, err := os.ReadDir(filepath.Join(s.dir, "receipts"))
+	if err != nil {
+		return nil, fmt.Errorf("read receipts dir: %w", err)
+	}
+	prefix := id + "."
+	var out []string
+	for _, e := range entries {
+		if strings.HasPrefix(e.Name(), prefix) {
+			out = append(out, strings.TrimPrefix(e.Name(), prefix))
+		}
+	}
+	sort.Strings(out)
+	return out, nil
+}
+
+// receiptPath returns the marker file recording that recipient consumed id.
+func (s *Store) receiptPath(id, recipient string) string {
+	return filepath.Join(s.dir, "receipts", id+"."+recipient)
+}
+
+// acked reports whether recipient has a receipt for message id.
+func (s *Store) acked(id, recipient string) bool {
+	_, err := os.Stat(s.receiptPath(id, recipient))
+	return err == nil
+}
+
+// Subscriptions returns the topics the given agent is subscribed to.
+func (s *Store) Subscriptions(agentID string) ([]string, error) {
+	data, err := os.ReadFile(s.subscriptionPath(agentID))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("read subscriptions: %w", err)
+	}
+	var topics []string
+	if err := json.Unmarshal(data, &topics); err != nil {
+		return nil, fmt.Errorf("unmarshal subscriptions: %w", err)
+	}
+	return topics, nil
+}
+
+// Subscribe adds topic to the agent's subscriptions. Subscribing twice is
+// a no-op.
+func (s *Store) Subscribe(agentID, topic string) error {
+	if topic == "" {
+		return fmt.Errorf("%w: empty topic", ErrInvalidAddress)
+	}
+	topics, err := s.Subscriptions(agentID)
+	if err != nil {
+		return err
+	}
+	for _, t := range topics {
+		if t == topic {
+			return nil
+		}
+	}
+	topics = append(topics, topic)
+	sort.Strings(topics)
+	return s.writeSubscriptions(agentID, topics)
+}
+
+// Unsubscribe removes topic from the agent's subscriptions.
+func (s *Store) Unsubscribe(agentID, topic string) error {
+	topics, err := s.Subscriptions(agentID)
+	if err != nil {
+		return err
+	}
+	kept := topics[:0]
+	for _, t := range topics {
+		if t != topic {
+			kept = append(kept, t)
+		}
+	}
+	if len(kept) == 0 {
+		err := os.Remove(s.subscriptionPath(agentID))
+		if err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("remove subscriptions: %w", err)
+		}
+		return nil
+	}
+	return s.writeSubscriptions(agentID, kept)
+}
+
+func (s *Store) subscriptionPath(agentID string) string {
+	return filepath.Join(s.dir, "subscriptions", agentID+".json")
+}
+
+func (s *Store) writeSubscriptions(agentID string, topics []string) error {
+	data, err := json.MarshalIndent(topics, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal subscriptions: %w", err)
+	}
+	return writeAtomic(s.subscriptionPath(agentID), data)
+}
+
 // Archive moves a message to the archive subdirectory.
 // Returns ErrNotFound if the message does not exist.
 func (s *Store) Archive(id string) error {
@@ -229,3 +677,169 @@ func (s *Store) Archive(id string) error {
 	}
 	return nil
 }
+
+// --- Delivery failures and dead letters ---
+
+// deadLetterDir is the subdirectory holding undeliverable messages.
+const deadLetterDir = "dead-letter"
+
+// RecordFailure increments the message's attempt counter and records the
+// handler error. It returns the updated message so callers can compare
+// Attempts against their retry limit.
+func (s *Store) RecordFailure(id, reason string) (*Message, error) {
+	path, err := s.findFile(id)
+	if err != nil {
+		return nil, err
+	}
+	m, err := readFile(path)
+	if err != nil {
+		return nil, err
+	}
+	m.Attempts++
+	m.LastError = reason
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("marshal message: %w", err)
+	}
+	if err := writeAtomic(path, data); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// DeadLetter moves a pending message to the dead-letter directory,
+// recording why it could not be delivered. Returns ErrNotFound if absent.
+func (s *Store) DeadLetter(id, reason string) error {
+	path, err := s.findFile(id)
+	if err != nil {
+		return err
+	}
+	m, err := readFile(path)
+	if err != nil {
+		return err
+	}
+	m.DeadReason = reason
+	m.DeadAt = time.Now().UTC()
+	if err := s.write(filepath.Join(s.dir, deadLetterDir), m); err != nil {
+		return fmt.Errorf("write dead letter: %w", err)
+	}
+	if err := os.Remove(path); err != nil {
+		return fmt.Errorf("remove dead-lettered message: %w", err)
+	}
+	return nil
+}
+
+// ListDeadLetters returns all dead-lettered messages, oldest first.
+func (s *Store) ListDeadLetters() ([]*Message, error) {
+	return readDir(filepath.Join(s.dir, deadLetterDir))
+}
+
+// GetDeadLetter reads a dead-lettered message by ID.
+func (s *Store) GetDeadLetter(id string) (*Message, error) {
+	path, err := findIn(filepath.Join(s.dir, deadLetterDir), id)
+	if err != nil {
+		return nil, err
+	}
+	return readFile(path)
+}
+
+// Replay moves a dead-lettered message back into the pending store. The
+// attempt counter, error, dead-letter fields and expiry are cleared so the
+// message gets a fresh delivery cycle. If to is non-empty the message is
+// readdressed. A group message is addressed afresh to the agents it
+// matches now. Returns ErrNotFound if no such dead letter exists.
+func (s *Store) Replay(id, to string) (*Message, error) {
+	path, err := findIn(filepath.Join(s.dir, deadLetterDir), id)
+	if err != nil {
+		return nil, err
+	}
+	m, err := readFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if to != "" {
+		if err := validateAddress(to); err != nil {
+			return nil, err
+		}
+		m.To = to
+	}
+	m.Attempts = 0
+	m.LastError = ""
+	m.DeadReason = ""
+	m.DeadAt = time.Time{}
+	m.ExpiresAt = time.Time{}
+	dir, err := s.address(m)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.write(dir, m); err != nil {
+		return nil, fmt.Errorf("write replayed message: %w", err)
+	}
+	if err := os.Remove(path); err != nil {
+		return nil, fmt.Errorf("remove dead letter: %w", err)
+	}
+	return m, nil
+}
+
+// ExpireStale moves every pending message whose TTL has elapsed at now to
+// the dead-letter directory and returns the expired messages.
+func (s *Store) ExpireStale(now time.Time) ([]*Message, error) {
+	all, err := readDir(s.dir)
+	if err != nil {
+		return nil, err
+	}
+	var expired []*Message
+	for _, m := range all {
+		if !m.Expired(now) {
+			continue
+		}
+		if err := s.DeadLetter(m.ID, ErrExpired.Error()); err != nil {
+			if errors.Is(err, ErrNotFound) {
+				continue // consumed concurrently
+			}
+			return expired, err
+		}
+		expired = append(expired, m)
+	}
+	return expired, nil
+}
+
+// --- Retention ---
+
+// Prune removes archived messages, dead letters and receipts last written
+// before cutoff and returns their paths. A receipt is only removed once
+// its group message has left the store, since it is what stops the message
+// being delivered again. With dryRun nothing is removed.
+func (s *Store) Prune(cutoff time.Time, dryRun bool) ([]string, error) {
+	var pruned []string
+	for _, sub := range []string{"archive", deadLetterDir, "receipts"} {
+		dir := filepath.Join(s.dir, sub)
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			return pruned, fmt.Errorf("read message %s dir: %w", sub, err)
+		}
+		for _, e := range entries {
+			if e.IsDir() || strings.HasPrefix(e.Name(), ".tmp-") {
+				continue
+			}
+			info, err := e.Info()
+			if err != nil || !info.ModTime().Before(cutoff) {
+				continue
+			}
+			if sub == "receipts" {
+				id, _, _ := strings.Cut(e.Name(), ".")
+				if _, err := s.findFile(id); err == nil {
+					continue
+				}
+			}
+			path := filepath.Join(dir, e.Name())
+			if !dryRun {
+				if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+					return pruned, fmt.Errorf("prune %s: %w", path, err)
+				}
+			}
+			pruned = append(pruned, path)
+		}
+	}
+	return pruned, nil
+}
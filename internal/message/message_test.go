@@ -1,6 +1,7 @@
 package message
 
 import (
+	"errors"
 	"os"
 	"path/filepath"
 	"strings"
@@ -40,7 +41,7 @@ func TestNewStoreCreatesDirectories(t *testing.T) {
 
 func TestCreateAndGet(t *testing.T) {
 	s := newTestStore(t)
-	payload := map[string]any{"key": "value", "count": float64(42)}
+	payload := map[string]any{"result": "value"}
 	m, err := s.Create(TypeTaskDone, "agent-a", "agent-b", "task-1", payload)
 	if err != nil {
 		t.Fatalf("Create: %v", err)
@@ -64,8 +65,8 @@ func TestCreateAndGet(t *testing.T) {
 	if m.TaskID != "task-1" {
 		t.Errorf("TaskID = %q, want %q", m.TaskID, "task-1")
 	}
-	if m.Payload["key"] != "value" {
-		t.Errorf("Payload[key] = %v, want %q", m.Payload["key"], "value")
+	if m.Payload["result"] != "value" {
+		t.Errorf("Payload[result] = %v, want %q", m.Payload["result"], "value")
 	}
 	if m.CreatedAt.IsZero() {
 		t.Error("CreatedAt is zero")
@@ -90,8 +91,8 @@ func TestCreateAndGet(t *testing.T) {
 	if got.TaskID != m.TaskID {
 		t.Errorf("Get TaskID = %q, want %q", got.TaskID, m.TaskID)
 	}
-	if got.Payload["count"] != float64(42) {
-		t.Errorf("Get Payload[count] = %v, want 42", got.Payload["count"])
+	if got.Payload["result"] != "value" {
+		t.Errorf("Get Payload[result] = %v, want %q", got.Payload["result"], "value")
 	}
 }
 
@@ -193,7 +194,7 @@ func TestListPendingTimestampOrdering(t *testing.T) {
 	s := newTestStore(t)
 	// Create 5 messages with slight delays to get distinct timestamps.
 	for i := 0; i < 5; i++ {
-		_, _ = s.Create(TypeCheckpoint, "sender", "recipient", "", map[string]any{"seq": float64(i)})
+		_, _ = s.Create(TypeCheckpoint, "sender", "recipient", "", map[string]any{"checkpoint": string(rune('a' + i))})
 		time.Sleep(time.Millisecond)
 	}
 
@@ -367,3 +368,387 @@ func TestListPendingExcludesArchived(t *testing.T) {
 		t.Errorf("remaining message type = %q, want %q", msgs[0].Type, TypeHelp)
 	}
 }
+
+// userBody is a minimal valid user_message payload.
+var userBody = map[string]any{"body": "hello"}
+
+func TestCreateInvalidAddress(t *testing.T) {
+	s := newTestStore(t)
+	for _, to := range []string{"", AddrRolePrefix, AddrTopicPrefix} {
+		if _, err := s.Create(TypeUserMessage, "a", to, "", userBody); !errors.Is(err, ErrInvalidAddress) {
+			t.Errorf("Create(to=%q) err = %v, want ErrInvalidAddress", to, err)
+		}
+	}
+}
+
+// fakeDirectory is a Directory backed by a map of agents.
+type fakeDirectory map[string]Recipient
+
+func (d fakeDirectory) Recipient(id string) (Recipient, bool) {
+	r, ok := d[id]
+	return r, ok
+}
+
+func (d fakeDirectory) Recipients() ([]Recipient, error) {
+	var out []Recipient
+	for _, r := range d {
+		if !r.Dead {
+			out = append(out, r)
+		}
+	}
+	return out, nil
+}
+
+// newDirectory returns a directory of agents started an hour ago, with
+// roles taken from their ID prefix.
+func newDirectory(ids ...string) fakeDirectory {
+	d := fakeDirectory{}
+	for _, id := range ids {
+		role, _, _ := strings.Cut(id, "-")
+		if role == "w" {
+			role = "worker"
+		}
+		d[id] = Recipient{ID: id, Role: role, StartedAt: time.Now().Add(-time.Hour)}
+	}
+	return d
+}
+
+func TestListPendingRoleAddress(t *testing.T) {
+	s := newTestStore(t)
+	s.SetDirectory(newDirectory("w-1", "w-2", "liaison-01"))
+
+	m, err := s.Create(TypeUserMessage, "user", ToRole("worker"), "", userBody)
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+
+	for _, id := range []string{"w-1", "w-2"} {
+		msgs, err := s.ListPending(id)
+		if err != nil {
+			t.Fatalf("ListPending(%s): %v", id, err)
+		}
+		if len(msgs) != 1 || msgs[0].ID != m.ID {
+			t.Errorf("ListPending(%s) = %v, want [%s]", id, msgs, m.ID)
+		}
+	}
+	msgs, _ := s.ListPending("liaison-01")
+	if len(msgs) != 0 {
+		t.Errorf("liaison got %d role:worker messages, want 0", len(msgs))
+	}
+}
+
+func TestListPendingRoleAddressWithoutDirectory(t *testing.T) {
+	s := newTestStore(t)
+	_, _ = s.Create(TypeUserMessage, "user", ToRole("worker"), "", userBody)
+	msgs, _ := s.ListPending("w-1")
+	if len(msgs) != 0 {
+		t.Errorf("got %d messages without a Directory, want 0", len(msgs))
+	}
+}
+
+func TestGroupMessageRetiredOnceAllAck(t *testing.T) {
+	s := newTestStore(t)
+	dir := newDirectory("w-1", "w-2", "liaison-01")
+	s.SetDirectory(dir)
+
+	m, err := s.Create(TypeUserMessage, "liaison-01", AddrBroadcast, "", userBody)
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if got := strings.Join(m.Recipients, ","); got != "w-1,w-2" {
+		t.Fatalf("Recipients = %q, want w-1,w-2", got)
+	}
+
+	_ = s.Ack(m.ID, "w-1")
+	if _, err := s.Get(m.ID); err != nil {
+		t.Fatalf("message retired before w-2 acked: %v", err)
+	}
+
+	// An agent spawned after the broadcast never sees it.
+	dir["w-3"] = Recipient{ID: "w-3", Role: "worker", StartedAt: time.Now()}
+	if msgs, _ := s.ListPending("w-3"); len(msgs) != 0 {
+		t.Errorf("late agent got %d messages, want 0", len(msgs))
+	}
+
+	_ = s.Ack(m.ID, "w-2")
+	if _, err := s.Get(m.ID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get after all acked = %v, want ErrNotFound", err)
+	}
+	if msgs, _ := s.ListPending("w-3"); len(msgs) != 0 {
+		t.Errorf("late agent got %d messages after retirement, want 0", len(msgs))
+	}
+}
+
+func TestGroupMessageWithoutRecipientsArchived(t *testing.T) {
+	s := newTestStore(t)
+	s.SetDirectory(newDirectory("liaison-01"))
+	m, err := s.Create(TypeUserMessage, "liaison-01", ToRole("worker"), "", userBody)
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if _, err := s.Get(m.ID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("message with no recipients still pending: %v", err)
+	}
+}
+
+func TestRetireDelivered(t *testing.T) {
+	s := newTestStore(t)
+	dir := newDirectory("w-1", "w-2")
+	s.SetDirectory(dir)
+	m, _ := s.Create(TypeUserMessage, "user", ToRole("worker"), "", userBody)
+	_ = s.Ack(m.ID, "w-1")
+
+	if retired, err := s.RetireDelivered(); err != nil || len(retired) != 0 {
+		t.Fatalf("RetireDelivered = %v, %v; want nothing while w-2 is alive", retired, err)
+	}
+	delete(dir, "w-2")
+	if retired, err := s.RetireDelivered(); err != nil || len(retired) != 1 || retired[0].ID != m.ID {
+		t.Fatalf("RetireDelivered = %v, %v; want %s", retired, err, m.ID)
+	}
+	if _, err := s.Get(m.ID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get after retire = %v, want ErrNotFound", err)
+	}
+}
+
+func TestAckGroupMessagePerRecipient(t *testing.T) {
+	s := newTestStore(t)
+	m, _ := s.Create(TypeUserMessage, "user", AddrBroadcast, "", userBody)
+
+	if err := s.Ack(m.ID, "alice"); err != nil {
+		t.Fatalf("Ack: %v", err)
+	}
+
+	msgs, _ := s.ListPending("alice")
+	if len(msgs) != 0 {
+		t.Errorf("alice has %d pending after ack, want 0", len(msgs))
+	}
+	msgs, _ = s.ListPending("bob")
+	if len(msgs) != 1 {
+		t.Errorf("bob has %d pending, want 1", len(msgs))
+	}
+
+	// The group message itself stays in the store.
+	if _, err := s.Get(m.ID); err != nil {
+		t.Errorf("Get after group ack: %v", err)
+	}
+	receipts, err := s.Receipts(m.ID)
+	if err != nil {
+		t.Fatalf("Receipts: %v", err)
+	}
+	if len(receipts) != 1 || receipts[0] != "alice" {
+		t.Errorf("Receipts = %v, want [alice]", receipts)
+	}
+}
+
+func TestAckDirectMessageArchives(t *testing.T) {
+	s := newTestStore(t)
+	m, _ := s.Create(TypeHelp, "a", "alice", "", nil)
+	if err := s.Ack(m.ID, "alice"); err != nil {
+		t.Fatalf("Ack: %v", err)
+	}
+	if _, err := s.Get(m.ID); err != ErrNotFound {
+		t.Errorf("expected ErrNotFound after ack, got %v", err)
+	}
+}
+
+func TestBroadcastExcludesSender(t *testing.T) {
+	s := newTestStore(t)
+	_, _ = s.Create(TypeUserMessage, "alice", AddrBroadcast, "", userBody)
+	msgs, _ := s.ListPending("alice")
+	if len(msgs) != 0 {
+		t.Errorf("sender received its own broadcast")
+	}
+}
+
+func TestTopicSubscription(t *testing.T) {
+	s := newTestStore(t)
+	if err := s.Subscribe("alice", "main-changed"); err != nil {
+		t.Fatalf("Subscribe: %v", err)
+	}
+	if err := s.Subscribe("alice", "main-changed"); err != nil {
+		t.Fatalf("Subscribe twice: %v", err)
+	}
+	topics, _ := s.Subscriptions("alice")
+	if len(topics) != 1 {
+		t.Fatalf("Subscriptions = %v, want one topic", topics)
+	}
+
+	_, _ = s.Create(TypeUserMessage, "user", ToTopic("main-changed"), "", userBody)
+
+	if msgs, _ := s.ListPending("alice"); len(msgs) != 1 {
+		t.Errorf("subscriber got %d messages, want 1", len(msgs))
+	}
+	if msgs, _ := s.ListPending("bob"); len(msgs) != 0 {
+		t.Errorf("non-subscriber got %d messages, want 0", len(msgs))
+	}
+
+	if err := s.Unsubscribe("alice", "main-changed"); err != nil {
+		t.Fatalf("Unsubscribe: %v", err)
+	}
+	if msgs, _ := s.ListPending("alice"); len(msgs) != 0 {
+		t.Errorf("unsubscribed agent got %d messages, want 0", len(msgs))
+	}
+}
+
+func TestListPendingPriorityOrdering(t *testing.T) {
+	s := newTestStore(t)
+	low, _ := s.Create(TypeHelp, "a", "alice", "", nil, WithPriority(PriorityLow))
+	time.Sleep(time.Millisecond)
+	normal, _ := s.Create(TypeHelp, "a", "alice", "", nil)
+	time.Sleep(time.Millisecond)
+	high, _ := s.Create(TypeHelp, "a", "alice", "", nil, WithPriority(PriorityHigh))
+
+	msgs, err := s.ListPending("alice")
+	if err != nil {
+		t.Fatalf("ListPending: %v", err)
+	}
+	want := []string{high.ID, normal.ID, low.ID}
+	if len(msgs) != len(want) {
+		t.Fatalf("ListPending = %d messages, want %d", len(msgs), len(want))
+	}
+	for i, id := range want {
+		if msgs[i].ID != id {
+			t.Errorf("msgs[%d] = %s, want %s", i, msgs[i].ID, id)
+		}
+	}
+}
+
+func TestParsePriority(t *testing.T) {
+	for in, want := range map[string]Priority{"low": PriorityLow, "": PriorityNormal, "normal": PriorityNormal, "high": PriorityHigh} {
+		got, err := ParsePriority(in)
+		if err != nil || got != want {
+			t.Errorf("ParsePriority(%q) = %v, %v; want %v", in, got, err, want)
+		}
+	}
+	if _, err := ParsePriority("urgent"); err == nil {
+		t.Error("expected error for unknown priority")
+	}
+}
+
+func TestTTLExpiry(t *testing.T) {
+	s := newTestStore(t)
+	m, err := s.Create(TypeUserMessage, "a", "alice", "", userBody, WithTTL(time.Minute))
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if m.ExpiresAt.IsZero() {
+		t.Fatal("ExpiresAt not set")
+	}
+
+	if msgs, _ := s.ListPending("alice"); len(msgs) != 1 {
+		t.Fatalf("fresh message not pending")
+	}
+
+	expired, err := s.ExpireStale(time.Now().Add(2 * time.Minute))
+	if err != nil {
+		t.Fatalf("ExpireStale: %v", err)
+	}
+	if len(expired) != 1 || expired[0].ID != m.ID {
+		t.Fatalf("ExpireStale = %v, want [%s]", expired, m.ID)
+	}
+	if msgs, _ := s.ListPending("alice"); len(msgs) != 0 {
+		t.Errorf("expired message still pending")
+	}
+	dead, err := s.GetDeadLetter(m.ID)
+	if err != nil {
+		t.Fatalf("GetDeadLetter: %v", err)
+	}
+	if dead.DeadReason != ErrExpired.Error() {
+		t.Errorf("DeadReason = %q, want %q", dead.DeadReason, ErrExpired.Error())
+	}
+}
+
+func TestRecordFailureAndDeadLetter(t *testing.T) {
+	s := newTestStore(t)
+	m, _ := s.Create(TypeTaskDone, "w-1", "daemon", "t-1", nil)
+
+	for i := 1; i <= 2; i++ {
+		got, err := s.RecordFailure(m.ID, "boom")
+		if err != nil {
+			t.Fatalf("RecordFailure: %v", err)
+		}
+		if got.Attempts != i || got.LastError != "boom" {
+			t.Errorf("after failure %d: Attempts=%d LastError=%q", i, got.Attempts, got.LastError)
+		}
+	}
+
+	if err := s.DeadLetter(m.ID, "gave up"); err != nil {
+		t.Fatalf("DeadLetter: %v", err)
+	}
+	if _, err := s.Get(m.ID); err != ErrNotFound {
+		t.Errorf("Get after DeadLetter = %v, want ErrNotFound", err)
+	}
+	dead, err := s.ListDeadLetters()
+	if err != nil {
+		t.Fatalf("ListDeadLetters: %v", err)
+	}
+	if len(dead) != 1 || dead[0].DeadReason != "gave up" || dead[0].Attempts != 2 {
+		t.Fatalf("ListDeadLetters = %+v", dead)
+	}
+}
+
+func TestPrune(t *testing.T) {
+	s := newTestStore(t)
+	archived, _ := s.Create(TypeHelp, "a", "alice", "", nil)
+	_ = s.Ack(archived.ID, "alice")
+	dead, _ := s.Create(TypeHelp, "a", "bob", "", nil)
+	_ = s.DeadLetter(dead.ID, "gave up")
+	group, _ := s.Create(TypeUserMessage, "a", AddrBroadcast, "", userBody)
+	_ = s.Ack(group.ID, "alice")
+
+	if got, err := s.Prune(time.Now().Add(-time.Hour), false); err != nil || len(got) != 0 {
+		t.Fatalf("Prune before cutoff = %v, %v; want nothing", got, err)
+	}
+	cutoff := time.Now().Add(time.Minute)
+	if got, err := s.Prune(cutoff, true); err != nil || len(got) != 2 {
+		t.Fatalf("Prune dry run = %v, %v; want archive and dead letter", got, err)
+	}
+	if dl, _ := s.ListDeadLetters(); len(dl) != 1 {
+		t.Fatal("dry run removed a dead letter")
+	}
+
+	// The receipt stays while its group message is pending.
+	if got, err := s.Prune(cutoff, false); err != nil || len(got) != 2 {
+		t.Fatalf("Prune = %v, %v; want archive and dead letter", got, err)
+	}
+	if dl, _ := s.ListDeadLetters(); len(dl) != 0 {
+		t.Errorf("dead letters left after Prune: %v", dl)
+	}
+	if r, _ := s.Receipts(group.ID); len(r) != 1 {
+		t.Errorf("Receipts = %v, want alice's kept", r)
+	}
+
+	_ = s.Delete(group.ID)
+	if got, err := s.Prune(cutoff, false); err != nil || len(got) != 1 || !strings.Contains(got[0], group.ID) {
+		t.Errorf("Prune after group message removed = %v, %v; want its receipt", got, err)
+	}
+}
+
+func TestReplay(t *testing.T) {
+	s := newTestStore(t)
+	m, _ := s.Create(TypeHelp, "a", "w-dead", "", nil, WithTTL(time.Nanosecond))
+	if _, err := s.RecordFailure(m.ID, "boom"); err != nil {
+		t.Fatalf("RecordFailure: %v", err)
+	}
+	if err := s.DeadLetter(m.ID, "recipient dead"); err != nil {
+		t.Fatalf("DeadLetter: %v", err)
+	}
+
+	replayed, err := s.Replay(m.ID, "liaison-01")
+	if err != nil {
+		t.Fatalf("Replay: %v", err)
+	}
+	if replayed.To != "liaison-01" || replayed.Attempts != 0 || replayed.DeadReason != "" || !replayed.ExpiresAt.IsZero() {
+		t.Errorf("replayed message not reset: %+v", replayed)
+	}
+	msgs, _ := s.ListPending("liaison-01")
+	if len(msgs) != 1 {
+		t.Errorf("replayed message not pending for new recipient")
+	}
+	if dead, _ := s.ListDeadLetters(); len(dead) != 0 {
+		t.Errorf("dead letters = %d after replay, want 0", len(dead))
+	}
+	if _, err := s.Replay("m-nope00", ""); err != ErrNotFound {
+		t.Errorf("Replay missing = %v, want ErrNotFound", err)
+	}
+}
@@ -0,0 +1,183 @@
+package message
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// ErrInvalidPayload is returned when a message payload does not match the
+// schema for its type.
+var ErrInvalidPayload = errors.New("invalid message payload")
+
+// Payload is implemented by the typed payload of each message type.
+type Payload interface {
+	// MessageType returns the message type this payload belongs to.
+	MessageType() Type
+	// Validate reports missing or inconsistent fields.
+	Validate() error
+}
+
+// TaskDonePayload is sent by a worker when it finishes its task.
+type TaskDonePayload struct {
+	Result string `json:"result,omitempty"`
+}
+
+func (TaskDonePayload) MessageType() Type { return TypeTaskDone }
+func (TaskDonePayload) Validate() error   { return nil }
+
+// HelpPayload asks the liaison for attention. The daemon sends it for
+// stalled or unresponsive workers and unresolvable merge conflicts.
+type HelpPayload struct {
+	Message         string   `json:"message,omitempty"`
+	WorkerID        string   `json:"worker_id,omitempty"`
+	EscalationLevel string   `json:"escalation_level,omitempty"`
+	StalledSince    string   `json:"stalled_since,omitempty"`
+	Conflicts       []string `json:"conflicts,omitempty"`
+	ResolveAttempts int      `json:"resolve_attempts,omitempty"`
+}
+
+func (HelpPayload) MessageType() Type { return TypeHelp }
+
+func (p HelpPayload) Validate() error {
+	if p.ResolveAttempts < 0 {
+		return fmt.Errorf("resolve_attempts must be >= 0, got %d", p.ResolveAttempts)
+	}
+	return nil
+}
+
+// MergeResultPayload reports the outcome of merging a task branch.
+type MergeResultPayload struct {
+	Success   bool     `json:"success"`
+	Outcome   string   `json:"outcome,omitempty"`
+	Conflicts []string `json:"conflicts,omitempty"`
+	Output    string   `json:"output,omitempty"`
+}
+
+func (MergeResultPayload) MessageType() Type { return TypeMergeResult }
+
+func (p MergeResultPayload) Validate() error {
+	if p.Success && len(p.Conflicts) > 0 {
+		return errors.New("successful merge cannot list conflicts")
+	}
+	return nil
+}
+
+// CheckpointPayload carries a progress note for a task.
+type CheckpointPayload struct {
+	Checkpoint string `json:"checkpoint,omitempty"`
+}
+
+func (CheckpointPayload) MessageType() Type { return TypeCheckpoint }
+func (CheckpointPayload) Validate() error   { return nil }
+
+// UserMessagePayload is free text from a human to an agent.
+type UserMessagePayload struct {
+	Body string `json:"body"`
+}
+
+func (UserMessagePayload) MessageType() Type { return TypeUserMessage }
+
+func (p UserMessagePayload) Validate() error {
+	if p.Body == "" {
+		return errors.New("body is required")
+	}
+	return nil
+}
+
+// newPayload returns an empty payload value for the given type.
+func newPayload(t Type) (Payload, bool) {
+	switch t {
+	case TypeTaskDone:
+		return &TaskDonePayload{}, true
+	case TypeHelp:
+		return &HelpPayload{}, true
+	case TypeMergeResult:
+		return &MergeResultPayload{}, true
+	case TypeCheckpoint:
+		return &CheckpointPayload{}, true
+	case TypeUserMessage:
+		return &UserMessagePayload{}, true
+	}
+	return nil, false
+}
+
+// EncodePayload converts a typed payload into the generic map stored on a
+// Message. The payload is validated first.
+func EncodePayload(p Payload) (map[string]any, error) {
+	if err := p.Validate(); err != nil {
+		return nil, fmt.Errorf("%w: %s: %v", ErrInvalidPayload, p.MessageType(), err)
+	}
+	data, err := json.Marshal(p)
+	if err != nil {
+		return nil, fmt.Errorf("marshal payload: %w", err)
+	}
+	var m map[string]any
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("unmarshal payload: %w", err)
+	}
+	return m, nil
+}
+
+// DecodePayload decodes the message payload into v, which should be a
+// pointer to the payload struct for the message's type. Fields absent from
+// the payload keep their zero values.
+func (m *Message) DecodePayload(v Payload) error {
+	if v.MessageType() != m.Type {
+		return fmt.Errorf("%w: cannot decode %s payload as %s", ErrInvalidPayload, m.Type, v.MessageType())
+	}
+	return decodeInto(m.Payload, v)
+}
+
+// ParsePayload decodes a generic payload map into the typed payload for t
+// and validates it. Unknown fields are rejected. A nil map decodes to the
+// zero payload.
+func ParsePayload(t Type, payload map[string]any) (Payload, error) {
+	p, ok := newPayload(t)
+	if !ok {
+		return nil, ErrInvalidType
+	}
+	if err := decodeInto(payload, p); err != nil {
+		return nil, err
+	}
+	if err := p.Validate(); err != nil {
+		return nil, fmt.Errorf("%w: %s: %v", ErrInvalidPayload, t, err)
+	}
+	return p, nil
+}
+
+// validatePayload checks a generic payload against the schema for t. A nil
+// payload is accepted for every type except those with required fields.
+func validatePayload(t Type, payload map[string]any) error {
+	_, err := ParsePayload(t, payload)
+	return err
+}
+
+// decodeInto decodes payload into v, rejecting fields v does not define so
+// a misspelt field fails instead of being dropped.
+func decodeInto(payload map[string]any, v any) error {
+	if payload == nil {
+		return nil
+	}
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("marshal payload: %w", err)
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidPayload, err)
+	}
+	return nil
+}
+
+// Send persists a message built from a typed payload. The message type is
+// taken from the payload.
+func (s *Store) Send(from, to, taskID string, p Payload, opts ...CreateOption) (*Message, error) {
+	payload, err := EncodePayload(p)
+	if err != nil {
+		return nil, err
+	}
+	return s.Create(p.MessageType(), from, to, taskID, payload, opts...)
+}
@@ -0,0 +1,82 @@
+package message
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestCreateRejectsMalformedPayload(t *testing.T) {
+	s := newTestStore(t)
+	cases := []struct {
+		name    string
+		typ     Type
+		payload map[string]any
+	}{
+		{"result not a string", TypeTaskDone, map[string]any{"result": 42}},
+		{"success not a bool", TypeMergeResult, map[string]any{"success": "yes"}},
+		{"success with conflicts", TypeMergeResult, map[string]any{"success": true, "conflicts": []any{"a.go"}}},
+		{"negative attempts", TypeHelp, map[string]any{"resolve_attempts": -1}},
+		{"missing body", TypeUserMessage, nil},
+		{"empty body", TypeUserMessage, map[string]any{"body": ""}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := s.Create(tc.typ, "a", "b", "", tc.payload); !errors.Is(err, ErrInvalidPayload) {
+				t.Errorf("Create err = %v, want ErrInvalidPayload", err)
+			}
+		})
+	}
+}
+
+func TestCreateRejectsUnknownPayloadFields(t *testing.T) {
+	s := newTestStore(t)
+	_, err := s.Create(TypeTaskDone, "a", "b", "", map[string]any{"resutl": "done"})
+	if !errors.Is(err, ErrInvalidPayload) || !strings.Contains(err.Error(), "resutl") {
+		t.Errorf("Create err = %v, want ErrInvalidPayload naming the field", err)
+	}
+}
+
+func TestSendAndDecodePayload(t *testing.T) {
+	s := newTestStore(t)
+	m, err := s.Send("daemon", "w-1", "t-1", MergeResultPayload{Success: false, Conflicts: []string{"a.go"}})
+	if err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	if m.Type != TypeMergeResult {
+		t.Errorf("Type = %q, want %q", m.Type, TypeMergeResult)
+	}
+
+	got, err := s.Get(m.ID)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	var p MergeResultPayload
+	if err := got.DecodePayload(&p); err != nil {
+		t.Fatalf("DecodePayload: %v", err)
+	}
+	if p.Success || len(p.Conflicts) != 1 || p.Conflicts[0] != "a.go" {
+		t.Errorf("decoded payload = %+v", p)
+	}
+}
+
+func TestSendRejectsInvalidPayload(t *testing.T) {
+	s := newTestStore(t)
+	if _, err := s.Send("user", "w-1", "", UserMessagePayload{}); !errors.Is(err, ErrInvalidPayload) {
+		t.Errorf("Send err = %v, want ErrInvalidPayload", err)
+	}
+}
+
+func TestDecodePayloadWrongType(t *testing.T) {
+	m := &Message{Type: TypeHelp, Payload: map[string]any{"message": "hi"}}
+	var p TaskDonePayload
+	if err := m.DecodePayload(&p); !errors.Is(err, ErrInvalidPayload) {
+		t.Errorf("DecodePayload err = %v, want ErrInvalidPayload", err)
+	}
+}
+
+func TestParsePayloadUnknownType(t *testing.T) {
+	if _, err := ParsePayload("bogus", nil); err != ErrInvalidType {
+		t.Errorf("ParsePayload err = %v, want ErrInvalidType", err)
+	}
+}
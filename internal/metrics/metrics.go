@@ -0,0 +1,271 @@
+// Package metrics implements the small subset of Prometheus instrumentation
+// the daemon needs: labelled counters and gauges, a histogram, and an HTTP
+// handler serving the text exposition format (version 0.0.4). It has no
+// dependencies outside the standard library.
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ContentType is the Content-Type of the text exposition format.
+const ContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// DefBuckets are histogram upper bounds in seconds, suited to durations
+// from a few milliseconds to a minute.
+var DefBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60}
+
+// collector is a metric family that can write itself in text format.
+type collector interface {
+	write(w io.Writer) error
+}
+
+// Registry holds metric families and renders them in registration order.
+type Registry struct {
+	mu         sync.Mutex
+	collectors []collector
+}
+
+// NewRegistry creates an empty Registry.
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+// NewCounter registers a counter family with the given label names.
+func (r *Registry) NewCounter(name, help string, labels ...string) *Counter {
+	c := &Counter{vec: newVec(name, help, "counter", labels)}
+	r.register(c.vec)
+	return c
+}
+
+// NewGauge registers a gauge family with the given label names.
+func (r *Registry) NewGauge(name, help string, labels ...string) *Gauge {
+	g := &Gauge{vec: newVec(name, help, "gauge", labels)}
+	r.register(g.vec)
+	return g
+}
+
+// NewHistogram registers an unlabelled histogram with the given bucket
+// upper bounds, which must be sorted ascending.
+func (r *Registry) NewHistogram(name, help string, buckets []float64) *Histogram {
+	h := &Histogram{name: name, help: help, bounds: buckets, counts: make([]uint64, len(buckets))}
+	r.register(h)
+	return h
+}
+
+func (r *Registry) register(c collector) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.collectors = append(r.collectors, c)
+}
+
+// WriteText writes every registered family in the text exposition format.
+func (r *Registry) WriteText(w io.Writer) error {
+	r.mu.Lock()
+	cs := append([]collector(nil), r.collectors...)
+	r.mu.Unlock()
+	for _, c := range cs {
+		if err := c.write(w); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Handler returns an http.Handler that serves the registry.
+func (r *Registry) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", ContentType)
+		_ = r.WriteText(w)
+	})
+}
+
+// vec is a family of float64 values keyed by label values.
+type vec struct {
+	name, help, typ string
+	labels          []string
+
+	mu     sync.Mutex
+	values map[string]float64
+	keys   map[string][]string // series key -> label values
+}
+
+func newVec(name, help, typ string, labels []string) *vec {
+	return &vec{name: name, help: help, typ: typ, labels: labels, values: map[string]float64{}, keys: map[string][]string{}}
+}
+
+// key validates the label values and returns the series key.
+func (v *vec) key(values []string) string {
+	if len(values) != len(v.labels) {
+		panic(fmt.Sprintf("metrics: %s has %d labels, got %d values", v.name, len(v.labels), len(values)))
+	}
+	k := strings.Join(values, "\xff")
+	if _, ok := v.keys[k]; !ok {
+		v.keys[k] = append([]string(nil), values...)
+	}
+	return k
+}
+
+func (v *vec) write(w io.Writer) error {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if _, err := fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", v.name, escapeHelp(v.help), v.name, v.typ); err != nil {
+		return err
+	}
+	keys := make([]string, 0, len(v.values))
+	for k := range v.values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		if _, err := fmt.Fprintf(w, "%s%s %s\n", v.name, labelPairs(v.labels, v.keys[k]), formatFloat(v.values[k])); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Counter is a monotonically increasing value per label set.
+type Counter struct{ vec *vec }
+
+// Inc adds one to the series with the given label values.
+func (c *Counter) Inc(labelValues ...string) { c.Add(1, labelValues...) }
+
+// Add adds delta, which must not be negative, to the series.
+func (c *Counter) Add(delta float64, labelValues ...string) {
+	if delta < 0 {
+		panic("metrics: counter cannot decrease")
+	}
+	c.vec.mu.Lock()
+	defer c.vec.mu.Unlock()
+	c.vec.values[c.vec.key(labelValues)] += delta
+}
+
+// Value returns the current value of the series.
+func (c *Counter) Value(labelValues ...string) float64 {
+	c.vec.mu.Lock()
+	defer c.vec.mu.Unlock()
+	return c.vec.values[strings.Join(labelValues, "\xff")]
+}
+
+// Gauge is a value per label set that can go up and down.
+type Gauge struct{ vec *vec }
+
+// Set sets the series with the given label values.
+func (g *Gauge) Set(value float64, labelValues ...string) {
+	g.vec.mu.Lock()
+	defer g.vec.mu.Unlock()
+	g.vec.values[g.vec.key(labelValues)] = value
+}
+
+// Value returns the current value of the series.
+func (g *Gauge) Value(labelValues ...string) float64 {
+	g.vec.mu.Lock()
+	defer g.vec.mu.Unlock()
+	return g.vec.values[strings.Join(labelValues, "\xff")]
+}
+
+// Reset removes every series, so label sets that no longer exist (such as
+// agents that have exited) stop being reported.
+func (g *Gauge) Reset() {
+	g.vec.mu.Lock()
+	defer g.vec.mu.Unlock()
+	g.vec.values = map[string]float64{}
+	g.vec.keys = map[string][]string{}
+}
+
+// Histogram counts observations into cumulative buckets.
+type Histogram struct {
+	name, help string
+	bounds     []float64
+
+	mu     sync.Mutex
+	counts []uint64 // per bucket, not cumulative
+	count  uint64
+	sum    float64
+}
+
+// Observe records one value.
+func (h *Histogram) Observe(v float64) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for i, b := range h.bounds {
+		if v <= b {
+			h.counts[i]++
+			break
+		}
+	}
+	h.count++
+	h.sum += v
+}
+
+// Count returns the number of observations.
+func (h *Histogram) Count() uint64 {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.count
+}
+
+func (h *Histogram) write(w io.Writer) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if _, err := fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s histogram\n", h.name, escapeHelp(h.help), h.name); err != nil {
+		return err
+	}
+	var cum uint64
+	for i, b := range h.bounds {
+		cum += h.counts[i]
+		if _, err := fmt.Fprintf(w, "%s_bucket{le=\"%s\"} %d\n", h.name, formatFloat(b), cum); err != nil {
+			return err
+		}
+	}
+	_, err := fmt.Fprintf(w, "%s_bucket{le=\"+Inf\"} %d\n%s_sum %s\n%s_count %d\n",
+		h.name, h.count, h.name, formatFloat(h.sum), h.name, h.count)
+	return err
+}
+
+func labelPairs(names, values []string) string {
+	if len(names) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteByte('{')
+	for i, n := range names {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(n)
+		b.WriteString(`="`)
+		b.WriteString(escapeLabel(values[i]))
+		b.WriteByte('"')
+	}
+	b.WriteByte('}')
+	return b.String()
+}
+
+var (
+	labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+	helpEscaper  = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+)
+
+func escapeLabel(s string) string { return labelEscaper.Replace(s) }
+func escapeHelp(s string) string  { return helpEscaper.Replace(s) }
+
+func formatFloat(f float64) string {
+	switch {
+	case math.IsInf(f, 1):
+		return "+Inf"
+	case math.IsInf(f, -1):
+		return "-Inf"
+	case math.IsNaN(f):
+		return "NaN"
+	}
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
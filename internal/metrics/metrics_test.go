@@ -0,0 +1,81 @@
+package metrics
+
+import (
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestWriteText(t *testing.T) {
+	r := NewRegistry()
+	spawns := r.NewCounter("alt_spawns_total", "Agents spawned.", "role")
+	depth := r.NewGauge("alt_queue_depth", "Items in the merge queue.")
+	stale := r.NewGauge("alt_stale_seconds", "Staleness.", "agent")
+	ticks := r.NewHistogram("alt_tick_seconds", "Tick duration.", []float64{0.1, 1})
+
+	spawns.Inc("worker")
+	spawns.Inc("worker")
+	spawns.Inc("resolver")
+	depth.Set(3)
+	stale.Set(1.5, `w"1`)
+	ticks.Observe(0.05)
+	ticks.Observe(0.5)
+	ticks.Observe(5)
+
+	var b strings.Builder
+	if err := r.WriteText(&b); err != nil {
+		t.Fatal(err)
+	}
+	want := `# HELP alt_spawns_total Agents spawned.
+# TYPE alt_spawns_total counter
+alt_spawns_total{role="resolver"} 1
+alt_spawns_total{role="worker"} 2
+# HELP alt_queue_depth Items in the merge queue.
+# TYPE alt_queue_depth gauge
+alt_queue_depth 3
+# HELP alt_stale_seconds Staleness.
+# TYPE alt_stale_seconds gauge
+alt_stale_seconds{agent="w\"1"} 1.5
+# HELP alt_tick_seconds Tick duration.
+# TYPE alt_tick_seconds histogram
+alt_tick_seconds_bucket{le="0.1"} 1
+alt_tick_seconds_bucket{le="1"} 2
+alt_tick_seconds_bucket{le="+Inf"} 3
+alt_tick_seconds_sum 5.55
+alt_tick_seconds_count 3
+`
+	if b.String() != want {
+		t.Errorf("got:\n%s\nwant:\n%s", b.String(), want)
+	}
+
+	stale.Reset()
+	b.Reset()
+	_ = r.WriteText(&b)
+	if strings.Contains(b.String(), "alt_stale_seconds{") {
+		t.Error("Reset should drop all series")
+	}
+}
+
+func TestHandler(t *testing.T) {
+	r := NewRegistry()
+	r.NewCounter("alt_test_total", "Test.").Inc()
+	rec := httptest.NewRecorder()
+	r.Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	if ct := rec.Header().Get("Content-Type"); ct != ContentType {
+		t.Errorf("Content-Type = %q", ct)
+	}
+	body, _ := io.ReadAll(rec.Body)
+	if !strings.Contains(string(body), "alt_test_total 1\n") {
+		t.Errorf("body = %s", body)
+	}
+}
+
+func TestLabelCountMismatchPanics(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("expected panic")
+		}
+	}()
+	NewRegistry().NewCounter("x_total", "x", "a", "b").Inc("only-one")
+}
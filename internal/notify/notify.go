@@ -0,0 +1,308 @@
+// Package notify delivers events from the event log to configured sinks:
+// HTTP webhooks (JSON or Slack-compatible bodies), shell commands, and
+// desktop notifications via notify-send. Each sink follows the log from its
+// own persisted cursor in .alt/notify/, so deliveries resume where they
+// left off after a daemon restart.
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/anthropics/altera/internal/config"
+	"github.com/anthropics/altera/internal/events"
+)
+
+// Defaults applied to sinks that leave the field unset.
+const (
+	DefaultMaxAttempts = 3
+	DefaultBackoff     = 2 * time.Second
+	DefaultTimeout     = 10 * time.Second
+)
+
+// execCommand is the exec.CommandContext function used by command and
+// notify-send sinks. Overridable for testing.
+var execCommand = exec.CommandContext
+
+// Sender delivers one event to a destination.
+type Sender interface {
+	Send(ctx context.Context, ev events.Event) error
+}
+
+// Sink is a configured destination with its filter and retry policy.
+type Sink struct {
+	cfg    config.Sink
+	sender Sender
+	types  map[events.Type]bool
+}
+
+// NewSink builds a Sink from its configuration.
+func NewSink(cfg config.Sink) (*Sink, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	s := &Sink{cfg: cfg, types: map[events.Type]bool{}}
+	for _, t := range cfg.Types {
+		s.types[events.Type(t)] = true
+	}
+	switch cfg.Kind {
+	case config.SinkWebhook:
+		s.sender = &webhookSender{url: cfg.URL, slack: cfg.Format == config.WebhookSlack, client: http.DefaultClient}
+	case config.SinkCommand:
+		s.sender = &commandSender{command: cfg.Command}
+	case config.SinkNotifySend:
+		s.sender = notifySendSender{}
+	}
+	return s, nil
+}
+
+// Name returns the sink's configured name.
+func (s *Sink) Name() string { return s.cfg.Name }
+
+// filter returns the events.Filter for the sink's single-valued filters.
+// Type lists are checked separately by Matches.
+func (s *Sink) filter() events.Filter {
+	return events.Filter{AgentID: s.cfg.AgentID, TaskID: s.cfg.TaskID, Data: s.cfg.Data}
+}
+
+// Matches reports whether the sink's type filter accepts ev.
+func (s *Sink) Matches(ev events.Event) bool {
+	return len(s.types) == 0 || s.types[ev.Type]
+}
+
+// Deliver sends ev, retrying with exponential backoff up to the sink's
+// attempt limit. It returns the last error if every attempt fails.
+func (s *Sink) Deliver(ctx context.Context, ev events.Event) error {
+	attempts := s.cfg.MaxAttempts
+	if attempts == 0 {
+		attempts = DefaultMaxAttempts
+	}
+	backoff := time.Duration(s.cfg.Backoff)
+	if backoff == 0 {
+		backoff = DefaultBackoff
+	}
+	timeout := time.Duration(s.cfg.Timeout)
+	if timeout == 0 {
+		timeout = DefaultTimeout
+	}
+
+	var err error
+	for i := 0; i < attempts; i++ {
+		if i > 0 {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(backoff):
+			}
+			backoff *= 2
+		}
+		actx, cancel := context.WithTimeout(ctx, timeout)
+		err = s.sender.Send(actx, ev)
+		cancel()
+		if err == nil {
+			return nil
+		}
+	}
+	return fmt.Errorf("sink %s: %d attempts failed: %w", s.cfg.Name, attempts, err)
+}
+
+// Summary renders a one-line human-readable description of an event.
+func Summary(ev events.Event) string {
+	var b strings.Builder
+	b.WriteString(string(ev.Type))
+	if ev.TaskID != "" {
+		b.WriteString(" task=" + ev.TaskID)
+	}
+	if ev.AgentID != "" {
+		b.WriteString(" agent=" + ev.AgentID)
+	}
+	keys := make([]string, 0, len(ev.Data))
+	for k := range ev.Data {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		fmt.Fprintf(&b, " %s=%v", k, ev.Data[k])
+	}
+	return b.String()
+}
+
+// webhookSender POSTs the event as JSON, or as a Slack {"text": ...} body.
+type webhookSender struct {
+	url    string
+	slack  bool
+	client *http.Client
+}
+
+func (w *webhookSender) Send(ctx context.Context, ev events.Event) error {
+	var body []byte
+	var err error
+	if w.slack {
+		body, err = json.Marshal(map[string]string{"text": "[altera] " + Summary(ev)})
+	} else {
+		body, err = json.Marshal(ev)
+	}
+	if err != nil {
+		return fmt.Errorf("webhook: marshal: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("webhook: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("webhook: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("webhook: %s returned %s", w.url, resp.Status)
+	}
+	return nil
+}
+
+// commandSender runs a shell command with the event JSON on stdin and the
+// main fields in ALT_EVENT_* environment variables.
+type commandSender struct {
+	command string
+}
+
+func (c *commandSender) Send(ctx context.Context, ev events.Event) error {
+	data, err := json.Marshal(ev)
+	if err != nil {
+		return fmt.Errorf("command: marshal: %w", err)
+	}
+	cmd := execCommand(ctx, "sh", "-c", c.command)
+	cmd.Stdin = bytes.NewReader(append(data, '\n'))
+	cmd.Env = append(os.Environ(),
+		"ALT_EVENT_TYPE="+string(ev.Type),
+		"ALT_EVENT_TASK="+ev.TaskID,
+		"ALT_EVENT_AGENT="+ev.AgentID,
+		"ALT_EVENT_SUMMARY="+Summary(ev),
+	)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("command: %w: %s", err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// notifySendSender shows a desktop notification. Deaths, budget overruns
+// and merge problems are sent with critical urgency.
+type notifySendSender struct{}
+
+func (notifySendSender) Send(ctx context.Context, ev events.Event) error {
+	urgency := "normal"
+	switch ev.Type {
+	case events.AgentDied, events.BudgetExceeded, events.MergeConflict, events.MergeFailed:
+		urgency = "critical"
+	}
+	cmd := execCommand(ctx, "notify-send", "-u", urgency, "-a", "altera", "Altera: "+string(ev.Type), Summary(ev))
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("notify-send: %w: %s", err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// Manager runs every configured sink against the event log.
+type Manager struct {
+	altDir   string
+	evReader *events.Reader
+	sinks    []*Sink
+	logger   *slog.Logger
+	poll     time.Duration
+}
+
+// NewManager creates a Manager for the sinks in cfgs. Cursors are kept in
+// altDir/notify/<sink>.cursor.
+func NewManager(altDir string, evReader *events.Reader, cfgs []config.Sink, logger *slog.Logger) (*Manager, error) {
+	if err := config.ValidateSinks(cfgs); err != nil {
+		return nil, err
+	}
+	if logger == nil {
+		logger = slog.New(slog.NewTextHandler(io.Discard, nil))
+	}
+	m := &Manager{altDir: altDir, evReader: evReader, logger: logger, poll: events.DefaultPollInterval}
+	for _, c := range cfgs {
+		s, err := NewSink(c)
+		if err != nil {
+			return nil, err
+		}
+		m.sinks = append(m.sinks, s)
+	}
+	return m, nil
+}
+
+// Sinks returns the configured sinks.
+func (m *Manager) Sinks() []*Sink { return m.sinks }
+
+// CursorPath returns the file holding the delivery cursor of a sink.
+func (m *Manager) CursorPath(name string) string {
+	return filepath.Join(m.altDir, "notify", name+".cursor")
+}
+
+// Run follows the event log for every sink until ctx is done. A sink with
+// no saved cursor starts at the current end of the log rather than
+// replaying history.
+func (m *Manager) Run(ctx context.Context) {
+	var wg sync.WaitGroup
+	for _, s := range m.sinks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := m.runSink(ctx, s); err != nil {
+				m.logger.Error("notify: sink stopped", "sink", s.Name(), "error", err)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func (m *Manager) runSink(ctx context.Context, s *Sink) error {
+	cursorPath := m.CursorPath(s.Name())
+	from, ok, err := events.LoadCursor(cursorPath)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		if from, err = m.evReader.End(); err != nil {
+			return err
+		}
+		if err := events.SaveCursor(cursorPath, from); err != nil {
+			return err
+		}
+	}
+
+	ch, err := m.evReader.Follow(ctx, s.filter(), from, events.WithPollInterval(m.poll))
+	if err != nil {
+		return err
+	}
+	for d := range ch {
+		if s.Matches(d.Event) {
+			if err := s.Deliver(ctx, d.Event); err != nil {
+				if ctx.Err() != nil {
+					return nil // shutting down; redeliver on restart
+				}
+				// Give up on this event rather than block the sink.
+				m.logger.Error("notify: delivery failed", "sink", s.Name(),
+					"type", d.Event.Type, "task", d.Event.TaskID, "error", err)
+			}
+		}
+		if err := events.SaveCursor(cursorPath, d.Cursor); err != nil {
+			m.logger.Error("notify: save cursor", "sink", s.Name(), "error", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,241 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/anthropics/altera/internal/config"
+	"github.com/anthropics/altera/internal/events"
+)
+
+func testEvent(typ events.Type, taskID string) events.Event {
+	return events.Event{
+		Timestamp: time.Now(),
+		Type:      typ,
+		AgentID:   "w-1",
+		TaskID:    taskID,
+		Data:      map[string]any{"reason": "dead"},
+	}
+}
+
+func TestWebhookJSONBody(t *testing.T) {
+	var got events.Event
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if ct := r.Header.Get("Content-Type"); ct != "application/json" {
+			t.Errorf("Content-Type = %q", ct)
+		}
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Errorf("decode: %v", err)
+		}
+	}))
+	defer srv.Close()
+
+	s, err := NewSink(config.Sink{Name: "hook", Kind: config.SinkWebhook, URL: srv.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Deliver(context.Background(), testEvent(events.AgentDied, "t-1")); err != nil {
+		t.Fatalf("Deliver: %v", err)
+	}
+	if got.Type != events.AgentDied || got.TaskID != "t-1" {
+		t.Errorf("webhook received %+v", got)
+	}
+}
+
+func TestWebhookSlackBody(t *testing.T) {
+	var body map[string]string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewDecoder(r.Body).Decode(&body)
+	}))
+	defer srv.Close()
+
+	s, err := NewSink(config.Sink{Name: "slack", Kind: config.SinkWebhook, URL: srv.URL, Format: config.WebhookSlack})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Deliver(context.Background(), testEvent(events.MergeConflict, "t-9")); err != nil {
+		t.Fatal(err)
+	}
+	want := "[altera] merge_conflict task=t-9 agent=w-1 reason=dead"
+	if body["text"] != want {
+		t.Errorf("text = %q, want %q", body["text"], want)
+	}
+}
+
+func TestDeliverRetries(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) < 3 {
+			w.WriteHeader(http.StatusBadGateway)
+		}
+	}))
+	defer srv.Close()
+
+	s, err := NewSink(config.Sink{
+		Name: "hook", Kind: config.SinkWebhook, URL: srv.URL,
+		MaxAttempts: 3, Backoff: config.Duration(time.Millisecond),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Deliver(context.Background(), testEvent(events.TaskDone, "t-1")); err != nil {
+		t.Fatalf("Deliver: %v", err)
+	}
+	if calls.Load() != 3 {
+		t.Errorf("calls = %d, want 3", calls.Load())
+	}
+
+	calls.Store(-10) // fail every remaining attempt
+	if err := s.Deliver(context.Background(), testEvent(events.TaskDone, "t-1")); err == nil {
+		t.Error("expected error after exhausting attempts")
+	}
+}
+
+func TestCommandSink(t *testing.T) {
+	out := filepath.Join(t.TempDir(), "out")
+	s, err := NewSink(config.Sink{
+		Name: "cmd", Kind: config.SinkCommand,
+		Command: `printf '%s ' "$ALT_EVENT_TYPE" "$ALT_EVENT_TASK" > ` + out + ` && cat >> ` + out,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Deliver(context.Background(), testEvent(events.BudgetExceeded, "t-7")); err != nil {
+		t.Fatalf("Deliver: %v", err)
+	}
+	data, _ := os.ReadFile(out)
+	if !strings.HasPrefix(string(data), "budget_exceeded t-7 {") {
+		t.Errorf("command output = %q", data)
+	}
+}
+
+func TestNotifySendArgs(t *testing.T) {
+	out := filepath.Join(t.TempDir(), "args")
+	orig := execCommand
+	execCommand = func(ctx context.Context, name string, args ...string) *exec.Cmd {
+		script := `printf '%s\n' "$@" > ` + out
+		return exec.CommandContext(ctx, "sh", append([]string{"-c", script, name}, args...)...)
+	}
+	t.Cleanup(func() { execCommand = orig })
+
+	s, err := NewSink(config.Sink{Name: "desk", Kind: config.SinkNotifySend})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Deliver(context.Background(), testEvent(events.AgentDied, "t-3")); err != nil {
+		t.Fatal(err)
+	}
+	data, _ := os.ReadFile(out)
+	args := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(args) < 6 || args[1] != "critical" || args[4] != "Altera: agent_died" {
+		t.Errorf("notify-send args = %q", args)
+	}
+}
+
+// recorder is an httptest server that records the task IDs it receives.
+type recorder struct {
+	mu    sync.Mutex
+	tasks []string
+	srv   *httptest.Server
+}
+
+func newRecorder(t *testing.T) *recorder {
+	rec := &recorder{}
+	rec.srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		var ev events.Event
+		_ = json.Unmarshal(data, &ev)
+		rec.mu.Lock()
+		rec.tasks = append(rec.tasks, ev.TaskID)
+		rec.mu.Unlock()
+	}))
+	t.Cleanup(rec.srv.Close)
+	return rec
+}
+
+func (r *recorder) waitFor(t *testing.T, n int) []string {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		r.mu.Lock()
+		got := append([]string(nil), r.tasks...)
+		r.mu.Unlock()
+		if len(got) >= n {
+			return got
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("timed out waiting for %d deliveries", n)
+	return nil
+}
+
+func TestManagerFiltersAndResumes(t *testing.T) {
+	altDir := t.TempDir()
+	evPath := filepath.Join(altDir, "events.jsonl")
+	w := events.NewWriter(evPath)
+	rec := newRecorder(t)
+
+	// History before the sink exists is not replayed.
+	if err := w.Append(testEvent(events.AgentDied, "t-history")); err != nil {
+		t.Fatal(err)
+	}
+
+	sinks := []config.Sink{{
+		Name: "ops", Kind: config.SinkWebhook, URL: rec.srv.URL,
+		Types: []string{string(events.AgentDied), string(events.MergeConflict)},
+	}}
+	start := func() (context.CancelFunc, chan struct{}) {
+		m, err := NewManager(altDir, events.NewReader(evPath), sinks, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		m.poll = 20 * time.Millisecond
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan struct{})
+		go func() { m.Run(ctx); close(done) }()
+		// Wait for the initial cursor so the run has attached to the log.
+		for i := 0; i < 500; i++ {
+			if _, err := os.Stat(m.CursorPath("ops")); err == nil {
+				break
+			}
+			time.Sleep(2 * time.Millisecond)
+		}
+		return cancel, done
+	}
+
+	cancel, done := start()
+	if err := w.Append(
+		testEvent(events.AgentDied, "t-1"),
+		testEvent(events.TaskCreated, "t-skip"),
+		testEvent(events.MergeConflict, "t-2"),
+	); err != nil {
+		t.Fatal(err)
+	}
+	rec.waitFor(t, 2)
+	cancel()
+	<-done
+
+	// Appended while the daemon is down: delivered after restart.
+	if err := w.Append(testEvent(events.AgentDied, "t-3")); err != nil {
+		t.Fatal(err)
+	}
+	cancel, done = start()
+	defer func() { cancel(); <-done }()
+
+	got := rec.waitFor(t, 3)
+	want := []string{"t-1", "t-2", "t-3"}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("delivered %v, want %v", got, want)
+	}
+}
@@ -1,11 +1,13 @@
 // Package help provides embedded help content for Altera agent types.
 //
-// Help topics are organized by agent type (worker, liaison) and topic name.
-// Content is embedded at compile time using embed.FS.
+// Help topics are organized by agent type (worker, liaison, resolver) and topic name.
+// Content is embedded at compile time using embed.FS; a Project layers a
+// project's own files and config snippets over it.
 package help
 
 import (
 	"embed"
+	"errors"
 	"fmt"
 	"io/fs"
 	"path"
@@ -13,12 +15,15 @@ import (
 	"strings"
 )
 
-//go:embed worker/*.md liaison/*.md
+//go:embed worker/*.md liaison/*.md resolver/*.md
 var helpFS embed.FS
 
+// ErrNotFound is returned by Lookup for a topic that does not exist.
+var ErrNotFound = errors.New("topic not found")
+
 // AgentTypes returns the list of valid agent types.
 func AgentTypes() []string {
-	return []string{"liaison", "worker"}
+	return []string{"liaison", "resolver", "worker"}
 }
 
 // Topics returns the list of available topics for an agent type.
@@ -49,7 +54,7 @@ func Lookup(agentType string, topicParts ...string) (string, error) {
 	filePath := path.Join(agentType, strings.Join(topicParts, "/") + ".md")
 	data, err := helpFS.ReadFile(filePath)
 	if err != nil {
-		return "", fmt.Errorf("topic not found: %s %s", agentType, strings.Join(topicParts, " "))
+		return "", fmt.Errorf("%w: %s %s", ErrNotFound, agentType, strings.Join(topicParts, " "))
 	}
 	return string(data), nil
 }
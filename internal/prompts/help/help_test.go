@@ -7,10 +7,10 @@ import (
 
 func TestAgentTypes(t *testing.T) {
 	types := AgentTypes()
-	if len(types) != 2 {
-		t.Fatalf("expected 2 agent types, got %d", len(types))
+	if len(types) != 3 {
+		t.Fatalf("expected 3 agent types, got %d", len(types))
 	}
-	want := map[string]bool{"liaison": true, "worker": true}
+	want := map[string]bool{"liaison": true, "resolver": true, "worker": true}
 	for _, at := range types {
 		if !want[at] {
 			t.Errorf("unexpected agent type: %s", at)
@@ -50,6 +50,22 @@ func TestTopics_Liaison(t *testing.T) {
 	}
 }
 
+func TestTopics_Resolver(t *testing.T) {
+	topics, err := Topics("resolver")
+	if err != nil {
+		t.Fatalf("Topics(resolver) failed: %v", err)
+	}
+	expected := []string{"escalation", "startup", "strategy", "verify"}
+	if len(topics) != len(expected) {
+		t.Fatalf("expected %d resolver topics, got %d: %v", len(expected), len(topics), topics)
+	}
+	for i, want := range expected {
+		if topics[i] != want {
+			t.Errorf("topic[%d] = %q, want %q", i, topics[i], want)
+		}
+	}
+}
+
 func TestTopics_UnknownType(t *testing.T) {
 	_, err := Topics("unknown")
 	if err == nil {
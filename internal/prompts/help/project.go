@@ -0,0 +1,151 @@
+package help
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// PromptsDir is the directory under .alt/ holding project help files, laid
+// out like the embedded content: prompts/<agent-type>/<topic>.md.
+const PromptsDir = "prompts"
+
+// Project is the help content for one project: the embedded topics,
+// overridden or extended by files in .alt/prompts/, with inline snippets
+// from config appended.
+type Project struct {
+	dir      string                       // .alt/prompts; need not exist
+	snippets map[string]map[string]string // agent type -> topic -> text
+}
+
+// NewProject returns the help content for the project in altDir, with
+// snippets (agent type -> topic -> markdown) appended to their topics. An
+// empty altDir gives the embedded content plus snippets.
+func NewProject(altDir string, snippets map[string]map[string]string) *Project {
+	p := &Project{snippets: snippets}
+	if altDir != "" {
+		p.dir = filepath.Join(altDir, PromptsDir)
+	}
+	return p
+}
+
+// AgentTypes returns the embedded agent types plus any the project adds.
+func (p *Project) AgentTypes() []string {
+	seen := map[string]bool{}
+	for _, t := range AgentTypes() {
+		seen[t] = true
+	}
+	if p.dir != "" {
+		entries, _ := os.ReadDir(p.dir)
+		for _, e := range entries {
+			if e.IsDir() && validName(e.Name()) {
+				seen[e.Name()] = true
+			}
+		}
+	}
+	for t := range p.snippets {
+		seen[t] = true
+	}
+	types := make([]string, 0, len(seen))
+	for t := range seen {
+		types = append(types, t)
+	}
+	sort.Strings(types)
+	return types
+}
+
+// Topics returns the topics for agentType from every source.
+func (p *Project) Topics(agentType string) ([]string, error) {
+	if !validName(agentType) {
+		return nil, fmt.Errorf("unknown agent type: %s", agentType)
+	}
+	seen := map[string]bool{}
+	known := false
+	if topics, err := Topics(agentType); err == nil {
+		known = true
+		for _, t := range topics {
+			seen[t] = true
+		}
+	}
+	if p.dir != "" {
+		entries, err := os.ReadDir(filepath.Join(p.dir, agentType))
+		known = known || err == nil
+		for _, e := range entries {
+			if name, ok := strings.CutSuffix(e.Name(), ".md"); ok && !e.IsDir() && validName(name) {
+				seen[name] = true
+			}
+		}
+	}
+	if snippets, ok := p.snippets[agentType]; ok {
+		known = true
+		for t := range snippets {
+			seen[t] = true
+		}
+	}
+	if !known {
+		return nil, fmt.Errorf("unknown agent type: %s", agentType)
+	}
+	topics := make([]string, 0, len(seen))
+	for t := range seen {
+		topics = append(topics, t)
+	}
+	sort.Strings(topics)
+	return topics, nil
+}
+
+// Lookup returns a topic's content: the project file if there is one,
+// otherwise the embedded topic, followed by any snippet for the topic.
+func (p *Project) Lookup(agentType string, topicParts ...string) (string, error) {
+	if len(topicParts) == 0 {
+		return "", fmt.Errorf("no topic specified")
+	}
+	notFound := fmt.Errorf("%w: %s %s", ErrNotFound, agentType, strings.Join(topicParts, " "))
+	for _, part := range append([]string{agentType}, topicParts...) {
+		if !validName(part) {
+			return "", notFound
+		}
+	}
+
+	content, err := p.projectFile(agentType, topicParts)
+	if err != nil {
+		return "", err
+	}
+	if content == "" {
+		content, _ = Lookup(agentType, topicParts...)
+	}
+	if snippet := p.snippets[agentType][strings.Join(topicParts, "/")]; snippet != "" {
+		if content != "" {
+			content = strings.TrimRight(content, "\n") + "\n\n"
+		}
+		content += snippet
+	}
+	if content == "" {
+		return "", notFound
+	}
+	return content, nil
+}
+
+func (p *Project) projectFile(agentType string, topicParts []string) (string, error) {
+	if p.dir == "" {
+		return "", nil
+	}
+	path := filepath.Join(append([]string{p.dir, agentType}, topicParts...)...) + ".md"
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("reading %s: %w", path, err)
+	}
+	return string(data), nil
+}
+
+// validName reports whether s can name an agent type or topic: non-empty,
+// not hidden, and free of path separators.
+func validName(s string) bool {
+	return s != "" && !strings.HasPrefix(s, ".") && !strings.ContainsAny(s, `/\`)
+}
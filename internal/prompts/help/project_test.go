@@ -0,0 +1,83 @@
+package help
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func writePrompt(t *testing.T, altDir, rel, content string) {
+	t.Helper()
+	path := filepath.Join(altDir, PromptsDir, rel)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestProjectOverridesAndAdditions(t *testing.T) {
+	altDir := t.TempDir()
+	writePrompt(t, altDir, "worker/commit.md", "# House commit rules\n")
+	writePrompt(t, altDir, "worker/style.md", "# Style guide\n")
+	writePrompt(t, altDir, "reviewer/startup.md", "# Reviewer\n")
+	p := NewProject(altDir, map[string]map[string]string{
+		"worker":  {"startup": "Always run make lint.", "deploy": "Never deploy."},
+		"liaison": {"startup": "Ask before cancelling tasks."},
+	})
+
+	if got, _ := p.Lookup("worker", "commit"); got != "# House commit rules\n" {
+		t.Errorf("override = %q", got)
+	}
+	if got, _ := p.Lookup("worker", "style"); !strings.Contains(got, "Style guide") {
+		t.Errorf("added topic = %q", got)
+	}
+	got, err := p.Lookup("worker", "startup")
+	if err != nil || !strings.Contains(got, "Worker: Startup") || !strings.HasSuffix(got, "\n\nAlways run make lint.") {
+		t.Errorf("startup with snippet = %q, %v", got, err)
+	}
+	if got, _ := p.Lookup("worker", "deploy"); got != "Never deploy." {
+		t.Errorf("snippet-only topic = %q", got)
+	}
+
+	topics, err := p.Topics("worker")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"checkpoint", "commit", "deploy", "startup", "style"} {
+		if !slices.Contains(topics, want) {
+			t.Errorf("Topics(worker) = %v, missing %q", topics, want)
+		}
+	}
+	if types := p.AgentTypes(); !slices.Equal(types, []string{"liaison", "resolver", "reviewer", "worker"}) {
+		t.Errorf("AgentTypes = %v", types)
+	}
+	if _, err := p.Topics("nobody"); err == nil {
+		t.Error("expected error for unknown agent type")
+	}
+}
+
+func TestProjectLookupRejectsPaths(t *testing.T) {
+	altDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(altDir, "secret.md"), []byte("secret"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	p := NewProject(altDir, nil)
+	for _, parts := range [][]string{{".."}, {"../../secret"}, {"..", "secret"}} {
+		if _, err := p.Lookup("worker", parts...); err == nil {
+			t.Errorf("Lookup(worker, %v) succeeded", parts)
+		}
+	}
+}
+
+func TestProjectWithoutDir(t *testing.T) {
+	p := NewProject("", nil)
+	got, err := p.Lookup("worker", "startup")
+	want, _ := Lookup("worker", "startup")
+	if err != nil || got != want {
+		t.Errorf("Lookup without project = %q, %v", got, err)
+	}
+}
@@ -0,0 +1,145 @@
+package help
+
+import (
+	"embed"
+	"errors"
+	"fmt"
+	"strings"
+	"text/template"
+	"time"
+)
+
+//go:embed prime/*.md
+var primeFS embed.FS
+
+// Data is what help, prime and project prompt templates can refer to, as
+// {{.Task.Title}}, {{.Agent.ID}}, {{.Config.TestCommand}} and so on. Task
+// is nil for an agent without a task, so templates guard it with
+// {{with .Task}}.
+type Data struct {
+	Agent    Agent
+	Task     *Task
+	Config   Config
+	Events   []Event   // recent events, oldest first
+	TaskJSON string    // the worker's task.json, if it has one
+	Conflict *Conflict // the resolver's merge conflict, if it has one
+}
+
+// Agent describes the agent a prompt is rendered for.
+type Agent struct {
+	ID       string
+	Role     string
+	Worktree string
+}
+
+// Task describes the agent's current task. Intent is the task's
+// description; Attempts counts the workers spawned for it, this one
+// included.
+type Task struct {
+	ID         string
+	Title      string
+	Intent     string
+	Status     string
+	Branch     string
+	Checkpoint string
+	Deps       []string
+	Tags       []string
+	Priority   int
+	Attempts   int
+	Budget     float64
+}
+
+// Conflict describes the merge conflict a resolver was spawned for, from
+// its conflict-context.json. Ours is the base branch, theirs the task's.
+// Attempt counts resolvers spawned for this merge, this one included;
+// MaxAttempts is the project's limit, or zero if unknown.
+type Conflict struct {
+	TaskID      string
+	Branch      string
+	BaseBranch  string
+	Intent      string
+	Attempt     int
+	MaxAttempts int
+	Files       []ConflictFile
+}
+
+// ConflictFile is one conflicting file and the hunks still marked in it.
+type ConflictFile struct {
+	Path  string
+	Hunks []Hunk
+}
+
+// Hunk is one conflict region. Line is the 1-based line of its <<<<<<<
+// marker; Ours and Theirs hold the lines on each side, possibly truncated.
+type Hunk struct {
+	Line   int
+	Ours   string
+	Theirs string
+}
+
+// Config holds the project settings prompts most often need.
+type Config struct {
+	RepoPath      string
+	DefaultBranch string
+	TestCommand   string
+}
+
+// Event is one recent entry from the event log.
+type Event struct {
+	Time    time.Time
+	Type    string
+	AgentID string
+	TaskID  string
+}
+
+var funcs = template.FuncMap{
+	"join": strings.Join,
+}
+
+// Render executes content as a text/template over data. name identifies
+// the content in errors.
+func Render(name, content string, data Data) (string, error) {
+	tmpl, err := template.New(name).Funcs(funcs).Parse(content)
+	if err != nil {
+		return "", fmt.Errorf("parsing %s: %w", name, err)
+	}
+	var b strings.Builder
+	if err := tmpl.Execute(&b, data); err != nil {
+		return "", fmt.Errorf("rendering %s: %w", name, err)
+	}
+	return b.String(), nil
+}
+
+// Render looks up a topic and renders it with data.
+func (p *Project) Render(data Data, agentType string, topicParts ...string) (string, error) {
+	content, err := p.Lookup(agentType, topicParts...)
+	if err != nil {
+		return "", err
+	}
+	return Render(agentType+" "+strings.Join(topicParts, " "), content, data)
+}
+
+// Prime renders the `alt prime` output for data.Agent.Role: the built-in
+// prime template for the role, if there is one, followed by the project's
+// "prime" topic, if it defines one.
+func (p *Project) Prime(data Data) (string, error) {
+	var out string
+	if builtin, err := primeFS.ReadFile("prime/" + data.Agent.Role + ".md"); err == nil {
+		rendered, err := Render(data.Agent.Role+" prime", string(builtin), data)
+		if err != nil {
+			return "", err
+		}
+		out = rendered
+	}
+	project, err := p.Render(data, data.Agent.Role, "prime")
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return out, nil
+		}
+		return out, err
+	}
+	if out != "" {
+		out = strings.TrimRight(out, "\n") + "\n\n"
+	}
+	return out + project, nil
+}
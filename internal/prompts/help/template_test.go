@@ -0,0 +1,116 @@
+package help
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPrimeWorker(t *testing.T) {
+	data := Data{
+		Agent:    Agent{ID: "worker-1", Role: "worker"},
+		Task:     &Task{ID: "t-1", Title: "Add login", Deps: []string{"t-0"}, Attempts: 2, Checkpoint: "half done"},
+		Config:   Config{TestCommand: "go test ./..."},
+		TaskJSON: "{\"id\": \"t-1\"}\n",
+	}
+	out, err := NewProject("", nil).Prime(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"# Worker Agent: worker-1",
+		"- **Task**: Add login `t-1`",
+		"- **Depends on**: t-0",
+		"- **Attempt**: 2",
+		"- **Tests**: `go test ./...`",
+		"```json\n{\"id\": \"t-1\"}\n```",
+		"## Checkpoint (resuming)\n\nhalf done",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("prime missing %q:\n%s", want, out)
+		}
+	}
+
+	out, err = NewProject("", nil).Prime(Data{Agent: Agent{ID: "worker-2", Role: "worker"}})
+	if err != nil || !strings.Contains(out, "No task currently assigned.") || strings.Contains(out, "Checkpoint") {
+		t.Errorf("prime without task = %q, %v", out, err)
+	}
+}
+
+func TestPrimeResolver(t *testing.T) {
+	data := Data{
+		Agent: Agent{ID: "resolver-01", Role: "resolver"},
+		Task:  &Task{ID: "t-1", Title: "Add login", Checkpoint: "ESCALATE: unclear"},
+		Conflict: &Conflict{
+			TaskID: "t-1", Branch: "worker/t-1", BaseBranch: "main",
+			Intent: "Create POST /api/login", Attempt: 2, MaxAttempts: 3,
+			Files: []ConflictFile{
+				{Path: "api.go", Hunks: []Hunk{{Line: 12, Ours: "a()\n", Theirs: "b()\n"}}},
+				{Path: "done.go"},
+			},
+		},
+	}
+	out, err := NewProject("", nil).Prime(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"# Resolver Agent: resolver-01",
+		"- **Task**: Add login `t-1`",
+		"- **Merging**: `worker/t-1` (theirs) into `main` (ours)",
+		"- **Attempt**: 2 of 3",
+		"Earlier resolvers did not finish this merge.",
+		"## Task Intent\n\nCreate POST /api/login",
+		"### api.go\n\nLine 12, ours:\n```\na()\n```\ntheirs:\n```\nb()\n```",
+		"### done.go\n\nNo conflict markers left.",
+		"## Checkpoint\n\nESCALATE: unclear",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("prime missing %q:\n%s", want, out)
+		}
+	}
+
+	out, err = NewProject("", nil).Prime(Data{Agent: Agent{ID: "resolver-02", Role: "resolver"}})
+	if err != nil || !strings.Contains(out, "No conflict-context.json found") || strings.Contains(out, "## Conflicts") {
+		t.Errorf("prime without conflict = %q, %v", out, err)
+	}
+}
+
+func TestProjectTemplates(t *testing.T) {
+	altDir := t.TempDir()
+	writePrompt(t, altDir, "worker/prime.md", "Run {{.Config.TestCommand}} before {{with .Task}}finishing {{.ID}}{{end}}.\n")
+	writePrompt(t, altDir, "worker/broken.md", "{{.Task.Nope}}")
+	p := NewProject(altDir, map[string]map[string]string{"liaison": {"prime": "Branch: {{.Config.DefaultBranch}}"}})
+	data := Data{
+		Agent:  Agent{ID: "worker-1", Role: "worker"},
+		Task:   &Task{ID: "t-9", Title: "x"},
+		Config: Config{TestCommand: "make test", DefaultBranch: "trunk"},
+	}
+
+	out, err := p.Prime(data)
+	if err != nil || !strings.Contains(out, "# Worker Agent") || !strings.HasSuffix(out, "Run make test before finishing t-9.\n") {
+		t.Errorf("worker prime = %q, %v", out, err)
+	}
+	data.Agent = Agent{ID: "liaison-01", Role: "liaison"}
+	if out, err := p.Prime(data); err != nil || out != "Branch: trunk" {
+		t.Errorf("liaison prime = %q, %v", out, err)
+	}
+	if _, err := p.Render(data, "worker", "broken"); err == nil {
+		t.Error("expected error for a bad template field")
+	}
+}
+
+func TestEmbeddedTopicsRender(t *testing.T) {
+	p := NewProject("", nil)
+	for _, agentType := range AgentTypes() {
+		topics, _ := Topics(agentType)
+		for _, topic := range topics {
+			if _, err := p.Render(Data{}, agentType, topic); err != nil {
+				t.Errorf("%s %s: %v", agentType, topic, err)
+			}
+		}
+	}
+	out, _ := p.Render(Data{}, "liaison", "startup")
+	if !strings.Contains(out, "`{{.Task.Title}}`") {
+		t.Error("liaison startup does not show template syntax literally")
+	}
+}
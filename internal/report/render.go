@@ -0,0 +1,254 @@
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Output formats accepted by Render.
+const (
+	FormatMarkdown = "markdown"
+	FormatJSON     = "json"
+	FormatHTML     = "html"
+)
+
+// Render writes r in the given format.
+func Render(w io.Writer, r Report, format string) error {
+	switch format {
+	case FormatMarkdown, "md":
+		return WriteMarkdown(w, r)
+	case FormatJSON:
+		return WriteJSON(w, r)
+	case FormatHTML:
+		return WriteHTML(w, r)
+	default:
+		return fmt.Errorf("report: unknown format %q (want markdown, json or html)", format)
+	}
+}
+
+// WriteJSON writes r as indented JSON.
+func WriteJSON(w io.Writer, r Report) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r)
+}
+
+// WriteMarkdown writes r as a Markdown document.
+func WriteMarkdown(w io.Writer, r Report) error {
+	var b strings.Builder
+	b.WriteString("# Altera run report\n\n")
+	if !r.From.IsZero() {
+		fmt.Fprintf(&b, "Events from %s to %s (%s).\n\n",
+			r.From.Format(time.RFC3339), r.To.Format(time.RFC3339), fmtSeconds(r.To.Sub(r.From).Seconds()))
+	}
+
+	b.WriteString("## Summary\n\n")
+	b.WriteString("| Metric | Value |\n|---|---|\n")
+	fmt.Fprintf(&b, "| Tasks | %d (%d merged, %d failed, %d open) |\n", r.Tasks.Total, r.Tasks.Merged, r.Tasks.Failed, r.Tasks.Open)
+	fmt.Fprintf(&b, "| Merge attempts | %d (%d conflicts, %d failed) |\n", r.Merges.Attempts, r.Merges.Conflicts, r.Merges.Failed)
+	fmt.Fprintf(&b, "| Conflict rate | %s |\n", fmtPercent(r.Merges.ConflictRate, r.Merges.Success+r.Merges.Conflicts+r.Merges.Failed))
+	fmt.Fprintf(&b, "| Resolvers | %d spawned, %d resolved, %d failed, %d active |\n",
+		r.Resolvers.Spawned, r.Resolvers.Resolved, r.Resolvers.Failed, r.Resolvers.Active)
+	fmt.Fprintf(&b, "| Resolver success rate | %s |\n", fmtPercent(r.Resolvers.SuccessRate, r.Resolvers.Resolved+r.Resolvers.Failed))
+	fmt.Fprintf(&b, "| Total cost | %.2f |\n", r.Cost.Total)
+	fmt.Fprintf(&b, "| Cost per task | %.2f |\n", r.Cost.PerTask)
+	if r.Cost.Unattributed > 0 {
+		fmt.Fprintf(&b, "| Unattributed cost | %.2f |\n", r.Cost.Unattributed)
+	}
+
+	b.WriteString("\n## Timings\n\n")
+	b.WriteString("| Stage | Count | Mean | p50 | p90 | Max |\n|---|---|---|---|---|---|\n")
+	for _, s := range []struct {
+		name string
+		st   Stats
+	}{
+		{"Cycle (created → merged)", r.CycleTime},
+		{"Wait for worker", r.WaitTime},
+		{"Merge queue", r.MergeQueueTime},
+	} {
+		fmt.Fprintf(&b, "| %s | %d | %s | %s | %s | %s |\n", s.name, s.st.Count,
+			fmtSeconds(s.st.Mean), fmtSeconds(s.st.P50), fmtSeconds(s.st.P90), fmtSeconds(s.st.Max))
+	}
+
+	b.WriteString("\n## Agent deaths\n\n")
+	if len(r.Deaths) == 0 {
+		b.WriteString("None.\n")
+	} else {
+		b.WriteString("| Reason | Count |\n|---|---|\n")
+		for _, reason := range sortedKeys(r.Deaths) {
+			fmt.Fprintf(&b, "| %s | %d |\n", mdEscape(reason), r.Deaths[reason])
+		}
+	}
+
+	b.WriteString("\n## Tasks\n\n")
+	if len(r.TaskRows) == 0 {
+		b.WriteString("None.\n")
+	} else {
+		b.WriteString("| Task | Title | Status | Wait | Merge queue | Cycle | Conflicts | Cost |\n|---|---|---|---|---|---|---|---|\n")
+		for _, t := range r.TaskRows {
+			fmt.Fprintf(&b, "| %s | %s | %s | %s | %s | %s | %d | %.2f |\n", t.ID, mdEscape(t.Title), t.Status,
+				fmtSeconds(t.Wait), fmtSeconds(t.MergeQueue), fmtSeconds(t.Cycle), t.Conflicts, t.Cost)
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func sortedKeys(m map[string]int) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if m[keys[i]] != m[keys[j]] {
+			return m[keys[i]] > m[keys[j]]
+		}
+		return keys[i] < keys[j]
+	})
+	return keys
+}
+
+func mdEscape(s string) string {
+	return strings.NewReplacer("|", `\|`, "\n", " ").Replace(s)
+}
+
+// fmtSeconds renders a duration in seconds compactly; zero renders as "-".
+func fmtSeconds(s float64) string {
+	if s == 0 {
+		return "-"
+	}
+	d := time.Duration(s * float64(time.Second))
+	switch {
+	case d >= time.Hour:
+		return d.Round(time.Minute).String()
+	case d >= time.Minute:
+		return d.Round(time.Second).String()
+	default:
+		return d.Round(time.Millisecond).String()
+	}
+}
+
+// fmtPercent renders a rate, or "-" when nothing was measured.
+func fmtPercent(rate float64, n int) string {
+	if n == 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%.0f%%", rate*100)
+}
+
+// ganttBar is an AgentActivity positioned as percentages of the chart.
+type ganttBar struct {
+	AgentActivity
+	Left, Width float64
+	Duration    string
+}
+
+// WriteHTML writes r as a self-contained HTML page: summary tables plus a
+// Gantt chart with one bar per agent.
+func WriteHTML(w io.Writer, r Report) error {
+	start, end := r.From, r.To
+	for _, a := range r.Agents {
+		if start.IsZero() || a.Start.Before(start) {
+			start = a.Start
+		}
+		if a.End.After(end) {
+			end = a.End
+		}
+	}
+	span := end.Sub(start).Seconds()
+	bars := make([]ganttBar, 0, len(r.Agents))
+	for _, a := range r.Agents {
+		b := ganttBar{AgentActivity: a, Width: 100, Duration: fmtSeconds(a.End.Sub(a.Start).Seconds())}
+		if span > 0 {
+			b.Left = a.Start.Sub(start).Seconds() / span * 100
+			b.Width = a.End.Sub(a.Start).Seconds() / span * 100
+		}
+		if b.Width < 0.3 {
+			b.Width = 0.3
+		}
+		bars = append(bars, b)
+	}
+
+	return htmlTemplate.Execute(w, map[string]any{
+		"Report":      r,
+		"Bars":        bars,
+		"Start":       start,
+		"End":         end,
+		"Deaths":      sortedKeys(r.Deaths),
+		"ConflictPct": fmtPercent(r.Merges.ConflictRate, r.Merges.Success+r.Merges.Conflicts+r.Merges.Failed),
+		"ResolverPct": fmtPercent(r.Resolvers.SuccessRate, r.Resolvers.Resolved+r.Resolvers.Failed),
+	})
+}
+
+var htmlTemplate = template.Must(template.New("report").Funcs(template.FuncMap{
+	"secs": fmtSeconds,
+	"pct":  func(f float64) template.CSS { return template.CSS(fmt.Sprintf("%.3f%%", f)) },
+	"ts":   func(t time.Time) string { return t.Format("2006-01-02 15:04:05") },
+	"cost": func(f float64) string { return fmt.Sprintf("%.2f", f) },
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>Altera run report</title>
+<style>
+body { font-family: system-ui, sans-serif; margin: 2em; color: #222; }
+table { border-collapse: collapse; margin-bottom: 1.5em; }
+th, td { border: 1px solid #ccc; padding: 4px 8px; text-align: left; font-size: 14px; }
+th { background: #f4f4f4; }
+.gantt { position: relative; border: 1px solid #ccc; padding: 4px 0; }
+.row { position: relative; height: 22px; margin: 2px 0; }
+.label { position: absolute; left: 4px; width: 190px; font-size: 12px; line-height: 22px; overflow: hidden; white-space: nowrap; }
+.track { position: absolute; left: 200px; right: 8px; top: 0; bottom: 0; }
+.bar { position: absolute; top: 3px; height: 16px; border-radius: 3px; }
+.worker { background: #4c8bf5; }
+.resolver { background: #f5a54c; }
+.liaison { background: #8bc34a; }
+.failed { outline: 2px solid #d32f2f; }
+.axis { display: flex; justify-content: space-between; margin-left: 200px; font-size: 12px; color: #666; }
+</style>
+</head>
+<body>
+<h1>Altera run report</h1>
+{{with .Report}}{{if not .From.IsZero}}<p>Events from {{ts .From}} to {{ts .To}}.</p>{{end}}
+<h2>Summary</h2>
+<table>
+<tr><th>Tasks</th><td>{{.Tasks.Total}} ({{.Tasks.Merged}} merged, {{.Tasks.Failed}} failed, {{.Tasks.Open}} open)</td></tr>
+<tr><th>Merge attempts</th><td>{{.Merges.Attempts}} ({{.Merges.Conflicts}} conflicts, {{.Merges.Failed}} failed)</td></tr>
+<tr><th>Conflict rate</th><td>{{$.ConflictPct}}</td></tr>
+<tr><th>Resolvers</th><td>{{.Resolvers.Spawned}} spawned, {{.Resolvers.Resolved}} resolved, {{.Resolvers.Failed}} failed, {{.Resolvers.Active}} active</td></tr>
+<tr><th>Resolver success rate</th><td>{{$.ResolverPct}}</td></tr>
+<tr><th>Total cost</th><td>{{cost .Cost.Total}}</td></tr>
+<tr><th>Cost per task</th><td>{{cost .Cost.PerTask}}</td></tr>
+</table>
+<h2>Timings</h2>
+<table>
+<tr><th>Stage</th><th>Count</th><th>Mean</th><th>p50</th><th>p90</th><th>Max</th></tr>
+{{with .CycleTime}}<tr><td>Cycle (created → merged)</td><td>{{.Count}}</td><td>{{secs .Mean}}</td><td>{{secs .P50}}</td><td>{{secs .P90}}</td><td>{{secs .Max}}</td></tr>{{end}}
+{{with .WaitTime}}<tr><td>Wait for worker</td><td>{{.Count}}</td><td>{{secs .Mean}}</td><td>{{secs .P50}}</td><td>{{secs .P90}}</td><td>{{secs .Max}}</td></tr>{{end}}
+{{with .MergeQueueTime}}<tr><td>Merge queue</td><td>{{.Count}}</td><td>{{secs .Mean}}</td><td>{{secs .P50}}</td><td>{{secs .P90}}</td><td>{{secs .Max}}</td></tr>{{end}}
+</table>
+{{end}}
+<h2>Agent activity</h2>
+{{if .Bars}}<div class="gantt">
+{{range .Bars}}<div class="row"><div class="label">{{.Role}} {{.ID}}{{if .TaskID}} ({{.TaskID}}){{end}}</div><div class="track"><div class="bar {{.Role}}{{if and (ne .Outcome "active") (ne .Outcome "resolved")}} failed{{end}}" style="left: {{pct .Left}}; width: {{pct .Width}}" title="{{.ID}}: {{ts .Start}} → {{ts .End}} ({{.Duration}}, {{.Outcome}})"></div></div></div>
+{{end}}</div>
+<div class="axis"><span>{{ts .Start}}</span><span>{{ts .End}}</span></div>
+{{else}}<p>No agent activity.</p>{{end}}
+<h2>Agent deaths</h2>
+{{if .Deaths}}<table>
+<tr><th>Reason</th><th>Count</th></tr>
+{{range .Deaths}}<tr><td>{{.}}</td><td>{{index $.Report.Deaths .}}</td></tr>
+{{end}}</table>{{else}}<p>None.</p>{{end}}
+<h2>Tasks</h2>
+{{with .Report.TaskRows}}<table>
+<tr><th>Task</th><th>Title</th><th>Status</th><th>Wait</th><th>Merge queue</th><th>Cycle</th><th>Conflicts</th><th>Cost</th></tr>
+{{range .}}<tr><td>{{.ID}}</td><td>{{.Title}}</td><td>{{.Status}}</td><td>{{secs .Wait}}</td><td>{{secs .MergeQueue}}</td><td>{{secs .Cycle}}</td><td>{{.Conflicts}}</td><td>{{cost .Cost}}</td></tr>
+{{end}}</table>{{else}}<p>None.</p>{{end}}
+</body>
+</html>
+`))
@@ -0,0 +1,379 @@
+// Package report summarises a run from the event log and the task and agent
+// stores: how long tasks took end to end, how long they waited for a worker
+// and in the merge queue, how often merges conflicted and resolvers
+// succeeded, why agents died, and what each task cost. Reports render as
+// Markdown, JSON, or a self-contained HTML page with a Gantt chart of agent
+// activity.
+package report
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"github.com/anthropics/altera/internal/agent"
+	"github.com/anthropics/altera/internal/events"
+	"github.com/anthropics/altera/internal/task"
+)
+
+// Report is the computed summary of a run. Durations are in seconds so
+// the JSON form is easy to chart.
+type Report struct {
+	GeneratedAt time.Time `json:"generated_at"`
+	From        time.Time `json:"from"`
+	To          time.Time `json:"to"`
+
+	Tasks          TaskCounts      `json:"tasks"`
+	CycleTime      Stats           `json:"cycle_time"`
+	WaitTime       Stats           `json:"wait_time"`
+	MergeQueueTime Stats           `json:"merge_queue_time"`
+	Merges         MergeCounts     `json:"merges"`
+	Resolvers      ResolverCounts  `json:"resolvers"`
+	Deaths         map[string]int  `json:"deaths_by_reason"`
+	Cost           CostSummary     `json:"cost"`
+	TaskRows       []TaskRow       `json:"task_rows"`
+	Agents         []AgentActivity `json:"agents"`
+}
+
+// TaskCounts counts the tasks covered by the report by outcome.
+type TaskCounts struct {
+	Total  int `json:"total"`
+	Merged int `json:"merged"`
+	Failed int `json:"failed"`
+	Open   int `json:"open"`
+}
+
+// Stats summarises a set of durations.
+type Stats struct {
+	Count int     `json:"count"`
+	Mean  float64 `json:"mean_seconds"`
+	P50   float64 `json:"p50_seconds"`
+	P90   float64 `json:"p90_seconds"`
+	Max   float64 `json:"max_seconds"`
+}
+
+// MergeCounts counts merge attempts by outcome. ConflictRate is the share
+// of finished merges that conflicted.
+type MergeCounts struct {
+	Attempts     int     `json:"attempts"`
+	Success      int     `json:"success"`
+	Conflicts    int     `json:"conflicts"`
+	Failed       int     `json:"failed"`
+	ConflictRate float64 `json:"conflict_rate"`
+}
+
+// ResolverCounts counts conflict resolvers. A resolver succeeds when it
+// exits with reason "resolved"; Active resolvers are still running.
+type ResolverCounts struct {
+	Spawned     int     `json:"spawned"`
+	Resolved    int     `json:"resolved"`
+	Failed      int     `json:"failed"`
+	Active      int     `json:"active"`
+	SuccessRate float64 `json:"success_rate"`
+}
+
+// CostSummary totals token_cost. PerTask is the mean over tasks with any
+// recorded cost; Unattributed is cost recorded without a task ID.
+type CostSummary struct {
+	Total        float64 `json:"total"`
+	PerTask      float64 `json:"per_task"`
+	Unattributed float64 `json:"unattributed"`
+}
+
+// TaskRow is the per-task breakdown. Durations are zero when the task has
+// not reached the corresponding stage.
+type TaskRow struct {
+	ID         string    `json:"id"`
+	Title      string    `json:"title,omitempty"`
+	Status     string    `json:"status"`
+	Created    time.Time `json:"created"`
+	Cycle      float64   `json:"cycle_seconds,omitempty"`
+	Wait       float64   `json:"wait_seconds,omitempty"`
+	MergeQueue float64   `json:"merge_queue_seconds,omitempty"`
+	Conflicts  int       `json:"conflicts,omitempty"`
+	Cost       float64   `json:"cost,omitempty"`
+}
+
+// AgentActivity is one bar of the Gantt chart.
+type AgentActivity struct {
+	ID      string    `json:"id"`
+	Role    string    `json:"role"`
+	TaskID  string    `json:"task_id,omitempty"`
+	Start   time.Time `json:"start"`
+	End     time.Time `json:"end"`
+	Outcome string    `json:"outcome"` // death reason, or "active"
+}
+
+// taskTimes collects the lifecycle timestamps of one task.
+type taskTimes struct {
+	created, assigned, done, queued, merged time.Time
+	failed                                  bool
+	conflicts                               int
+	cost                                    float64
+}
+
+// Build computes a report from events and the current task and agent
+// records. Tasks are included if they appear in tasks or in evts; now
+// closes the Gantt bars of agents that are still running.
+func Build(evts []events.Event, tasks []*task.Task, agents []*agent.Agent, now time.Time) Report {
+	sort.SliceStable(evts, func(i, j int) bool { return evts[i].Timestamp.Before(evts[j].Timestamp) })
+
+	r := Report{GeneratedAt: now, Deaths: map[string]int{}}
+	if len(evts) > 0 {
+		r.From, r.To = evts[0].Timestamp, evts[len(evts)-1].Timestamp
+	}
+
+	byID := map[string]*taskTimes{}
+	get := func(id string) *taskTimes {
+		t, ok := byID[id]
+		if !ok {
+			t = &taskTimes{}
+			byID[id] = t
+		}
+		return t
+	}
+	titles := map[string]string{}
+	statuses := map[string]string{}
+	for _, t := range tasks {
+		tt := get(t.ID)
+		tt.created = t.CreatedAt
+		titles[t.ID] = t.Title
+		statuses[t.ID] = string(t.Status)
+	}
+
+	roles := map[string]string{}
+	bars := map[string]*AgentActivity{}
+	var barOrder []string
+	for _, a := range agents {
+		roles[a.ID] = string(a.Role)
+	}
+
+	for _, ev := range evts {
+		if cost, ok := ev.Data["token_cost"].(float64); ok {
+			r.Cost.Total += cost
+			if ev.TaskID == "" {
+				r.Cost.Unattributed += cost
+			} else {
+				get(ev.TaskID).cost += cost
+			}
+		}
+
+		var tt *taskTimes
+		if ev.TaskID != "" {
+			tt = get(ev.TaskID)
+			if tt.created.IsZero() {
+				tt.created = ev.Timestamp
+			}
+		}
+
+		switch ev.Type {
+		case events.TaskCreated:
+			if tt != nil && ev.Timestamp.Before(tt.created) {
+				tt.created = ev.Timestamp
+			}
+		case events.TaskAssigned:
+			if tt != nil && tt.assigned.IsZero() {
+				tt.assigned = ev.Timestamp
+			}
+		case events.TaskDone:
+			if tt != nil && tt.done.IsZero() {
+				tt.done = ev.Timestamp
+			}
+		case events.TaskFailed:
+			if tt != nil {
+				tt.failed = true
+			}
+		case events.MergeStarted:
+			r.Merges.Attempts++
+			if tt != nil && tt.queued.IsZero() && !tt.done.IsZero() {
+				tt.queued = ev.Timestamp
+			}
+		case events.MergeSuccess:
+			r.Merges.Success++
+			if tt != nil {
+				tt.merged = ev.Timestamp
+			}
+		case events.MergeConflict:
+			r.Merges.Conflicts++
+			if tt != nil {
+				tt.conflicts++
+			}
+		case events.MergeFailed:
+			r.Merges.Failed++
+			if tt != nil {
+				tt.failed = true
+			}
+		case events.AgentSpawned:
+			role, _ := ev.Data["role"].(string)
+			if role == "" {
+				role = roles[ev.AgentID]
+			}
+			if role == "" {
+				role = string(agent.RoleWorker)
+			}
+			roles[ev.AgentID] = role
+			if role == string(agent.RoleResolver) {
+				r.Resolvers.Spawned++
+			}
+			if _, ok := bars[ev.AgentID]; !ok {
+				barOrder = append(barOrder, ev.AgentID)
+			}
+			bars[ev.AgentID] = &AgentActivity{ID: ev.AgentID, Role: role, TaskID: ev.TaskID, Start: ev.Timestamp, Outcome: "active"}
+		case events.AgentDied:
+			reason, _ := ev.Data["reason"].(string)
+			if reason == "" {
+				reason = "unknown"
+			}
+			if roles[ev.AgentID] == string(agent.RoleResolver) {
+				if reason == "resolved" {
+					r.Resolvers.Resolved++
+				} else {
+					r.Resolvers.Failed++
+				}
+			}
+			if reason != "resolved" {
+				r.Deaths[reason]++
+			}
+			if b, ok := bars[ev.AgentID]; ok && b.End.IsZero() {
+				b.End = ev.Timestamp
+				b.Outcome = reason
+			}
+		}
+	}
+
+	// Agents the log never saw spawn (e.g. the liaison, or a window that
+	// starts mid-run) come from the agent store.
+	for _, a := range agents {
+		if _, ok := bars[a.ID]; ok || a.StartedAt.IsZero() {
+			continue
+		}
+		if !r.From.IsZero() && a.StartedAt.Before(r.From) && a.Status == agent.StatusDead {
+			continue
+		}
+		barOrder = append(barOrder, a.ID)
+		bars[a.ID] = &AgentActivity{ID: a.ID, Role: string(a.Role), TaskID: a.CurrentTask, Start: a.StartedAt, Outcome: "active"}
+		if a.Status == agent.StatusDead {
+			bars[a.ID].End = a.Heartbeat
+			bars[a.ID].Outcome = "dead"
+		}
+	}
+	for _, id := range barOrder {
+		b := bars[id]
+		if b.End.IsZero() {
+			b.End = now
+			if a := findAgent(agents, id); a != nil && a.Status == agent.StatusDead {
+				b.End = a.Heartbeat
+				b.Outcome = "dead"
+			}
+		}
+		if b.End.Before(b.Start) {
+			b.End = b.Start
+		}
+		if b.Outcome == "active" && b.Role == string(agent.RoleResolver) {
+			r.Resolvers.Active++
+		}
+		r.Agents = append(r.Agents, *b)
+	}
+	sort.SliceStable(r.Agents, func(i, j int) bool { return r.Agents[i].Start.Before(r.Agents[j].Start) })
+
+	var cycle, wait, queue []time.Duration
+	var costed int
+	ids := make([]string, 0, len(byID))
+	for id := range byID {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		a, b := byID[ids[i]], byID[ids[j]]
+		if !a.created.Equal(b.created) {
+			return a.created.Before(b.created)
+		}
+		return ids[i] < ids[j]
+	})
+	for _, id := range ids {
+		tt := byID[id]
+		row := TaskRow{ID: id, Title: titles[id], Status: statuses[id], Created: tt.created, Conflicts: tt.conflicts, Cost: tt.cost}
+		switch {
+		case !tt.merged.IsZero():
+			r.Tasks.Merged++
+			d := tt.merged.Sub(tt.created)
+			cycle = append(cycle, d)
+			row.Cycle = d.Seconds()
+			if row.Status == "" {
+				row.Status = "merged"
+			}
+		case tt.failed:
+			r.Tasks.Failed++
+		default:
+			r.Tasks.Open++
+		}
+		if row.Status == "" {
+			row.Status = "unknown"
+		}
+		if !tt.assigned.IsZero() {
+			d := tt.assigned.Sub(tt.created)
+			wait = append(wait, d)
+			row.Wait = d.Seconds()
+		}
+		if !tt.queued.IsZero() {
+			d := tt.queued.Sub(tt.done)
+			queue = append(queue, d)
+			row.MergeQueue = d.Seconds()
+		}
+		if tt.cost > 0 {
+			costed++
+		}
+		r.TaskRows = append(r.TaskRows, row)
+	}
+	r.Tasks.Total = len(ids)
+	r.CycleTime = newStats(cycle)
+	r.WaitTime = newStats(wait)
+	r.MergeQueueTime = newStats(queue)
+
+	if outcomes := r.Merges.Success + r.Merges.Conflicts + r.Merges.Failed; outcomes > 0 {
+		r.Merges.ConflictRate = float64(r.Merges.Conflicts) / float64(outcomes)
+	}
+	if finished := r.Resolvers.Resolved + r.Resolvers.Failed; finished > 0 {
+		r.Resolvers.SuccessRate = float64(r.Resolvers.Resolved) / float64(finished)
+	}
+	if costed > 0 {
+		r.Cost.PerTask = (r.Cost.Total - r.Cost.Unattributed) / float64(costed)
+	}
+	return r
+}
+
+func findAgent(agents []*agent.Agent, id string) *agent.Agent {
+	for _, a := range agents {
+		if a.ID == id {
+			return a
+		}
+	}
+	return nil
+}
+
+// newStats computes summary statistics. Percentiles use the nearest-rank
+// method.
+func newStats(ds []time.Duration) Stats {
+	if len(ds) == 0 {
+		return Stats{}
+	}
+	sorted := append([]time.Duration(nil), ds...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	var sum time.Duration
+	for _, d := range sorted {
+		sum += d
+	}
+	rank := func(p float64) float64 {
+		i := int(math.Ceil(p*float64(len(sorted)))) - 1
+		if i < 0 {
+			i = 0
+		}
+		return sorted[i].Seconds()
+	}
+	return Stats{
+		Count: len(sorted),
+		Mean:  (sum / time.Duration(len(sorted))).Seconds(),
+		P50:   rank(0.5),
+		P90:   rank(0.9),
+		Max:   sorted[len(sorted)-1].Seconds(),
+	}
+}
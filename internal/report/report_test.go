@@ -0,0 +1,160 @@
+package report
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/anthropics/altera/internal/agent"
+	"github.com/anthropics/altera/internal/events"
+	"github.com/anthropics/altera/internal/task"
+)
+
+var t0 = time.Date(2026, 2, 19, 12, 0, 0, 0, time.UTC)
+
+func ev(min int, typ events.Type, agentID, taskID string, data map[string]any) events.Event {
+	return events.Event{
+		Timestamp: t0.Add(time.Duration(min) * time.Minute),
+		Type:      typ,
+		AgentID:   agentID,
+		TaskID:    taskID,
+		Data:      data,
+	}
+}
+
+// run has one task that merges after a resolved conflict, one that merges
+// cleanly, and one whose worker died and is still open.
+func run() ([]events.Event, []*task.Task, []*agent.Agent) {
+	evts := []events.Event{
+		ev(0, events.TaskCreated, "", "t-1", nil),
+		ev(0, events.TaskCreated, "", "t-2", nil),
+		ev(0, events.TaskCreated, "", "t-3", nil),
+		ev(2, events.TaskAssigned, "w-1", "t-1", nil),
+		ev(2, events.AgentSpawned, "w-1", "t-1", nil),
+		ev(4, events.TaskAssigned, "w-2", "t-2", nil),
+		ev(4, events.AgentSpawned, "w-2", "t-2", nil),
+		ev(6, events.TaskAssigned, "w-3", "t-3", nil),
+		ev(6, events.AgentSpawned, "w-3", "t-3", nil),
+		ev(10, events.BudgetExceeded, "w-1", "t-1", map[string]any{"token_cost": 3.0}),
+		ev(12, events.AgentDied, "w-3", "t-3", map[string]any{"reason": "heartbeat timeout"}),
+		ev(20, events.TaskDone, "w-1", "t-1", nil),
+		ev(21, events.MergeStarted, "w-1", "t-1", nil),
+		ev(21, events.MergeConflict, "w-1", "t-1", nil),
+		ev(22, events.AgentSpawned, "resolver-01", "t-1", map[string]any{"role": "resolver"}),
+		ev(25, events.AgentDied, "resolver-01", "t-1", map[string]any{"reason": "resolved"}),
+		ev(26, events.MergeStarted, "w-1", "t-1", nil),
+		ev(26, events.MergeSuccess, "w-1", "t-1", nil),
+		ev(30, events.TaskDone, "w-2", "t-2", nil),
+		ev(34, events.MergeStarted, "w-2", "t-2", nil),
+		ev(34, events.MergeSuccess, "w-2", "t-2", map[string]any{"token_cost": 1.0}),
+		ev(35, events.BudgetExceeded, "", "", map[string]any{"token_cost": 0.5}),
+	}
+	tasks := []*task.Task{
+		{ID: "t-1", Title: "first", Status: task.StatusDone, CreatedAt: t0},
+		{ID: "t-2", Title: "second", Status: task.StatusDone, CreatedAt: t0},
+		{ID: "t-3", Title: "third", Status: task.StatusOpen, CreatedAt: t0},
+	}
+	agents := []*agent.Agent{
+		{ID: "liaison-01", Role: agent.RoleLiaison, Status: agent.StatusActive, StartedAt: t0.Add(-time.Minute)},
+	}
+	return evts, tasks, agents
+}
+
+func TestBuild(t *testing.T) {
+	evts, tasks, agents := run()
+	r := Build(evts, tasks, agents, t0.Add(40*time.Minute))
+
+	if r.Tasks != (TaskCounts{Total: 3, Merged: 2, Open: 1}) {
+		t.Errorf("tasks = %+v", r.Tasks)
+	}
+	// Cycle: t-1 26m, t-2 34m.
+	if r.CycleTime.Count != 2 || r.CycleTime.Mean != (30*time.Minute).Seconds() || r.CycleTime.Max != (34*time.Minute).Seconds() {
+		t.Errorf("cycle = %+v", r.CycleTime)
+	}
+	// Wait: 2m, 4m, 6m.
+	if r.WaitTime.Count != 3 || r.WaitTime.P50 != (4*time.Minute).Seconds() {
+		t.Errorf("wait = %+v", r.WaitTime)
+	}
+	// Merge queue: t-1 1m, t-2 4m.
+	if r.MergeQueueTime.Count != 2 || r.MergeQueueTime.Max != (4*time.Minute).Seconds() {
+		t.Errorf("merge queue = %+v", r.MergeQueueTime)
+	}
+	if r.Merges.Attempts != 3 || r.Merges.Conflicts != 1 || r.Merges.Success != 2 {
+		t.Errorf("merges = %+v", r.Merges)
+	}
+	if got := r.Merges.ConflictRate; got < 0.33 || got > 0.34 {
+		t.Errorf("conflict rate = %v", got)
+	}
+	if r.Resolvers.Spawned != 1 || r.Resolvers.Resolved != 1 || r.Resolvers.SuccessRate != 1 {
+		t.Errorf("resolvers = %+v", r.Resolvers)
+	}
+	if len(r.Deaths) != 1 || r.Deaths["heartbeat timeout"] != 1 {
+		t.Errorf("deaths = %v", r.Deaths)
+	}
+	if r.Cost.Total != 4.5 || r.Cost.Unattributed != 0.5 || r.Cost.PerTask != 2 {
+		t.Errorf("cost = %+v", r.Cost)
+	}
+
+	if len(r.Agents) != 5 {
+		t.Fatalf("got %d agent bars, want 5: %+v", len(r.Agents), r.Agents)
+	}
+	if r.Agents[0].ID != "liaison-01" || !r.Agents[0].End.Equal(t0.Add(40*time.Minute)) {
+		t.Errorf("liaison bar = %+v", r.Agents[0])
+	}
+	for _, a := range r.Agents {
+		if a.ID == "w-3" && (a.Outcome != "heartbeat timeout" || !a.End.Equal(t0.Add(12*time.Minute))) {
+			t.Errorf("w-3 bar = %+v", a)
+		}
+	}
+}
+
+func TestRender(t *testing.T) {
+	evts, tasks, agents := run()
+	r := Build(evts, tasks, agents, t0.Add(40*time.Minute))
+
+	var md strings.Builder
+	if err := Render(&md, r, FormatMarkdown); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"| Tasks | 3 (2 merged, 0 failed, 1 open) |",
+		"| Conflict rate | 33% |",
+		"| Resolver success rate | 100% |",
+		"| Cycle (created → merged) | 2 | 30m0s |",
+		"| heartbeat timeout | 1 |",
+		"| t-1 | first | done | 2m0s | 1m0s | 26m0s | 1 | 3.00 |",
+	} {
+		if !strings.Contains(md.String(), want) {
+			t.Errorf("markdown missing %q:\n%s", want, md.String())
+		}
+	}
+
+	var js strings.Builder
+	if err := Render(&js, r, FormatJSON); err != nil {
+		t.Fatal(err)
+	}
+	var decoded Report
+	if err := json.Unmarshal([]byte(js.String()), &decoded); err != nil {
+		t.Fatalf("json: %v", err)
+	}
+	if decoded.Merges != r.Merges {
+		t.Errorf("json round trip: %+v", decoded.Merges)
+	}
+
+	var page strings.Builder
+	if err := Render(&page, r, FormatHTML); err != nil {
+		t.Fatal(err)
+	}
+	html := page.String()
+	if !strings.HasPrefix(html, "<!DOCTYPE html>") || strings.Count(html, `class="row"`) != 5 {
+		t.Errorf("html should have 5 gantt rows:\n%s", html)
+	}
+	if !strings.Contains(html, `class="bar worker failed" style="left: 17.073%; width: 14.634%"`) {
+		t.Errorf("w-3 bar not positioned as expected:\n%s", html)
+	}
+
+	if err := Render(&page, r, "pdf"); err == nil {
+		t.Error("expected error for unknown format")
+	}
+}
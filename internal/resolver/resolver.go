@@ -181,11 +181,20 @@ func (m *Manager) SpawnResolver(ctx ConflictContext) (*agent.Agent, error) {
 		return nil, fmt.Errorf("creating tmux session: %w", err)
 	}
 
-	initialPrompt := fmt.Sprintf(
-		"Read conflict-context.json, then resolve all merge conflicts. When done, commit and exit. Agent ID: %s, Task: %s",
-		id, ctx.TaskID,
-	)
-	claudeCmd := fmt.Sprintf("cd %s && claude --dangerously-skip-permissions %q", worktreePath, initialPrompt)
+	profile, err := agent.Profile(cfg, agent.RoleResolver, "")
+	if err != nil {
+		_ = tmux.KillSession(sessionName)
+		cleanup()
+		return nil, err
+	}
+	claudeCmd, err := agent.LaunchCommand(profile, agent.LaunchData{
+		AgentID: id, TaskID: ctx.TaskID, Role: agent.RoleResolver, Dir: worktreePath,
+	})
+	if err != nil {
+		_ = tmux.KillSession(sessionName)
+		cleanup()
+		return nil, err
+	}
 	if err := tmux.SendKeys(sessionName, claudeCmd); err != nil {
 		_ = tmux.KillSession(sessionName)
 		cleanup()
@@ -204,7 +213,7 @@ func (m *Manager) SpawnResolver(ctx ConflictContext) (*agent.Agent, error) {
 	}
 
 	// 6. Capture the pane PID (same pattern as daemon.spawnWorker).
-	time.Sleep(500 * time.Millisecond)
+	time.Sleep(time.Duration(cfg.Daemon.PIDSettleDelay))
 	panePID, _ := tmux.PanePID(sessionName)
 
 	// 7. Create agent record.
@@ -236,6 +245,7 @@ func (m *Manager) SpawnResolver(ctx ConflictContext) (*agent.Agent, error) {
 			"role":     "resolver",
 			"worktree": worktreePath,
 			"branch":   branchName,
+			"attempt":  ctx.ResolveAttempt,
 		},
 	})
 
@@ -420,8 +430,8 @@ type HookCmd struct {
 	Command string `json:"command"`
 }
 
-// writeClaudeSettings creates .claude/settings.json with a heartbeat hook
-// for the given agent ID.
+// writeClaudeSettings creates .claude/settings.json with prime, heartbeat
+// and checkpoint hooks for the given agent ID.
 func writeClaudeSettings(worktreePath, agentID string) error {
 	claudeDir := filepath.Join(worktreePath, ".claude")
 	if err := os.MkdirAll(claudeDir, 0o755); err != nil {
@@ -430,6 +440,12 @@ func writeClaudeSettings(worktreePath, agentID string) error {
 
 	settings := ClaudeSettings{
 		Hooks: map[string][]HookGroup{
+			"SessionStart": {
+				{
+					Matcher: "",
+					Hooks:   []HookCmd{{Type: "command", Command: fmt.Sprintf("ALT_AGENT_ID=%s alt prime", agentID)}},
+				},
+			},
 			"PreToolUse": {
 				{
 					Matcher: "",
@@ -280,6 +280,14 @@ func TestWriteClaudeSettings(t *testing.T) {
 	if stop[0].Hooks[0].Command != "alt checkpoint resolver-01" {
 		t.Errorf("Stop command = %q, want %q", stop[0].Hooks[0].Command, "alt checkpoint resolver-01")
 	}
+
+	start, ok := settings.Hooks["SessionStart"]
+	if !ok || len(start) == 0 || len(start[0].Hooks) == 0 {
+		t.Fatal("missing SessionStart hook")
+	}
+	if start[0].Hooks[0].Command != "ALT_AGENT_ID=resolver-01 alt prime" {
+		t.Errorf("SessionStart command = %q, want %q", start[0].Hooks[0].Command, "ALT_AGENT_ID=resolver-01 alt prime")
+	}
 }
 
 func TestHasConflictMarkers(t *testing.T) {
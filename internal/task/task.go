@@ -76,6 +76,7 @@ type Task struct {
 	Tags        []string  `json:"tags,omitempty"`
 	Priority    int       `json:"priority,omitempty"`
 	Checkpoint  string    `json:"checkpoint,omitempty"`
+	Budget      float64   `json:"budget,omitempty"` // spend cap for the task; 0 means none
 }
 
 // GenerateID creates a new task ID in the format t-{6 random hex chars}.
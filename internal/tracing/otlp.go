@@ -0,0 +1,250 @@
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultServiceName is the service.name resource attribute of exported
+// traces.
+const DefaultServiceName = "altera"
+
+// OTLP span kinds and status codes (opentelemetry-proto trace.proto).
+const (
+	spanKindInternal = 1
+	statusOK         = 1
+	statusError      = 2
+)
+
+// The otlp* types mirror the OTLP/JSON encoding of
+// ExportTraceServiceRequest. IDs are hex strings and 64-bit integers are
+// decimal strings, as the OTLP/JSON spec requires.
+type otlpRequest struct {
+	ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+}
+
+type otlpResourceSpans struct {
+	Resource   otlpResource     `json:"resource"`
+	ScopeSpans []otlpScopeSpans `json:"scopeSpans"`
+}
+
+type otlpResource struct {
+	Attributes []otlpKeyValue `json:"attributes"`
+}
+
+type otlpScopeSpans struct {
+	Scope otlpScope  `json:"scope"`
+	Spans []otlpSpan `json:"spans"`
+}
+
+type otlpScope struct {
+	Name string `json:"name"`
+}
+
+type otlpSpan struct {
+	TraceID           string         `json:"traceId"`
+	SpanID            string         `json:"spanId"`
+	ParentSpanID      string         `json:"parentSpanId,omitempty"`
+	Name              string         `json:"name"`
+	Kind              int            `json:"kind"`
+	StartTimeUnixNano string         `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string         `json:"endTimeUnixNano"`
+	Attributes        []otlpKeyValue `json:"attributes,omitempty"`
+	Events            []otlpEvent    `json:"events,omitempty"`
+	Status            otlpStatus     `json:"status"`
+}
+
+type otlpEvent struct {
+	TimeUnixNano string         `json:"timeUnixNano"`
+	Name         string         `json:"name"`
+	Attributes   []otlpKeyValue `json:"attributes,omitempty"`
+}
+
+type otlpStatus struct {
+	Code    int    `json:"code"`
+	Message string `json:"message,omitempty"`
+}
+
+type otlpKeyValue struct {
+	Key   string       `json:"key"`
+	Value otlpAnyValue `json:"value"`
+}
+
+type otlpAnyValue struct {
+	StringValue *string  `json:"stringValue,omitempty"`
+	BoolValue   *bool    `json:"boolValue,omitempty"`
+	IntValue    *string  `json:"intValue,omitempty"`
+	DoubleValue *float64 `json:"doubleValue,omitempty"`
+}
+
+// EncodeOTLP encodes traces as an OTLP/JSON ExportTraceServiceRequest.
+func EncodeOTLP(traces []Trace, serviceName string) ([]byte, error) {
+	if serviceName == "" {
+		serviceName = DefaultServiceName
+	}
+	spans := []otlpSpan{}
+	for _, tr := range traces {
+		for _, s := range tr.Spans {
+			spans = append(spans, toOTLPSpan(s))
+		}
+	}
+	req := otlpRequest{ResourceSpans: []otlpResourceSpans{{
+		Resource: otlpResource{Attributes: attributes(map[string]any{"service.name": serviceName})},
+		ScopeSpans: []otlpScopeSpans{{
+			Scope: otlpScope{Name: "github.com/anthropics/altera/internal/tracing"},
+			Spans: spans,
+		}},
+	}}}
+	data, err := json.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("tracing: encode: %w", err)
+	}
+	return data, nil
+}
+
+func toOTLPSpan(s Span) otlpSpan {
+	out := otlpSpan{
+		TraceID:           s.TraceID,
+		SpanID:            s.SpanID,
+		ParentSpanID:      s.ParentID,
+		Name:              s.Name,
+		Kind:              spanKindInternal,
+		StartTimeUnixNano: unixNano(s.Start),
+		EndTimeUnixNano:   unixNano(s.End),
+		Attributes:        attributes(s.Attributes),
+		Status:            otlpStatus{Code: statusOK},
+	}
+	if s.Error {
+		out.Status = otlpStatus{Code: statusError, Message: s.Status}
+	}
+	for _, ev := range s.Events {
+		out.Events = append(out.Events, otlpEvent{
+			TimeUnixNano: unixNano(ev.Time),
+			Name:         ev.Name,
+			Attributes:   attributes(ev.Attributes),
+		})
+	}
+	return out
+}
+
+func unixNano(t time.Time) string {
+	return strconv.FormatInt(t.UnixNano(), 10)
+}
+
+// attributes converts a map into OTLP key/values sorted by key. Lists are
+// flattened to comma-separated strings.
+func attributes(m map[string]any) []otlpKeyValue {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	out := make([]otlpKeyValue, 0, len(keys))
+	for _, k := range keys {
+		out = append(out, otlpKeyValue{Key: k, Value: anyValue(m[k])})
+	}
+	return out
+}
+
+func anyValue(v any) otlpAnyValue {
+	switch x := v.(type) {
+	case string:
+		return otlpAnyValue{StringValue: &x}
+	case bool:
+		return otlpAnyValue{BoolValue: &x}
+	case int:
+		s := strconv.Itoa(x)
+		return otlpAnyValue{IntValue: &s}
+	case int64:
+		s := strconv.FormatInt(x, 10)
+		return otlpAnyValue{IntValue: &s}
+	case float64:
+		if x == math.Trunc(x) && math.Abs(x) < 1<<53 {
+			s := strconv.FormatInt(int64(x), 10)
+			return otlpAnyValue{IntValue: &s}
+		}
+		return otlpAnyValue{DoubleValue: &x}
+	case []string:
+		s := strings.Join(x, ",")
+		return otlpAnyValue{StringValue: &s}
+	case []any:
+		parts := make([]string, len(x))
+		for i, p := range x {
+			parts[i] = fmt.Sprint(p)
+		}
+		s := strings.Join(parts, ",")
+		return otlpAnyValue{StringValue: &s}
+	default:
+		s := fmt.Sprint(v)
+		return otlpAnyValue{StringValue: &s}
+	}
+}
+
+// TracesURL returns the OTLP/HTTP traces URL for endpoint. A bare
+// collector address such as http://localhost:4318 gets the standard
+// /v1/traces path.
+func TracesURL(endpoint string) (string, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return "", fmt.Errorf("tracing: invalid endpoint %q: %w", endpoint, err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" || u.Host == "" {
+		return "", fmt.Errorf("tracing: endpoint %q must be an http(s) URL", endpoint)
+	}
+	if u.Path == "" || u.Path == "/" {
+		u.Path = "/v1/traces"
+	}
+	return u.String(), nil
+}
+
+// Exporter POSTs traces to an OTLP/HTTP collector.
+type Exporter struct {
+	url         string
+	serviceName string
+	client      *http.Client
+}
+
+// NewExporter creates an Exporter for the collector at endpoint.
+func NewExporter(endpoint, serviceName string) (*Exporter, error) {
+	u, err := TracesURL(endpoint)
+	if err != nil {
+		return nil, err
+	}
+	return &Exporter{url: u, serviceName: serviceName, client: &http.Client{Timeout: 10 * time.Second}}, nil
+}
+
+// Export sends traces in a single request.
+func (e *Exporter) Export(ctx context.Context, traces []Trace) error {
+	if len(traces) == 0 {
+		return nil
+	}
+	body, err := EncodeOTLP(traces, e.serviceName)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("tracing: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("tracing: export: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("tracing: collector %s returned %s", e.url, resp.Status)
+	}
+	return nil
+}
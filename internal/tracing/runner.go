@@ -0,0 +1,109 @@
+package tracing
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"path/filepath"
+	"time"
+
+	"github.com/anthropics/altera/internal/events"
+)
+
+// exportAttempts is how many times the Runner tries to export a finished
+// task's trace before giving up on it.
+const exportAttempts = 3
+
+// Runner exports each task's trace as soon as the task reaches a terminal
+// event. It follows the event log from a cursor persisted in
+// .alt/tracing.cursor, so traces finished while the daemon was down are
+// exported on the next start.
+type Runner struct {
+	altDir   string
+	evReader *events.Reader
+	exporter *Exporter
+	logger   *slog.Logger
+	poll     time.Duration
+	backoff  time.Duration
+}
+
+// NewRunner creates a Runner that sends traces through exporter.
+func NewRunner(altDir string, evReader *events.Reader, exporter *Exporter, logger *slog.Logger) *Runner {
+	if logger == nil {
+		logger = slog.New(slog.NewTextHandler(io.Discard, nil))
+	}
+	return &Runner{
+		altDir:   altDir,
+		evReader: evReader,
+		exporter: exporter,
+		logger:   logger,
+		poll:     events.DefaultPollInterval,
+		backoff:  time.Second,
+	}
+}
+
+// CursorPath returns the file holding the Runner's cursor.
+func (r *Runner) CursorPath() string {
+	return filepath.Join(r.altDir, "tracing.cursor")
+}
+
+// Run exports finished traces until ctx is done. Without a saved cursor it
+// starts at the end of the log; use alt export traces for history.
+func (r *Runner) Run(ctx context.Context) {
+	from, ok, err := events.LoadCursor(r.CursorPath())
+	if err != nil {
+		r.logger.Error("tracing: load cursor", "error", err)
+		return
+	}
+	if !ok {
+		if from, err = r.evReader.End(); err != nil {
+			r.logger.Error("tracing: find end of log", "error", err)
+			return
+		}
+		if err := events.SaveCursor(r.CursorPath(), from); err != nil {
+			r.logger.Error("tracing: save cursor", "error", err)
+			return
+		}
+	}
+
+	ch, err := r.evReader.Follow(ctx, events.Filter{}, from, events.WithPollInterval(r.poll))
+	if err != nil {
+		r.logger.Error("tracing: follow events", "error", err)
+		return
+	}
+	for d := range ch {
+		if Terminal(d.Event) {
+			if err := r.export(ctx, d.Event.TaskID); err != nil {
+				if ctx.Err() != nil {
+					return // shutting down; retried from the cursor on restart
+				}
+				r.logger.Error("tracing: export", "task", d.Event.TaskID, "error", err)
+			}
+		}
+		if err := events.SaveCursor(r.CursorPath(), d.Cursor); err != nil {
+			r.logger.Error("tracing: save cursor", "error", err)
+		}
+	}
+}
+
+// export builds and sends the trace of one task, retrying with backoff.
+func (r *Runner) export(ctx context.Context, taskID string) error {
+	evts, err := r.evReader.Read(events.Filter{TaskID: taskID})
+	if err != nil {
+		return err
+	}
+	traces := Build(evts)
+	backoff := r.backoff
+	for i := 0; ; i++ {
+		err = r.exporter.Export(ctx, traces)
+		if err == nil || i == exportAttempts-1 {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
@@ -0,0 +1,253 @@
+// Package tracing turns the event log into OpenTelemetry-compatible traces.
+// Each task becomes a trace whose root span runs from the task's first event
+// to its merge (or failure); worker and resolver agents and merge attempts
+// are child spans, and the remaining task events are attached as span
+// events. Traces are encoded as OTLP/JSON so they can be written to a file
+// or POSTed to a local collector (Jaeger, Tempo, the OTel Collector).
+package tracing
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/anthropics/altera/internal/events"
+)
+
+// Span is one timed operation within a task trace.
+type Span struct {
+	TraceID    string
+	SpanID     string
+	ParentID   string // empty for the root span
+	Name       string
+	Start      time.Time
+	End        time.Time
+	Attributes map[string]any
+	Events     []SpanEvent
+	Error      bool
+	Status     string // status message when Error is set
+}
+
+// SpanEvent is a point-in-time annotation on a span.
+type SpanEvent struct {
+	Name       string
+	Time       time.Time
+	Attributes map[string]any
+}
+
+// Trace holds the spans of one task, root span first.
+type Trace struct {
+	TaskID   string
+	TraceID  string
+	Spans    []Span
+	Complete bool // the task reached a terminal event (merged or failed)
+}
+
+// Terminal reports whether ev ends a task's trace.
+func Terminal(ev events.Event) bool {
+	switch ev.Type {
+	case events.MergeSuccess, events.MergeFailed, events.TaskFailed:
+		return true
+	}
+	return false
+}
+
+// Build groups events by task and builds one trace per task, ordered by
+// the task's first event. Events without a task ID are ignored.
+func Build(evts []events.Event) []Trace {
+	var order []string
+	byTask := map[string][]events.Event{}
+	for _, ev := range evts {
+		if ev.TaskID == "" {
+			continue
+		}
+		if _, ok := byTask[ev.TaskID]; !ok {
+			order = append(order, ev.TaskID)
+		}
+		byTask[ev.TaskID] = append(byTask[ev.TaskID], ev)
+	}
+	traces := make([]Trace, 0, len(order))
+	for _, id := range order {
+		traces = append(traces, buildTask(id, byTask[id]))
+	}
+	return traces
+}
+
+// buildTask builds the trace for a single task from its events.
+func buildTask(taskID string, evts []events.Event) Trace {
+	sort.SliceStable(evts, func(i, j int) bool { return evts[i].Timestamp.Before(evts[j].Timestamp) })
+
+	tr := Trace{TaskID: taskID, TraceID: traceID(taskID)}
+	root := Span{
+		TraceID:    tr.TraceID,
+		SpanID:     spanID(taskID, "task", "", time.Time{}),
+		Name:       "task " + taskID,
+		Start:      evts[0].Timestamp,
+		End:        evts[len(evts)-1].Timestamp,
+		Attributes: map[string]any{"alt.task.id": taskID, "alt.task.outcome": "open"},
+	}
+
+	var children []*Span
+	openAgents := map[string]*Span{}
+	var openMerge *Span
+
+	newChild := func(name, agentID string, start time.Time, attrs map[string]any) *Span {
+		s := &Span{
+			TraceID:    tr.TraceID,
+			SpanID:     spanID(taskID, name, agentID, start),
+			ParentID:   root.SpanID,
+			Name:       name,
+			Start:      start,
+			Attributes: attrs,
+		}
+		children = append(children, s)
+		return s
+	}
+	annotate := func(ev events.Event) {
+		target := &root
+		if s, ok := openAgents[ev.AgentID]; ok && ev.AgentID != "" {
+			target = s
+		}
+		target.Events = append(target.Events, SpanEvent{
+			Name:       string(ev.Type),
+			Time:       ev.Timestamp,
+			Attributes: eventAttributes(ev),
+		})
+	}
+
+	for _, ev := range evts {
+		switch ev.Type {
+		case events.AgentSpawned:
+			role := agentRole(ev)
+			attrs := map[string]any{"alt.agent.id": ev.AgentID, "alt.agent.role": role}
+			if a, ok := ev.Data["attempt"]; ok {
+				attrs["alt.resolve.attempt"] = a
+			}
+			if prev, ok := openAgents[ev.AgentID]; ok {
+				prev.End = ev.Timestamp
+			}
+			openAgents[ev.AgentID] = newChild(role+" "+ev.AgentID, ev.AgentID, ev.Timestamp, attrs)
+
+		case events.AgentDied:
+			s, ok := openAgents[ev.AgentID]
+			if !ok {
+				annotate(ev)
+				continue
+			}
+			s.End = ev.Timestamp
+			reason, _ := ev.Data["reason"].(string)
+			if reason != "" {
+				s.Attributes["alt.agent.exit_reason"] = reason
+			}
+			if reason != "resolved" {
+				s.Error = true
+				s.Status = "agent died: " + reason
+			}
+			delete(openAgents, ev.AgentID)
+
+		case events.MergeStarted:
+			if openMerge != nil {
+				openMerge.End = ev.Timestamp
+			}
+			openMerge = newChild("merge", "", ev.Timestamp, map[string]any{"alt.agent.id": ev.AgentID})
+
+		case events.MergeSuccess, events.MergeConflict, events.MergeFailed:
+			s := openMerge
+			if s == nil {
+				s = newChild("merge", "", ev.Timestamp, map[string]any{"alt.agent.id": ev.AgentID})
+			}
+			openMerge = nil
+			s.End = ev.Timestamp
+			outcome := strings.TrimPrefix(string(ev.Type), "merge_")
+			s.Attributes["alt.merge.outcome"] = outcome
+			for k, v := range eventAttributes(ev) {
+				s.Attributes[k] = v
+			}
+			if ev.Type != events.MergeSuccess {
+				s.Error = true
+				s.Status = "merge " + outcome
+			}
+			switch ev.Type {
+			case events.MergeSuccess:
+				root.Attributes["alt.task.outcome"] = "merged"
+			case events.MergeFailed:
+				root.Attributes["alt.task.outcome"] = "merge_failed"
+				root.Error = true
+				root.Status = "merge failed"
+			}
+
+		case events.TaskFailed:
+			root.Attributes["alt.task.outcome"] = "failed"
+			root.Error = true
+			root.Status = "task failed"
+			annotate(ev)
+
+		default:
+			annotate(ev)
+		}
+		if Terminal(ev) {
+			tr.Complete = true
+			root.End = ev.Timestamp
+		}
+	}
+
+	// Spans still open at the end of the log close with the root.
+	for _, s := range children {
+		if s.End.IsZero() || s.End.Before(s.Start) {
+			s.End = root.End
+			if s.End.Before(s.Start) {
+				s.End = s.Start
+			}
+		}
+		if s.End.After(root.End) {
+			root.End = s.End
+		}
+	}
+
+	tr.Spans = append(tr.Spans, root)
+	for _, s := range children {
+		tr.Spans = append(tr.Spans, *s)
+	}
+	return tr
+}
+
+// agentRole returns the role recorded on a spawn event, falling back to
+// the agent ID prefix.
+func agentRole(ev events.Event) string {
+	if r, ok := ev.Data["role"].(string); ok && r != "" {
+		return r
+	}
+	if strings.HasPrefix(ev.AgentID, "resolver-") {
+		return "resolver"
+	}
+	return "worker"
+}
+
+// eventAttributes maps event data to span attributes under "alt.".
+func eventAttributes(ev events.Event) map[string]any {
+	attrs := map[string]any{}
+	if ev.AgentID != "" {
+		attrs["alt.agent.id"] = ev.AgentID
+	}
+	for k, v := range ev.Data {
+		attrs["alt."+k] = v
+	}
+	return attrs
+}
+
+// traceID derives a stable 16-byte trace ID from the task ID, so exporting
+// the same task twice yields the same trace.
+func traceID(taskID string) string {
+	sum := sha256.Sum256([]byte("altera/trace/" + taskID))
+	return hex.EncodeToString(sum[:16])
+}
+
+// spanID derives a stable 8-byte span ID.
+func spanID(taskID, name, agentID string, start time.Time) string {
+	key := fmt.Sprintf("altera/span/%s/%s/%s/%d", taskID, name, agentID, start.UnixNano())
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:8])
+}
@@ -0,0 +1,286 @@
+package tracing
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/anthropics/altera/internal/events"
+)
+
+var t0 = time.Date(2026, 2, 19, 12, 0, 0, 0, time.UTC)
+
+func at(min int, typ events.Type, agentID string, data map[string]any) events.Event {
+	return events.Event{
+		Timestamp: t0.Add(time.Duration(min) * time.Minute),
+		Type:      typ,
+		AgentID:   agentID,
+		TaskID:    "t-1",
+		Data:      data,
+	}
+}
+
+// lifecycle is a task that conflicts once and is merged after one
+// resolver attempt.
+func lifecycle() []events.Event {
+	return []events.Event{
+		at(0, events.TaskCreated, "", nil),
+		at(1, events.TaskAssigned, "w-1", nil),
+		at(1, events.AgentSpawned, "w-1", nil),
+		at(5, events.AgentWarning, "w-1", nil),
+		at(20, events.TaskDone, "w-1", nil),
+		at(21, events.MergeStarted, "w-1", nil),
+		at(21, events.MergeConflict, "w-1", map[string]any{"conflicts": []any{"a.go"}}),
+		at(22, events.AgentSpawned, "resolver-01", map[string]any{"role": "resolver", "attempt": float64(1)}),
+		at(30, events.AgentDied, "resolver-01", map[string]any{"reason": "resolved"}),
+		at(31, events.MergeStarted, "resolver-01", nil),
+		at(32, events.MergeSuccess, "resolver-01", nil),
+	}
+}
+
+func spansByName(tr Trace) map[string][]Span {
+	out := map[string][]Span{}
+	for _, s := range tr.Spans {
+		out[s.Name] = append(out[s.Name], s)
+	}
+	return out
+}
+
+func TestBuildTaskTrace(t *testing.T) {
+	traces := Build(lifecycle())
+	if len(traces) != 1 {
+		t.Fatalf("got %d traces, want 1", len(traces))
+	}
+	tr := traces[0]
+	if !tr.Complete {
+		t.Error("trace should be complete after merge_success")
+	}
+
+	root := tr.Spans[0]
+	if root.ParentID != "" || root.Name != "task t-1" {
+		t.Errorf("root = %+v", root)
+	}
+	if !root.Start.Equal(t0) || !root.End.Equal(t0.Add(32*time.Minute)) {
+		t.Errorf("root span %v - %v", root.Start, root.End)
+	}
+	if root.Attributes["alt.task.outcome"] != "merged" {
+		t.Errorf("outcome = %v", root.Attributes["alt.task.outcome"])
+	}
+
+	by := spansByName(tr)
+	worker := by["worker w-1"]
+	if len(worker) != 1 || worker[0].ParentID != root.SpanID {
+		t.Fatalf("worker span = %+v", worker)
+	}
+	if len(worker[0].Events) != 2 || worker[0].Events[0].Name != "agent_warning" {
+		t.Errorf("worker events = %+v", worker[0].Events)
+	}
+
+	resolver := by["resolver resolver-01"]
+	if len(resolver) != 1 || resolver[0].Error {
+		t.Fatalf("resolver span = %+v", resolver)
+	}
+	if d := resolver[0].End.Sub(resolver[0].Start); d != 8*time.Minute {
+		t.Errorf("resolver duration = %v", d)
+	}
+	if resolver[0].Attributes["alt.resolve.attempt"] != float64(1) {
+		t.Errorf("resolver attrs = %v", resolver[0].Attributes)
+	}
+
+	merges := by["merge"]
+	if len(merges) != 2 {
+		t.Fatalf("got %d merge spans, want 2", len(merges))
+	}
+	if !merges[0].Error || merges[0].Attributes["alt.merge.outcome"] != "conflict" {
+		t.Errorf("first merge = %+v", merges[0])
+	}
+	if merges[1].Error || merges[1].Attributes["alt.merge.outcome"] != "success" {
+		t.Errorf("second merge = %+v", merges[1])
+	}
+
+	// IDs are stable across builds.
+	again := Build(lifecycle())[0]
+	if again.TraceID != tr.TraceID || again.Spans[2].SpanID != tr.Spans[2].SpanID {
+		t.Error("trace and span IDs should be deterministic")
+	}
+}
+
+func TestBuildOpenTaskAndDeadAgent(t *testing.T) {
+	evts := []events.Event{
+		at(0, events.TaskCreated, "", nil),
+		at(1, events.AgentSpawned, "w-1", nil),
+		at(11, events.AgentDied, "w-1", map[string]any{"reason": "heartbeat timeout"}),
+		at(12, events.AgentSpawned, "w-2", nil),
+		at(15, events.WorkerStalled, "w-2", nil),
+		{Timestamp: t0, Type: events.DaemonStarted}, // no task: ignored
+	}
+	traces := Build(evts)
+	if len(traces) != 1 {
+		t.Fatalf("got %d traces", len(traces))
+	}
+	tr := traces[0]
+	if tr.Complete {
+		t.Error("open task reported complete")
+	}
+	by := spansByName(tr)
+	dead := by["worker w-1"][0]
+	if !dead.Error || dead.Attributes["alt.agent.exit_reason"] != "heartbeat timeout" {
+		t.Errorf("dead worker span = %+v", dead)
+	}
+	live := by["worker w-2"][0]
+	if !live.End.Equal(t0.Add(15 * time.Minute)) {
+		t.Errorf("open worker span should end with the root, got %v", live.End)
+	}
+}
+
+func TestEncodeOTLP(t *testing.T) {
+	data, err := EncodeOTLP(Build(lifecycle()), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var req struct {
+		ResourceSpans []struct {
+			Resource struct {
+				Attributes []struct {
+					Key   string
+					Value struct{ StringValue string }
+				}
+			}
+			ScopeSpans []struct {
+				Spans []struct {
+					TraceID           string `json:"traceId"`
+					SpanID            string `json:"spanId"`
+					ParentSpanID      string `json:"parentSpanId"`
+					StartTimeUnixNano string `json:"startTimeUnixNano"`
+					Status            struct{ Code int }
+				}
+			}
+		}
+	}
+	if err := json.Unmarshal(data, &req); err != nil {
+		t.Fatal(err)
+	}
+	rs := req.ResourceSpans[0]
+	if rs.Resource.Attributes[0].Key != "service.name" || rs.Resource.Attributes[0].Value.StringValue != DefaultServiceName {
+		t.Errorf("resource = %+v", rs.Resource)
+	}
+	spans := rs.ScopeSpans[0].Spans
+	if len(spans) != 5 {
+		t.Fatalf("got %d spans, want 5", len(spans))
+	}
+	if len(spans[0].TraceID) != 32 || len(spans[0].SpanID) != 16 || spans[0].ParentSpanID != "" {
+		t.Errorf("root span IDs = %+v", spans[0])
+	}
+	if spans[1].ParentSpanID != spans[0].SpanID {
+		t.Error("child span not parented to root")
+	}
+	if spans[0].StartTimeUnixNano != "1771502400000000000" {
+		t.Errorf("start = %s", spans[0].StartTimeUnixNano)
+	}
+}
+
+func TestTracesURL(t *testing.T) {
+	tests := map[string]string{
+		"http://localhost:4318":            "http://localhost:4318/v1/traces",
+		"http://localhost:4318/":           "http://localhost:4318/v1/traces",
+		"https://otel.local/custom/traces": "https://otel.local/custom/traces",
+	}
+	for in, want := range tests {
+		got, err := TracesURL(in)
+		if err != nil || got != want {
+			t.Errorf("TracesURL(%q) = %q, %v; want %q", in, got, err, want)
+		}
+	}
+	if _, err := TracesURL("localhost:4318"); err == nil {
+		t.Error("expected error for endpoint without scheme")
+	}
+}
+
+// collector records the bodies POSTed to /v1/traces.
+type collector struct {
+	mu     sync.Mutex
+	bodies [][]byte
+	srv    *httptest.Server
+}
+
+func newCollector(t *testing.T) *collector {
+	c := &collector{}
+	c.srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/traces" {
+			http.NotFound(w, r)
+			return
+		}
+		data, _ := io.ReadAll(r.Body)
+		c.mu.Lock()
+		c.bodies = append(c.bodies, data)
+		c.mu.Unlock()
+	}))
+	t.Cleanup(c.srv.Close)
+	return c
+}
+
+func (c *collector) count() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.bodies)
+}
+
+func TestExporter(t *testing.T) {
+	c := newCollector(t)
+	e, err := NewExporter(c.srv.URL, "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := e.Export(context.Background(), Build(lifecycle())); err != nil {
+		t.Fatalf("Export: %v", err)
+	}
+	if c.count() != 1 {
+		t.Errorf("collector got %d requests", c.count())
+	}
+}
+
+func TestRunnerExportsFinishedTasks(t *testing.T) {
+	altDir := t.TempDir()
+	evPath := filepath.Join(altDir, "events.jsonl")
+	w := events.NewWriter(evPath)
+	c := newCollector(t)
+	e, err := NewExporter(c.srv.URL, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := NewRunner(altDir, events.NewReader(evPath), e, nil)
+	r.poll = 20 * time.Millisecond
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() { r.Run(ctx); close(done) }()
+	defer func() { cancel(); <-done }()
+
+	// Wait for the runner to record its starting cursor.
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if _, ok, _ := events.LoadCursor(r.CursorPath()); ok {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("runner never saved a cursor")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	if err := w.Append(lifecycle()...); err != nil {
+		t.Fatal(err)
+	}
+	for c.count() < 1 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if c.count() != 1 {
+		t.Fatalf("collector got %d exports, want 1 (only merge_success is terminal)", c.count())
+	}
+}
@@ -0,0 +1,312 @@
+// Package usage accounts for model token usage and its cost. Usage comes
+// from two places: agents reporting it with `alt report-usage`, and the
+// daemon reading the "usage" blocks of assistant messages in each agent's
+// Claude Code JSONL transcript. Either way it is recorded as a token_usage
+// event carrying a token_cost, which is what the budget ceiling sums.
+package usage
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/anthropics/altera/internal/config"
+	"github.com/anthropics/altera/internal/events"
+	"github.com/anthropics/altera/internal/session"
+)
+
+// Event sources recorded in token_usage events.
+const (
+	SourceAgent      = "agent"
+	SourceTranscript = "transcript"
+)
+
+// Usage is a token count for one model.
+type Usage struct {
+	Model               string
+	InputTokens         int64
+	OutputTokens        int64
+	CacheCreationTokens int64
+	CacheReadTokens     int64
+}
+
+// Add accumulates o into u.
+func (u *Usage) Add(o Usage) {
+	u.InputTokens += o.InputTokens
+	u.OutputTokens += o.OutputTokens
+	u.CacheCreationTokens += o.CacheCreationTokens
+	u.CacheReadTokens += o.CacheReadTokens
+}
+
+// IsZero reports whether no tokens were counted.
+func (u Usage) IsZero() bool {
+	return u.InputTokens == 0 && u.OutputTokens == 0 && u.CacheCreationTokens == 0 && u.CacheReadTokens == 0
+}
+
+// DefaultPrices are list prices in USD per million tokens, keyed by model
+// name prefix. Project config can override or extend them.
+var DefaultPrices = map[string]config.ModelPrice{
+	"claude-opus-4-5":  {Input: 5, Output: 25, CacheWrite: 6.25, CacheRead: 0.5},
+	"claude-opus-4":    {Input: 15, Output: 75, CacheWrite: 18.75, CacheRead: 1.5},
+	"claude-sonnet-4":  {Input: 3, Output: 15, CacheWrite: 3.75, CacheRead: 0.3},
+	"claude-haiku-4-5": {Input: 1, Output: 5, CacheWrite: 1.25, CacheRead: 0.1},
+	"claude-3-5-haiku": {Input: 0.8, Output: 4, CacheWrite: 1, CacheRead: 0.08},
+}
+
+// Prices is a price table keyed by model name prefix.
+type Prices map[string]config.ModelPrice
+
+// NewPrices returns DefaultPrices with overrides applied.
+func NewPrices(overrides map[string]config.ModelPrice) Prices {
+	p := make(Prices, len(DefaultPrices)+len(overrides))
+	for k, v := range DefaultPrices {
+		p[k] = v
+	}
+	for k, v := range overrides {
+		p[k] = v
+	}
+	return p
+}
+
+// Lookup returns the price of the longest prefix of model in the table.
+func (p Prices) Lookup(model string) (config.ModelPrice, bool) {
+	best := ""
+	for prefix := range p {
+		if strings.HasPrefix(model, prefix) && len(prefix) > len(best) {
+			best = prefix
+		}
+	}
+	if best == "" {
+		return config.ModelPrice{}, false
+	}
+	return p[best], true
+}
+
+// Cost prices u. The second result is false when the model is not in the
+// table, in which case the cost is zero.
+func (p Prices) Cost(u Usage) (float64, bool) {
+	mp, ok := p.Lookup(u.Model)
+	if !ok {
+		return 0, false
+	}
+	cost := float64(u.InputTokens)*mp.Input +
+		float64(u.OutputTokens)*mp.Output +
+		float64(u.CacheCreationTokens)*mp.CacheWrite +
+		float64(u.CacheReadTokens)*mp.CacheRead
+	return cost / 1e6, true
+}
+
+// NewEvent builds the token_usage event for u.
+func NewEvent(agentID, taskID string, u Usage, cost float64, source string) events.Event {
+	return events.Event{
+		Timestamp: time.Now(),
+		Type:      events.TokenUsage,
+		AgentID:   agentID,
+		TaskID:    taskID,
+		Data: map[string]any{
+			"model":                 u.Model,
+			"input_tokens":          u.InputTokens,
+			"output_tokens":         u.OutputTokens,
+			"cache_creation_tokens": u.CacheCreationTokens,
+			"cache_read_tokens":     u.CacheReadTokens,
+			"token_cost":            cost,
+			"source":                source,
+		},
+	}
+}
+
+// transcriptLine is the subset of a Claude Code transcript entry that
+// carries usage.
+type transcriptLine struct {
+	Type    string `json:"type"`
+	Message struct {
+		ID    string `json:"id"`
+		Model string `json:"model"`
+		Usage *struct {
+			InputTokens              int64 `json:"input_tokens"`
+			OutputTokens             int64 `json:"output_tokens"`
+			CacheCreationInputTokens int64 `json:"cache_creation_input_tokens"`
+			CacheReadInputTokens     int64 `json:"cache_read_input_tokens"`
+		} `json:"usage"`
+	} `json:"message"`
+}
+
+// transcriptCursor records how far one transcript has been read.
+type transcriptCursor struct {
+	Offset      int64  `json:"offset"`
+	LastMessage string `json:"last_message,omitempty"`
+}
+
+// cursorFile is the per-agent state in .alt/usage/<agent-id>.json, keyed
+// by transcript file name.
+type cursorFile struct {
+	Transcripts map[string]transcriptCursor `json:"transcripts"`
+}
+
+// Tracker reads new usage from agents' transcripts, remembering per-agent
+// read offsets in a directory so each message is counted once across
+// daemon restarts.
+type Tracker struct {
+	dir string
+}
+
+// NewTracker creates a Tracker that keeps its cursors in dir
+// (.alt/usage).
+func NewTracker(dir string) *Tracker {
+	return &Tracker{dir: dir}
+}
+
+func (t *Tracker) cursorPath(agentID string) string {
+	return filepath.Join(t.dir, agentID+".json")
+}
+
+// Collect returns the usage added to the agent's transcripts in sessionDir
+// since the last call, one entry per model, sorted by model.
+func (t *Tracker) Collect(agentID, sessionDir string) ([]Usage, error) {
+	if sessionDir == "" {
+		return nil, nil
+	}
+	paths, err := session.FindTranscripts(sessionDir)
+	if err != nil || len(paths) == 0 {
+		return nil, err
+	}
+
+	cf := cursorFile{Transcripts: map[string]transcriptCursor{}}
+	if data, err := os.ReadFile(t.cursorPath(agentID)); err == nil {
+		_ = json.Unmarshal(data, &cf)
+		if cf.Transcripts == nil {
+			cf.Transcripts = map[string]transcriptCursor{}
+		}
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("usage: read cursor: %w", err)
+	}
+
+	byModel := map[string]*Usage{}
+	changed := false
+	for _, path := range paths {
+		name := filepath.Base(path)
+		cur := cf.Transcripts[name]
+		next, err := scanTranscript(path, cur, byModel)
+		if err != nil {
+			return nil, err
+		}
+		if next != cur {
+			cf.Transcripts[name] = next
+			changed = true
+		}
+	}
+	if changed {
+		if err := t.save(agentID, cf); err != nil {
+			return nil, err
+		}
+	}
+
+	out := make([]Usage, 0, len(byModel))
+	for _, u := range byModel {
+		if !u.IsZero() {
+			out = append(out, *u)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Model < out[j].Model })
+	return out, nil
+}
+
+// scanTranscript adds the usage of complete lines after cur.Offset to
+// byModel and returns the new cursor. Claude Code writes one line per
+// content block, each repeating the message's usage, so lines are counted
+// once per message ID.
+func scanTranscript(path string, cur transcriptCursor, byModel map[string]*Usage) (transcriptCursor, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return cur, fmt.Errorf("usage: open transcript: %w", err)
+	}
+	defer func() { _ = f.Close() }()
+
+	info, err := f.Stat()
+	if err != nil {
+		return cur, fmt.Errorf("usage: stat transcript: %w", err)
+	}
+	if info.Size() < cur.Offset {
+		cur = transcriptCursor{} // truncated or replaced: start over
+	}
+	if info.Size() == cur.Offset {
+		return cur, nil
+	}
+	data, err := io.ReadAll(io.NewSectionReader(f, cur.Offset, info.Size()-cur.Offset))
+	if err != nil {
+		return cur, fmt.Errorf("usage: read transcript: %w", err)
+	}
+	end := bytes.LastIndexByte(data, '\n')
+	if end < 0 {
+		return cur, nil // no complete line yet
+	}
+
+	seen := map[string]bool{}
+	if cur.LastMessage != "" {
+		seen[cur.LastMessage] = true
+	}
+	for _, line := range bytes.Split(data[:end], []byte("\n")) {
+		var tl transcriptLine
+		if len(line) == 0 || json.Unmarshal(line, &tl) != nil {
+			continue
+		}
+		if tl.Type != "assistant" || tl.Message.Usage == nil {
+			continue
+		}
+		if id := tl.Message.ID; id != "" {
+			cur.LastMessage = id
+			if seen[id] {
+				continue
+			}
+			seen[id] = true
+		}
+		u := byModel[tl.Message.Model]
+		if u == nil {
+			u = &Usage{Model: tl.Message.Model}
+			byModel[tl.Message.Model] = u
+		}
+		u.Add(Usage{
+			InputTokens:         tl.Message.Usage.InputTokens,
+			OutputTokens:        tl.Message.Usage.OutputTokens,
+			CacheCreationTokens: tl.Message.Usage.CacheCreationInputTokens,
+			CacheReadTokens:     tl.Message.Usage.CacheReadInputTokens,
+		})
+	}
+	cur.Offset += int64(end) + 1
+	return cur, nil
+}
+
+func (t *Tracker) save(agentID string, cf cursorFile) error {
+	if err := os.MkdirAll(t.dir, 0o755); err != nil {
+		return fmt.Errorf("usage: create dir: %w", err)
+	}
+	data, err := json.MarshalIndent(cf, "", "  ")
+	if err != nil {
+		return fmt.Errorf("usage: marshal cursor: %w", err)
+	}
+	tmp, err := os.CreateTemp(t.dir, ".tmp-*")
+	if err != nil {
+		return fmt.Errorf("usage: write cursor: %w", err)
+	}
+	if _, err := tmp.Write(append(data, '\n')); err != nil {
+		_ = tmp.Close()
+		_ = os.Remove(tmp.Name())
+		return fmt.Errorf("usage: write cursor: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		_ = os.Remove(tmp.Name())
+		return fmt.Errorf("usage: write cursor: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), t.cursorPath(agentID)); err != nil {
+		_ = os.Remove(tmp.Name())
+		return fmt.Errorf("usage: write cursor: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,86 @@
+package usage
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/anthropics/altera/internal/config"
+)
+
+func TestPricesLookupAndCost(t *testing.T) {
+	p := NewPrices(map[string]config.ModelPrice{"custom-model": {Input: 2, Output: 4}})
+
+	if mp, ok := p.Lookup("claude-opus-4-5-20251101"); !ok || mp.Input != 5 {
+		t.Errorf("opus 4.5 = %+v, %v (longest prefix should win)", mp, ok)
+	}
+	if mp, ok := p.Lookup("claude-opus-4-1-20250805"); !ok || mp.Input != 15 {
+		t.Errorf("opus 4.1 = %+v, %v", mp, ok)
+	}
+	if _, ok := p.Lookup("gpt-4"); ok {
+		t.Error("unknown model should not match")
+	}
+
+	cost, ok := p.Cost(Usage{Model: "claude-sonnet-4-5", InputTokens: 1_000_000, OutputTokens: 100_000, CacheReadTokens: 1_000_000})
+	if !ok || math.Abs(cost-(3+1.5+0.3)) > 1e-9 {
+		t.Errorf("sonnet cost = %v, %v", cost, ok)
+	}
+	if cost, _ := p.Cost(Usage{Model: "custom-model", InputTokens: 500_000}); cost != 1 {
+		t.Errorf("override cost = %v", cost)
+	}
+}
+
+const transcript = `{"type":"user","message":{"role":"user","content":"hi"}}
+{"type":"assistant","message":{"id":"msg_1","model":"claude-sonnet-4-5","usage":{"input_tokens":100,"output_tokens":20,"cache_read_input_tokens":1000}}}
+{"type":"assistant","message":{"id":"msg_1","model":"claude-sonnet-4-5","usage":{"input_tokens":100,"output_tokens":20,"cache_read_input_tokens":1000}}}
+{"type":"assistant","message":{"id":"msg_2","model":"claude-haiku-4-5","usage":{"input_tokens":10,"output_tokens":5}}}
+`
+
+func TestTrackerCollect(t *testing.T) {
+	sessionDir := t.TempDir()
+	path := filepath.Join(sessionDir, "s1.jsonl")
+	if err := os.WriteFile(path, []byte(transcript), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	tr := NewTracker(filepath.Join(t.TempDir(), "usage"))
+
+	got, err := tr.Collect("w-1", sessionDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 {
+		t.Fatalf("got %d usages, want 2: %+v", len(got), got)
+	}
+	if got[0].Model != "claude-haiku-4-5" || got[0].InputTokens != 10 {
+		t.Errorf("haiku usage = %+v", got[0])
+	}
+	if got[1].InputTokens != 100 || got[1].CacheReadTokens != 1000 {
+		t.Errorf("sonnet usage = %+v (duplicate message lines must count once)", got[1])
+	}
+
+	// Nothing new: nothing reported.
+	if got, _ := tr.Collect("w-1", sessionDir); len(got) != 0 {
+		t.Errorf("second collect = %+v, want none", got)
+	}
+
+	// A partial line is held back until it is complete, and a repeat of
+	// the last message is not counted again.
+	f, _ := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0)
+	_, _ = f.WriteString(`{"type":"assistant","message":{"id":"msg_2","model":"claude-haiku-4-5","usage":{"input_tokens":10,"output_tokens":5}}}` + "\n")
+	_, _ = f.WriteString(`{"type":"assistant","message":{"id":"msg_3","model":"claude-haiku-4-5","usage":{"input_tokens":7`)
+	if got, _ := tr.Collect("w-1", sessionDir); len(got) != 0 {
+		t.Errorf("collect with partial line = %+v, want none", got)
+	}
+	_, _ = f.WriteString(`,"output_tokens":1}}}` + "\n")
+	_ = f.Close()
+	got, _ = tr.Collect("w-1", sessionDir)
+	if len(got) != 1 || got[0].InputTokens != 7 {
+		t.Errorf("collect after completing line = %+v", got)
+	}
+
+	// Cursors persist across trackers.
+	if got, _ := NewTracker(tr.dir).Collect("w-1", sessionDir); len(got) != 0 {
+		t.Errorf("new tracker re-counted usage: %+v", got)
+	}
+}
@@ -154,11 +154,20 @@ func (m *Manager) SpawnWorker(t *task.Task) (*agent.Agent, error) {
 		return nil, fmt.Errorf("creating tmux session: %w", err)
 	}
 
-	initialPrompt := fmt.Sprintf(
-		"Read task.json, then run alt help worker startup for full instructions. When finished, run: alt task-done %s %s",
-		t.ID, id,
-	)
-	claudeCmd := fmt.Sprintf("cd %s && env -u CLAUDECODE ALT_AGENT_ID=%s claude --dangerously-skip-permissions %q", worktreePath, id, initialPrompt)
+	profile, err := agent.Profile(cfg, agent.RoleWorker, agent.ProfileName(t.Tags))
+	if err != nil {
+		_ = tmux.KillSession(sessionName)
+		cleanup()
+		return nil, err
+	}
+	claudeCmd, err := agent.LaunchCommand(profile, agent.LaunchData{
+		AgentID: id, TaskID: t.ID, Role: agent.RoleWorker, Dir: worktreePath,
+	})
+	if err != nil {
+		_ = tmux.KillSession(sessionName)
+		cleanup()
+		return nil, err
+	}
 	if err := tmux.SendKeys(sessionName, claudeCmd); err != nil {
 		_ = tmux.KillSession(sessionName)
 		cleanup()